@@ -0,0 +1,180 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "path"
+    "slices"
+
+    "github.com/spf13/cobra"
+    "github.com/verlyn13/ds-go/internal/branch"
+    "github.com/verlyn13/ds-go/internal/scan"
+    "github.com/verlyn13/ds-go/internal/ui"
+)
+
+// repoPatterns narrows branch and sync to repositories whose name matches
+var repoPatterns []string
+
+var branchCmd = &cobra.Command{
+    Use:   "branch",
+    Short: "Create, switch or delete a branch across repositories",
+    Long: `Work on the same branch in many repositories. Select repositories with
+--account and --repo (name globs, repeatable). Checkouts refuse repositories
+with uncommitted work unless --allow-dirty is given; take a ds snapshot-wip
+first if in doubt.`,
+}
+
+var branchCreateCmd = &cobra.Command{
+    Use:   "create <name>",
+    Short: "Create a branch in each repository and switch to it",
+    Args:  cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        repos, err := branchRepos()
+        if err != nil { return err }
+        from, _ := cmd.Flags().GetString("from")
+        noSwitch, _ := cmd.Flags().GetBool("no-switch")
+        allowDirty, _ := cmd.Flags().GetBool("allow-dirty")
+        return printBranchResults(branch.Create(repos, args[0], from, !noSwitch, allowDirty))
+    },
+}
+
+var branchSwitchCmd = &cobra.Command{
+    Use:   "switch <name>",
+    Short: "Switch to a branch in each repository that has it",
+    Long:  `Check out a branch in each repository that has it locally or on origin; a branch only on origin is created tracking it. Repositories without the branch are left alone.`,
+    Args:  cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        repos, err := branchRepos()
+        if err != nil { return err }
+        allowDirty, _ := cmd.Flags().GetBool("allow-dirty")
+        return printBranchResults(branch.Switch(repos, args[0], allowDirty))
+    },
+}
+
+var branchDeleteCmd = &cobra.Command{
+    Use:   "delete <name>",
+    Short: "Delete a local branch from each repository that has it",
+    Args:  cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        repos, err := branchRepos()
+        if err != nil { return err }
+        force, _ := cmd.Flags().GetBool("force")
+        return printBranchResults(branch.Delete(repos, args[0], force))
+    },
+}
+
+var syncCmd = &cobra.Command{
+    Use:   "sync",
+    Short: "Fast-forward default branches, optionally rebasing the current branch",
+    Long: `Fetch origin and fast-forward each repository's default branch to
+origin's, without checking it out. With --rebase, a repository on another
+branch has it rebased onto the default branch; a rebase that conflicts is
+aborted and reported. A diverged default branch is reported, never merged.`,
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        repos, err := branchRepos()
+        if err != nil { return err }
+        noFetch, _ := cmd.Flags().GetBool("no-fetch")
+        rebase, _ := cmd.Flags().GetBool("rebase")
+        allowDirty, _ := cmd.Flags().GetBool("allow-dirty")
+        results := branch.Sync(repos, branch.SyncOptions{Fetch: !noFetch, Rebase: rebase, AllowDirty: allowDirty})
+        var fail int
+        for _, r := range results {
+            if !r.Success { fail++ }
+        }
+        err = printer.Print(ui.Output{
+            Data:   results,
+            Failed: fail > 0,
+            Table: func() error {
+                for _, r := range results {
+                    switch {
+                    case !r.Success:
+                        fmt.Printf("  ✗ %s: %s\n", r.Repo, r.Error)
+                    case r.Rebased > 0:
+                        fmt.Printf("  ✓ %s %s +%d, %s rebased (%d commits)\n", r.Repo, r.Branch, r.FastForwarded, r.Current, r.Rebased)
+                    case r.Action == branch.ActionFastForwarded:
+                        fmt.Printf("  ✓ %s %s +%d\n", r.Repo, r.Branch, r.FastForwarded)
+                    default:
+                        fmt.Printf("  ✓ %s %s %s\n", r.Repo, r.Branch, r.Action)
+                    }
+                }
+                fmt.Printf("Synced %d repos: %d ok, %d failed\n", len(results), len(results)-fail, fail)
+                return nil
+            },
+        })
+        if err != nil { return err }
+        if fail > 0 { os.Exit(30) }
+        return nil
+    },
+}
+
+// branchRepos scans the workspace, narrowed by --account and --repo
+func branchRepos() ([]scan.Repository, error) {
+    repos, err := wipRepos()
+    if err != nil { return nil, err }
+    if len(repoPatterns) == 0 { return repos, nil }
+    for _, pat := range repoPatterns {
+        if _, err := path.Match(pat, ""); err != nil { return nil, fmt.Errorf("invalid --repo pattern %q", pat) }
+    }
+    return slices.DeleteFunc(repos, func(r scan.Repository) bool {
+        return !slices.ContainsFunc(repoPatterns, func(pat string) bool {
+            ok, _ := path.Match(pat, r.Name)
+            return ok
+        })
+    }), nil
+}
+
+// printBranchResults prints per-repository results, leaving repositories
+// without the branch out of the table, and exits 30 if any failed
+func printBranchResults(results []branch.Result) error {
+    var fail int
+    for _, r := range results {
+        if !r.Success { fail++ }
+    }
+    err := printer.Print(ui.Output{
+        Data:   results,
+        Failed: fail > 0,
+        Table: func() error {
+            shown := 0
+            for _, r := range results {
+                switch {
+                case !r.Success:
+                    fmt.Printf("  ✗ %s: %s\n", r.Repo, r.Error)
+                case r.Action == branch.ActionMissing:
+                    continue
+                case r.Action == branch.ActionCreated && r.Switched:
+                    fmt.Printf("  ✓ %s %s created, switched\n", r.Repo, r.Branch)
+                default:
+                    fmt.Printf("  ✓ %s %s %s\n", r.Repo, r.Branch, r.Action)
+                }
+                shown++
+            }
+            fmt.Printf("%d repos: %d ok, %d failed, %d without the branch\n", len(results), len(results)-fail, fail, len(results)-shown)
+            return nil
+        },
+    })
+    if err != nil { return err }
+    if fail > 0 { os.Exit(30) }
+    return nil
+}
+
+func init() {
+    branchCreateCmd.Flags().String("from", "", "start point (default: each repository's HEAD)")
+    branchCreateCmd.Flags().Bool("no-switch", false, "create the branch without checking it out")
+    branchCreateCmd.Flags().Bool("allow-dirty", false, "check out in repositories with uncommitted work")
+    branchSwitchCmd.Flags().Bool("allow-dirty", false, "check out in repositories with uncommitted work")
+    branchDeleteCmd.Flags().Bool("force", false, "delete branches that are not merged")
+    syncCmd.Flags().Bool("no-fetch", false, "use the remote-tracking branches as they are")
+    syncCmd.Flags().Bool("rebase", false, "rebase the current branch onto the default branch")
+    syncCmd.Flags().Bool("allow-dirty", false, "fast-forward a checked out default branch despite uncommitted work")
+    for _, c := range []*cobra.Command{branchCmd, syncCmd} {
+        c.PersistentFlags().StringVar(&scanPath, "path", "", "path to scan (default: ~/Projects)")
+        c.PersistentFlags().StringVarP(&accountFilter, "account", "a", "", "only repositories for this account")
+        c.PersistentFlags().StringSliceVarP(&repoPatterns, "repo", "r", nil, "only repositories whose name matches these globs")
+    }
+    branchCmd.AddCommand(branchCreateCmd)
+    branchCmd.AddCommand(branchSwitchCmd)
+    branchCmd.AddCommand(branchDeleteCmd)
+    rootCmd.AddCommand(branchCmd)
+    rootCmd.AddCommand(syncCmd)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "os/signal"
+    "slices"
+    "strings"
+
+    "github.com/spf13/cobra"
+    "github.com/verlyn13/ds-go/internal/config"
+    "github.com/verlyn13/ds-go/internal/events"
+    "github.com/verlyn13/ds-go/internal/scan"
+    "github.com/verlyn13/ds-go/internal/ui"
+)
+
+var eventsCmd = &cobra.Command{
+    Use:   "events",
+    Short: "Show the workspace event log",
+    Long: `Print recent events from the event log, or follow it with --follow.
+
+Events: repo.dirty (a clean repository got uncommitted changes), repo.stale
+(still dirty after events.stale_after, 72h by default), repo.behind (its
+upstream gained commits), fetch.failed, policy.failed and organize.applied.
+ds status, scan, fetch, organize and policy check record them, as does ds serve.
+Webhooks in the events section of the config receive each event as a signed
+JSON POST, and events.hooks run shell commands for the events they name.`,
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        cfg, err := config.Load(cfgFile)
+        if err != nil { return fmt.Errorf("loading config: %w", err) }
+        types, _ := cmd.Flags().GetStringSlice("type")
+        for _, t := range types {
+            if !events.ValidType(t) { return fmt.Errorf("unknown event type %q (use %s)", t, strings.Join(events.Types, ", ")) }
+        }
+        n, _ := cmd.Flags().GetInt("lines")
+        follow, _ := cmd.Flags().GetBool("follow")
+        if follow && !slices.Contains([]ui.Format{ui.FormatTable, ui.FormatNDJSON, ui.FormatTemplate}, printer.Format) {
+            return fmt.Errorf("--follow supports table, ndjson and template output")
+        }
+
+        log := events.NewBus(cfg.Events).Log()
+        all, err := log.Read("")
+        if err != nil { return fmt.Errorf("reading %s: %w", log.Path(), err) }
+        last := ""
+        if len(all) > 0 { last = all[len(all)-1].ID }
+        list := slices.DeleteFunc(all, func(e events.Event) bool { return !e.Match(types) })
+        if n > 0 && len(list) > n { list = list[len(list)-n:] }
+        if err := printEvents(list); err != nil { return err }
+        if !follow { return nil }
+
+        ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+        defer stop()
+        for e, err := range log.Follow(ctx, last) {
+            if err != nil { return err }
+            if !e.Match(types) { continue }
+            if err := printEvents([]events.Event{e}); err != nil { return err }
+        }
+        return nil
+    },
+}
+
+func printEvents(list []events.Event) error {
+    return printer.Print(ui.Output{
+        Data: list,
+        Table: func() error {
+            for _, e := range list {
+                fmt.Printf("%s  %-16s %s\n", e.Time.Local().Format("2006-01-02 15:04:05"), e.Type, eventSummary(e))
+            }
+            return nil
+        },
+    })
+}
+
+// eventSummary is the table description of e
+func eventSummary(e events.Event) string {
+    switch e.Type {
+    case events.RepoDirty:
+        return fmt.Sprintf("%s: %v uncommitted on %v", e.Repo, e.Data["uncommitted"], e.Data["branch"])
+    case events.RepoStale:
+        return fmt.Sprintf("%s: dirty for %v on %v", e.Repo, e.Data["dirty_for"], e.Data["branch"])
+    case events.RepoBehind:
+        return fmt.Sprintf("%s: %v behind on %v (+%v)", e.Repo, e.Data["behind"], e.Data["branch"], e.Data["new_commits"])
+    case events.FetchFailed:
+        return fmt.Sprintf("%s: %v", e.Repo, e.Data["error"])
+    case events.PolicyFailed:
+        name := e.Repo
+        if name == "" { name = e.Path }
+        return fmt.Sprintf("%s: %v", name, e.Data["checks"])
+    case events.OrganizeApplied:
+        return fmt.Sprintf("%v moved, %v failed", e.Data["moved"], e.Data["failed"])
+    }
+    return e.Repo
+}
+
+// publish sends evs to the config's event log, webhooks and hooks, waiting
+// for deliveries and hook commands so they are not cut off when ds exits
+func publish(cfg *config.Config, evs ...events.Event) {
+    if len(evs) == 0 { return }
+    bus := events.NewBus(cfg.Events)
+    bus.Publish(evs...)
+    bus.Close()
+}
+
+// observe publishes the events a scan produces against the previous one
+func observe(cfg *config.Config, repos []scan.Repository) {
+    evs, err := events.NewDetector(events.DefaultState(), cfg.Events.StaleAfterDuration()).Observe(repos)
+    if err != nil { fmt.Fprintf(os.Stderr, "Warning: events: %v\n", err) }
+    publish(cfg, evs...)
+}
+
+func init() {
+    eventsCmd.Flags().BoolP("follow", "f", false, "keep printing events as they are logged")
+    eventsCmd.Flags().IntP("lines", "n", 20, "number of past events to show (0 for all)")
+    eventsCmd.Flags().StringSlice("type", nil, "only events of these types")
+    rootCmd.AddCommand(eventsCmd)
+}
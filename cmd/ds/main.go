@@ -1,18 +1,22 @@
 package main
 
 import (
-    "encoding/json"
     "fmt"
     "os"
     "os/exec"
+    "path/filepath"
+    "slices"
     "strings"
     "time"
 
     "github.com/spf13/cobra"
+    "github.com/verlyn13/ds-go/internal/auth"
     "github.com/verlyn13/ds-go/internal/config"
+    "github.com/verlyn13/ds-go/internal/events"
     "github.com/verlyn13/ds-go/internal/server"
     "github.com/verlyn13/ds-go/internal/scan"
     "github.com/verlyn13/ds-go/internal/ui"
+    "github.com/verlyn13/ds-go/internal/hooks"
     "github.com/verlyn13/ds-go/internal/policy"
     "github.com/verlyn13/ds-go/internal/runner"
 )
@@ -28,12 +32,29 @@ var (
     quietMode   bool
     workerCount int
     exitOnDirty bool
+    outputFormat   string
+    outputTemplate string
+    printer        *ui.Printer
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "ds",
 	Short: "Dead Simple repository manager",
 	Long:  `A blazing fast Git repository scanner and status reporter.`,
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// --json is shorthand for --output json
+		format := outputFormat
+		if jsonOutput && !cmd.Flags().Changed("output") {
+			format = string(ui.FormatJSON)
+		}
+		p, err := ui.NewPrinter(format, outputTemplate)
+		if err != nil {
+			return err
+		}
+		printer = p
+		return nil
+	},
 }
 
 var statusCmd = &cobra.Command{
@@ -46,11 +67,20 @@ var statusCmd = &cobra.Command{
 			return fmt.Errorf("loading config: %w", err)
 		}
 
+		columns, _ := cmd.Flags().GetStringSlice("columns")
+		sortBy, _ := cmd.Flags().GetString("sort")
+		groupBy, _ := cmd.Flags().GetString("group-by")
+		opts := ui.TableOptions{Columns: columns, SortBy: sortBy, GroupBy: groupBy}.WithDefaults(cfg)
+		if err := opts.Validate(); err != nil {
+			return err
+		}
+
 		scanner := scan.New(cfg, workerCount)
 		repos, err := scanner.Scan(scanPath)
 		if err != nil {
 			return fmt.Errorf("scanning repos: %w", err)
 		}
+		observe(cfg, repos)
 
 		// Apply filters
 		if dirtyOnly {
@@ -59,15 +89,12 @@ var statusCmd = &cobra.Command{
 		if accountFilter != "" {
 			repos = filterByAccount(repos, accountFilter)
 		}
+		scan.SortRepos(repos, opts.SortBy)
 
-        if jsonOutput {
-            if err := ui.PrintJSON(repos); err != nil { return err }
-            if exitOnDirty && len(filterDirty(repos)) > 0 {
-                os.Exit(10)
-            }
-            return nil
-        }
-        if err := ui.PrintTable(repos, cfg); err != nil { return err }
+        if err := printer.Print(ui.Output{
+            Data:  repos,
+            Table: func() error { return ui.PrintTable(repos, opts) },
+        }); err != nil { return err }
         if exitOnDirty && len(filterDirty(repos)) > 0 {
             os.Exit(10)
         }
@@ -75,6 +102,25 @@ var statusCmd = &cobra.Command{
     },
 }
 
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Interactive repository dashboard",
+	Long:  `Full-screen dashboard with a live repository list, detail pane, and keybindings to fetch, pull, open a shell or editor, or run a saved command.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("loading config: %w", err)
+		}
+
+		refresh, _ := cmd.Flags().GetDuration("refresh")
+		return ui.RunDashboard(cfg, ui.DashboardOptions{
+			ScanPath: scanPath,
+			Workers:  workerCount,
+			Refresh:  refresh,
+		})
+	},
+}
+
 var fetchCmd = &cobra.Command{
 	Use:     "fetch",
 	Aliases: []string{"f"},
@@ -92,14 +138,17 @@ var fetchCmd = &cobra.Command{
 		}
 
         fetcher := scan.NewFetcher(workerCount)
-        results := fetcher.FetchAll(repos, !quietMode)
-        if jsonOutput {
-            return ui.PrintJSONFetchResults(results)
-        }
-        if !quietMode {
-            ui.PrintFetchResults(results)
-        }
-        return nil
+        results := fetcher.FetchAll(repos, !quietMode && printer.IsTable())
+        publish(cfg, events.FetchFailures(repos, results)...)
+        // Rescan so upstream commits just fetched produce repo.behind
+        if fetched, err := scanner.Scan(scanPath); err == nil { observe(cfg, fetched) }
+        return printer.Print(ui.Output{
+            Data: results,
+            Table: func() error {
+                if !quietMode { ui.PrintFetchResults(results) }
+                return nil
+            },
+        })
     },
 }
 
@@ -117,27 +166,29 @@ var scanCmd = &cobra.Command{
 		if fetchFirst {
 			repos, _ := scanner.Scan(scanPath)
 			fetcher := scan.NewFetcher(workerCount)
-			fetcher.FetchAll(repos, !quietMode)
+			results := fetcher.FetchAll(repos, !quietMode && printer.IsTable())
+			publish(cfg, events.FetchFailures(repos, results)...)
 		}
 		
 		repos, err := scanner.Scan(scanPath)
 		if err != nil {
 			return fmt.Errorf("scanning: %w", err)
 		}
+		observe(cfg, repos)
 
         // Save index
         if err := scanner.SaveIndex(repos); err != nil {
             return fmt.Errorf("saving index: %w", err)
         }
 
-        if jsonOutput {
-            type scanSummary struct{ Count int `json:"count"` }
-            enc := json.NewEncoder(os.Stdout)
-            enc.SetIndent("", "  ")
-            return enc.Encode(scanSummary{Count: len(repos)})
-        }
-        fmt.Printf("Scanned %d repositories\n", len(repos))
-        return nil
+        type scanSummary struct{ Count int `json:"count"` }
+        return printer.Print(ui.Output{
+            Data: scanSummary{Count: len(repos)},
+            Table: func() error {
+                fmt.Printf("Scanned %d repositories\n", len(repos))
+                return nil
+            },
+        })
     },
 }
 
@@ -151,20 +202,112 @@ var initCmd = &cobra.Command{
 }
 
 var cloneCmd = &cobra.Command{
-	Use:   "clone <repo-url>",
-	Short: "Clone a repository with proper SSH config",
-	Long:  `Clone a GitHub repository using the appropriate SSH host configuration based on the owner.`,
-	Args:  cobra.ExactArgs(1),
+	Use:   "clone [repo-url...]",
+	Short: "Clone repositories with proper SSH config",
+	Long: `Clone GitHub repositories using the appropriate SSH host configuration based on the owner.
+URLs can be given as arguments, read from a file with --file, or piped on stdin (one per line).
+Repositories already present anywhere in the index are skipped.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, err := config.Load(cfgFile)
 		if err != nil {
 			return fmt.Errorf("loading config: %w", err)
 		}
-		
-		return scan.CloneRepo(args[0], cfg, clonePath)
+
+		urls, err := cloneInputs(cmd, args)
+		if err != nil {
+			return err
+		}
+		if len(urls) == 0 {
+			return fmt.Errorf("provide at least one repository URL, --file, or URLs on stdin")
+		}
+		if clonePath != "" && len(urls) > 1 {
+			return fmt.Errorf("--path can only be used when cloning a single repository")
+		}
+
+		var opts scan.CloneOptions
+		opts.Depth, _ = cmd.Flags().GetInt("depth")
+		opts.Branch, _ = cmd.Flags().GetString("branch")
+		opts.Filter, _ = cmd.Flags().GetString("filter")
+		opts.RecurseSubmodules, _ = cmd.Flags().GetBool("recurse-submodules")
+
+		// Use the saved index to find repos that already exist elsewhere;
+		// fall back to a scan when no index has been built yet
+		scanner := scan.New(cfg, workerCount)
+		existing, err := scanner.LoadIndex()
+		if err != nil || len(existing) == 0 {
+			existing, _ = scanner.Scan("")
+		}
+
+		cloner := scan.NewCloner(cfg, workerCount)
+		results := cloner.CloneAll(urls, existing, opts, clonePath, !quietMode && printer.IsTable())
+
+		var failed int
+		for _, r := range results {
+			if !r.Success {
+				failed++
+			}
+		}
+		if err := printer.Print(ui.Output{
+			Data:   results,
+			Failed: failed > 0,
+			Table: func() error {
+				if !quietMode {
+					ui.PrintCloneResults(results)
+				}
+				return nil
+			},
+		}); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d clones failed", failed, len(results))
+		}
+		return nil
 	},
 }
 
+// cloneInputs collects URLs from args, --file, and stdin ("-" or piped input)
+func cloneInputs(cmd *cobra.Command, args []string) ([]string, error) {
+	var urls []string
+	readStdin := false
+	for _, a := range args {
+		if a == "-" {
+			readStdin = true
+			continue
+		}
+		urls = append(urls, a)
+	}
+
+	file, _ := cmd.Flags().GetString("file")
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("opening url list: %w", err)
+		}
+		defer f.Close()
+		list, err := scan.ReadURLList(f)
+		if err != nil {
+			return nil, fmt.Errorf("reading url list: %w", err)
+		}
+		urls = append(urls, list...)
+	}
+
+	if len(args) == 0 && file == "" {
+		if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice == 0 {
+			readStdin = true
+		}
+	}
+	if readStdin {
+		list, err := scan.ReadURLList(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("reading stdin: %w", err)
+		}
+		urls = append(urls, list...)
+	}
+
+	return urls, nil
+}
+
 var cdCmd = &cobra.Command{
 	Use:   "cd <repo-name>",
 	Short: "Print path to change directory to a repository",
@@ -187,8 +330,13 @@ var cdCmd = &cobra.Command{
 			if repo.Name == repoName || 
 			   strings.Contains(repo.Path, "/"+repoName) ||
 			   strings.HasSuffix(repo.Path, repoName) {
-				fmt.Print(repo.Path)
-				return nil
+				return printer.Print(ui.Output{
+					Data: repo,
+					Table: func() error {
+						fmt.Print(repo.Path)
+						return nil
+					},
+				})
 			}
 		}
 		
@@ -211,10 +359,8 @@ var configViewCmd = &cobra.Command{
 			return fmt.Errorf("loading config: %w", err)
 		}
 		
-		if jsonOutput {
-			encoder := json.NewEncoder(os.Stdout)
-			encoder.SetIndent("", "  ")
-			return encoder.Encode(cfg)
+		if !printer.IsTable() {
+			return printer.Print(ui.Output{Data: cfg})
 		}
 		
 		// Print in readable format
@@ -299,16 +445,18 @@ Repositories will be organized as: ~/Projects/account/repo-name`,
 
         if plan {
             plans := scan.OrganizePlanJSON(repos, cfg)
-            if jsonOutput {
-                return ui.PrintJSONResponse(true, plans, nil)
-            }
-            for _, p := range plans {
-                mark := "USR"
-                if p.IsOrg { mark = "ORG" }
-                fmt.Printf("[%s] %s -> %s\n", mark, p.OldPath, p.NewPath)
-            }
-            fmt.Printf("%d moves planned\n", len(plans))
-            return nil
+            return printer.Print(ui.Output{
+                Data: plans,
+                Table: func() error {
+                    for _, p := range plans {
+                        mark := "USR"
+                        if p.IsOrg { mark = "ORG" }
+                        fmt.Printf("[%s] %s -> %s\n", mark, p.OldPath, p.NewPath)
+                    }
+                    fmt.Printf("%d moves planned\n", len(plans))
+                    return nil
+                },
+            })
         }
 
         if requireClean {
@@ -319,7 +467,27 @@ Repositories will be organized as: ~/Projects/account/repo-name`,
             }
         }
 
-        return scan.OrganizeRepos(repos, cfg, dryRun, force)
+        if printer.IsTable() {
+            results, err := scan.OrganizeRepos(repos, cfg, dryRun, force)
+            publish(cfg, events.Organized(results)...)
+            return err
+        }
+        // Structured output cannot prompt for confirmation
+        if !dryRun && !force {
+            return fmt.Errorf("use --dry-run or --force with --output %s", printer.Format)
+        }
+        results, moved, failed := scan.ApplyOrganizePlan(repos, cfg, dryRun, force)
+        publish(cfg, events.Organized(results)...)
+        type organizeSummary struct {
+            Moved   int                   `json:"moved"`
+            Failed  int                   `json:"failed"`
+            Results []scan.OrganizeResult `json:"results"`
+        }
+        return printer.Print(ui.Output{
+            Data:   organizeSummary{Moved: moved, Failed: failed, Results: results},
+            Items:  results,
+            Failed: failed > 0,
+        })
     },
 }
 
@@ -329,23 +497,33 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default: $XDG_CONFIG_HOME/ds/config.yaml)")
 	rootCmd.PersistentFlags().IntVarP(&workerCount, "workers", "w", 10, "number of concurrent workers")
 	rootCmd.PersistentFlags().BoolVarP(&quietMode, "quiet", "q", false, "suppress progress output")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "table", "output format: table, json, ndjson, csv, yaml, markdown, template")
+	rootCmd.PersistentFlags().StringVar(&outputTemplate, "format", "", "Go template applied to each record, e.g. '{{.Name}} {{.Branch}}' (implies --output template)")
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "shorthand for --output json")
 
     statusCmd.Flags().BoolVarP(&dirtyOnly, "dirty", "d", false, "show only repositories with uncommitted changes")
     statusCmd.Flags().StringVarP(&accountFilter, "account", "a", "", "filter by account")
-    statusCmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON")
     statusCmd.Flags().BoolVar(&exitOnDirty, "exit-on-dirty", false, "exit with code 10 when dirty repos are found")
     statusCmd.Flags().StringVar(&scanPath, "path", "", "path to scan (default: ~/Projects)")
+    statusCmd.Flags().StringSlice("columns", nil, "columns to show, e.g. icon,name,branch,sync,last_commit (default from config display.columns)")
+    statusCmd.Flags().String("sort", "", "sort by name, account, folder, branch, last_commit, behind, ahead or dirty; prefix - to reverse")
+    statusCmd.Flags().String("group-by", "", "group rows by account, folder, tag or none")
+
+	uiCmd.Flags().StringVar(&scanPath, "path", "", "path to scan (default: ~/Projects)")
+	uiCmd.Flags().Duration("refresh", 30*time.Second, "rescan interval (0 disables live updates)")
 
 	scanCmd.Flags().StringVar(&scanPath, "path", "", "path to scan (default: ~/Projects)")
 	scanCmd.Flags().BoolVar(&fetchFirst, "fetch", false, "fetch all repos before scanning")
-	scanCmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON")
 
     fetchCmd.Flags().StringVar(&scanPath, "path", "", "path to scan (default: ~/Projects)")
-    fetchCmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON")
 
-	cloneCmd.Flags().StringVarP(&clonePath, "path", "p", "", "directory to clone into")
+	cloneCmd.Flags().StringVarP(&clonePath, "path", "p", "", "directory to clone into (single repository only)")
+	cloneCmd.Flags().StringP("file", "f", "", "read repository URLs from file (one per line)")
+	cloneCmd.Flags().Int("depth", 0, "create a shallow clone with history truncated to this many commits")
+	cloneCmd.Flags().StringP("branch", "b", "", "check out this branch instead of the remote HEAD")
+	cloneCmd.Flags().String("filter", "", "partial clone filter, e.g. blob:none")
+	cloneCmd.Flags().Bool("recurse-submodules", false, "initialize and clone submodules")
 
-	configViewCmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON")
 	
 	configCmd.AddCommand(configViewCmd)
 	configCmd.AddCommand(configEditCmd)
@@ -354,9 +532,9 @@ func init() {
     organizeCmd.Flags().Bool("force", false, "move repos even if destination exists")
     organizeCmd.Flags().Bool("plan", false, "show planned moves and exit")
     organizeCmd.Flags().Bool("require-clean", false, "abort if any repository has uncommitted changes")
-    organizeCmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON")
 
 	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(uiCmd)
 	rootCmd.AddCommand(fetchCmd)
 	rootCmd.AddCommand(scanCmd)
 	rootCmd.AddCommand(initCmd)
@@ -378,7 +556,9 @@ func initConfig() {
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if printer == nil || !printer.PrintError(err) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
 		os.Exit(1)
 	}
 }
@@ -388,17 +568,47 @@ var serveCmd = &cobra.Command{
     Short: "Start a local HTTP API for agents",
     RunE: func(cmd *cobra.Command, args []string) error {
         addr, _ := cmd.Flags().GetString("addr")
+        if listen, _ := cmd.Flags().GetString("listen"); listen != "" { addr = listen }
         token, _ := cmd.Flags().GetString("token")
         cfg, err := config.Load(cfgFile)
         if err != nil { return fmt.Errorf("loading config: %w", err) }
-        s := server.New(cfg, workerCount).WithToken(token)
+        noRaw, _ := cmd.Flags().GetBool("no-raw-exec")
+        auditPath, _ := cmd.Flags().GetString("audit-log")
+        tokenFile, _ := cmd.Flags().GetString("token-file")
+        store, err := auth.Open(tokenFile)
+        if err != nil { return fmt.Errorf("loading tokens: %w", err) }
+        origins, _ := cmd.Flags().GetStringSlice("cors-origin")
+        if !cmd.Flags().Changed("cors-origin") { origins = server.CORSOriginsFromEnv(os.Getenv("DS_CORS")) }
+        s := server.New(cfg, workerCount).WithToken(token).WithTokenStore(store).WithRawExec(!noRaw).WithAuditLog(auditPath).WithCORS(origins...)
+        ttl, _ := cmd.Flags().GetDuration("cache-ttl")
+        s.WithCacheTTL(ttl)
+        if noEvents, _ := cmd.Flags().GetBool("no-events"); !noEvents {
+            s.WithEvents(events.NewBus(cfg.Events), events.NewDetector(events.DefaultState(), cfg.Events.StaleAfterDuration()))
+        }
+        certFile, _ := cmd.Flags().GetString("tls-cert")
+        keyFile, _ := cmd.Flags().GetString("tls-key")
+        selfSigned, _ := cmd.Flags().GetBool("tls-self-signed")
+        if certFile != "" || keyFile != "" || selfSigned {
+            if strings.HasPrefix(addr, "unix://") { return fmt.Errorf("TLS is not used on Unix sockets; drop --tls-* or listen on TCP") }
+            s.WithTLS(server.TLSConfig{CertFile: certFile, KeyFile: keyFile, SelfSigned: selfSigned})
+        }
         return s.Start(addr)
     },
 }
 
 func init() {
     serveCmd.Flags().String("addr", "127.0.0.1:7777", "address to bind the local API server")
+    serveCmd.Flags().String("listen", "", "listen address: host:port, tcp://host:port or unix:///path/ds.sock (overrides --addr)")
+    serveCmd.Flags().String("tls-cert", "", "serve HTTPS with this PEM certificate")
+    serveCmd.Flags().String("tls-key", "", "PEM private key for --tls-cert")
+    serveCmd.Flags().Bool("tls-self-signed", false, "serve HTTPS with a generated self-signed certificate (kept in $XDG_STATE_HOME/ds/tls)")
     serveCmd.Flags().String("token", os.Getenv("DS_TOKEN"), "optional bearer token for API auth (overrides DS_TOKEN)")
+    serveCmd.Flags().String("token-file", auth.DefaultPath(), "scoped tokens managed with 'ds token'; any token here enables auth")
+    serveCmd.Flags().Duration("cache-ttl", server.DefaultCacheTTL, "reuse repository scans for this long; 0 rescans on every request")
+    serveCmd.Flags().StringSlice("cors-origin", nil, "browser origins allowed by CORS, e.g. http://localhost:* (overrides DS_CORS; DS_CORS=1 allows loopback origins)")
+    serveCmd.Flags().Bool("no-raw-exec", false, "reject raw shell commands on /v1/exec; only named config commands run")
+    serveCmd.Flags().String("audit-log", server.DefaultAuditLog(), "file receiving a JSON line per /v1/exec call (empty disables)")
+    serveCmd.Flags().Bool("no-events", false, "neither record events nor deliver them to webhooks; /v1/events answers 404")
 }
 
 var policyCmd = &cobra.Command{
@@ -409,43 +619,123 @@ var policyCmd = &cobra.Command{
 var policyCheckCmd = &cobra.Command{
     Use:   "check",
     Short: "Run compliance checks from .project-compliance.yaml",
+    Long:  `Run compliance checks in the current directory, or with --all in every scanned repository. Checks run in parallel with a per-check timeout.`,
     RunE: func(cmd *cobra.Command, args []string) error {
         path, _ := cmd.Flags().GetString("file")
         if path == "" { path = ".project-compliance.yaml" }
         cfg, err := policy.Load(path)
         if err != nil { return fmt.Errorf("load policy: %w", err) }
-        report, err := policy.RunChecks(cfg)
-        if err != nil { return fmt.Errorf("run checks: %w", err) }
-        if jsonOutput {
-            enc := json.NewEncoder(os.Stdout)
-            enc.SetIndent("", "  ")
-            if err := enc.Encode(report); err != nil { return err }
-        } else {
-            fmt.Printf("Checks: %d, Passed: %d, Failed: %d\n", report.Summary.Total, report.Summary.Passed, report.Summary.Failed)
-            for _, r := range report.Results {
-                mark := "✓"
-                if !r.Passed { mark = "✗" }
-                fmt.Printf(" %s %-10s %s\n", mark, r.Severity, r.Name)
-            }
-        }
-        // Exit non-zero if any critical failure
+
+        all, _ := cmd.Flags().GetBool("all")
+        timeout, _ := cmd.Flags().GetDuration("timeout")
         failOn, _ := cmd.Flags().GetString("fail-on")
+        var th policy.Severity
         if failOn != "" {
-            th, err := policy.SeverityFromString(failOn)
+            if th, err = policy.SeverityFromString(failOn); err != nil { return err }
+        }
+        reportFormat, _ := cmd.Flags().GetString("report")
+        reportFile, _ := cmd.Flags().GetString("report-file")
+        if reportFormat == "" && printer.Format == ui.FormatMarkdown { reportFormat = policy.FormatMarkdown }
+        if reportFile != "" && reportFormat == "" { return fmt.Errorf("--report-file requires --report") }
+
+        // Outside --all the ds config only routes events, so it is optional
+        dsCfg := &config.Config{}
+        if _, statErr := os.Stat(cfgFile); statErr == nil || all {
+            if dsCfg, err = config.Load(cfgFile); err != nil { return fmt.Errorf("loading config: %w", err) }
+        }
+        targets := []policy.Target{{Dir: "."}}
+        if all {
+            repos, err := scan.New(dsCfg, workerCount).Scan(scanPath)
+            if err != nil { return fmt.Errorf("scanning repos: %w", err) }
+            if accountFilter != "" { repos = filterByAccount(repos, accountFilter) }
+            targets = policyTargets(repos)
+        }
+
+        report, err := policy.Run(cmd.Context(), cfg, targets, policy.Options{Workers: workerCount, Timeout: timeout, FailOn: th})
+        if err != nil { return fmt.Errorf("run checks: %w", err) }
+        report.File = path
+        publish(dsCfg, events.PolicyFailures(report, policyEventTargets(targets))...)
+
+        switch {
+        case reportFile != "":
+            f, err := os.Create(reportFile)
+            if err != nil { return fmt.Errorf("creating report: %w", err) }
+            err = report.Write(f, reportFormat)
+            f.Close()
             if err != nil { return err }
-            if policy.FailIfAboveSeverity(report, th, cfg) {
-                os.Exit(20)
-            }
+        case reportFormat != "":
+            if err := report.Write(os.Stdout, reportFormat); err != nil { return err }
+        }
+        if reportFormat == "" || reportFile != "" {
+            if err := printer.Print(ui.Output{
+                Data:   report,
+                Items:  report.Results,
+                Failed: report.Summary.Failed > 0,
+                Table:  func() error { printPolicyReport(report); return nil },
+            }); err != nil { return err }
+        }
+        // Exit non-zero if any failure at or above the threshold
+        if code := report.ExitCode(); code != 0 {
+            os.Exit(code)
         }
         return nil
     },
 }
 
+// policyEventTargets resolves a relative target directory so events name
+// an absolute path
+func policyEventTargets(targets []policy.Target) []policy.Target {
+    out := slices.Clone(targets)
+    for i := range out {
+        if abs, err := filepath.Abs(out[i].Dir); err == nil { out[i].Dir = abs }
+    }
+    return out
+}
+
+// policyTargets turns scanned repositories into policy targets, by name
+func policyTargets(repos []scan.Repository) []policy.Target {
+    scan.SortRepos(repos, "name")
+    targets := make([]policy.Target, len(repos))
+    for i, r := range repos {
+        targets[i] = policy.Target{Name: r.Name, Dir: r.Path}
+    }
+    return targets
+}
+
+func printPolicyReport(report *policy.Report) {
+    fmt.Printf("Checks: %d, Passed: %d, Failed: %d, Warnings: %d, Waived: %d, Skipped: %d\n",
+        report.Summary.Total, report.Summary.Passed, report.Summary.Failed, report.Summary.Warnings, report.Summary.Waived, report.Summary.Skipped)
+    repo := ""
+    for _, r := range report.Results {
+        if r.Repo != repo {
+            repo = r.Repo
+            fmt.Printf("\n%s%s%s\n", ui.ColorBold, repo, ui.ColorReset)
+        }
+        mark := "✓"
+        switch r.Status {
+        case policy.StatusFailed: mark = "✗"
+        case policy.StatusWarning: mark = "!"
+        case policy.StatusWaived: mark = "~"
+        case policy.StatusSkipped: mark = "-"
+        }
+        fmt.Printf(" %s %-10s %s\n", mark, r.Severity, r.Name)
+        if r.Waiver != nil { fmt.Printf("     waived until %s: %s\n", r.Waiver.Expires, r.Waiver.Reason) }
+        if r.Status == policy.StatusPassed || r.Status == policy.StatusSkipped { continue }
+        if r.Error != "" { fmt.Printf("     %s\n", r.Error) }
+        if r.Stderr != "" { fmt.Printf("     %s\n", strings.ReplaceAll(r.Stderr, "\n", "\n     ")) }
+    }
+}
+
 func init() {
     policyCmd.AddCommand(policyCheckCmd)
     policyCheckCmd.Flags().String("file", ".project-compliance.yaml", "policy file")
-    policyCheckCmd.Flags().String("fail-on", "critical", "fail on failed checks at or above this severity")
-    policyCheckCmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON")
+    policyCheckCmd.Flags().String("fail-on", "critical", "fail on failed checks at or above this severity; failures below it are warnings")
+    policyCheckCmd.Flags().Bool("all", false, "apply the policy to every repository in the workspace")
+    policyCheckCmd.Flags().StringVar(&scanPath, "path", "", "path to scan with --all (default: ~/Projects)")
+    policyCheckCmd.Flags().StringVarP(&accountFilter, "account", "a", "", "with --all, only check repositories for this account")
+    policyCheckCmd.Flags().Duration("timeout", policy.DefaultTimeout, "per-check timeout when the check sets none")
+    policyCheckCmd.Flags().String("report", "", "write the report as json, sarif, junit or markdown instead of the normal output")
+    policyCheckCmd.Flags().String("report-file", "", "write the --report format to this file and keep the normal output")
 }
 
 var hooksCmd = &cobra.Command{
@@ -455,67 +745,226 @@ var hooksCmd = &cobra.Command{
 
 var hooksInstallCmd = &cobra.Command{
     Use:   "install",
-    Short: "Install pre-commit and pre-push hooks in the current repo",
+    Short: "Install git hooks from the policy file's workflow.git_hooks",
+    Long:  `Write a hook script for each entry in workflow.git_hooks of .project-compliance.yaml. Existing hooks are kept as <hook>.ds-prev and run first. Hooks go wherever git runs them from, including core.hooksPath.`,
     RunE: func(cmd *cobra.Command, args []string) error {
-        hooksDir := ".git/hooks"
-        if _, err := os.Stat(hooksDir); err != nil {
-            return fmt.Errorf("not a git repository (missing %s)", hooksDir)
+        targets, err := hookTargets(cmd)
+        if err != nil { return err }
+        var results []hooks.Result
+        for _, t := range targets {
+            declared, source, err := loadGitHooks(cmd, t)
+            if err == nil {
+                var rs []hooks.Result
+                if rs, err = hooks.Install(t.dir, declared, source); err == nil {
+                    results = append(results, withRepo(rs, t.name)...)
+                    continue
+                }
+            }
+            results = append(results, hooks.Result{Repo: t.name, Path: t.dir, Action: "skipped", Error: err.Error()})
         }
-        preCommit := `#!/usr/bin/env bash
-set -e
-if command -v mise >/dev/null 2>&1; then
-  mise run lint || exit 1
-  mise run test || exit 1
-else
-  golangci-lint run ./... || exit 1
-  go test ./... || exit 1
-fi
-`
-        prePush := `#!/usr/bin/env bash
-set -e
-if command -v mise >/dev/null 2>&1; then
-  mise run ci || exit 1
-else
-  golangci-lint run ./... || exit 1
-  go test ./... || exit 1
-  go build ./... || exit 1
-fi
-`
-        if err := os.WriteFile(hooksDir+"/pre-commit", []byte(preCommit), 0755); err != nil { return err }
-        if err := os.WriteFile(hooksDir+"/pre-push", []byte(prePush), 0755); err != nil { return err }
-        fmt.Println("Hooks installed: pre-commit, pre-push")
-        return nil
+        return printHookResults(results)
+    },
+}
+
+var hooksStatusCmd = &cobra.Command{
+    Use:   "status",
+    Short: "Compare installed git hooks with workflow.git_hooks",
+    RunE: func(cmd *cobra.Command, args []string) error {
+        targets, err := hookTargets(cmd)
+        if err != nil { return err }
+        var statuses []hooks.Status
+        for _, t := range targets {
+            declared, source, err := loadGitHooks(cmd, t)
+            if err != nil {
+                statuses = append(statuses, hooks.Status{Repo: t.name, Path: t.dir, State: hooks.StateNoPolicy})
+                continue
+            }
+            sts, err := hooks.Audit(t.dir, declared, source)
+            if err != nil { return err }
+            for _, st := range sts {
+                st.Repo = t.name
+                statuses = append(statuses, st)
+            }
+        }
+        var drift int
+        for _, st := range statuses {
+            if st.State != hooks.StateInstalled { drift++ }
+        }
+        return printer.Print(ui.Output{
+            Data:   statuses,
+            Failed: drift > 0,
+            Table: func() error {
+                repo := ""
+                for _, st := range statuses {
+                    if st.Repo != repo {
+                        repo = st.Repo
+                        fmt.Printf("\n%s%s%s\n", ui.ColorBold, repo, ui.ColorReset)
+                    }
+                    mark := "✓"
+                    if st.State != hooks.StateInstalled { mark = "✗" }
+                    chained := ""
+                    if st.Chained { chained = " (chains existing hook)" }
+                    fmt.Printf(" %s %-12s %s%s\n", mark, st.Hook, st.State, chained)
+                }
+                fmt.Printf("\n%d of %d hooks need attention\n", drift, len(statuses))
+                return nil
+            },
+        })
     },
 }
 
+var hooksUninstallCmd = &cobra.Command{
+    Use:   "uninstall",
+    Short: "Remove hooks installed by ds and restore chained hooks",
+    RunE: func(cmd *cobra.Command, args []string) error {
+        targets, err := hookTargets(cmd)
+        if err != nil { return err }
+        var results []hooks.Result
+        for _, t := range targets {
+            rs, err := hooks.Uninstall(t.dir)
+            if err != nil {
+                results = append(results, hooks.Result{Repo: t.name, Path: t.dir, Action: "skipped", Error: err.Error()})
+                continue
+            }
+            results = append(results, withRepo(rs, t.name)...)
+        }
+        return printHookResults(results)
+    },
+}
+
+// hookTarget is a repository that ds hooks operates on
+type hookTarget struct {
+    name string // Empty outside --all
+    dir  string
+}
+
+// hookTargets returns the current repository, or every scanned one with --all
+func hookTargets(cmd *cobra.Command) ([]hookTarget, error) {
+    all, _ := cmd.Flags().GetBool("all")
+    if !all {
+        root, _, err := hooks.Resolve(".")
+        if err != nil { return nil, err }
+        return []hookTarget{{dir: root}}, nil
+    }
+    cfg, err := config.Load(cfgFile)
+    if err != nil { return nil, fmt.Errorf("loading config: %w", err) }
+    repos, err := scan.New(cfg, workerCount).Scan(scanPath)
+    if err != nil { return nil, fmt.Errorf("scanning repos: %w", err) }
+    if accountFilter != "" { repos = filterByAccount(repos, accountFilter) }
+    scan.SortRepos(repos, "name")
+    targets := make([]hookTarget, len(repos))
+    for i, r := range repos {
+        targets[i] = hookTarget{name: r.Name, dir: r.Path}
+    }
+    return targets, nil
+}
+
+// loadGitHooks reads workflow.git_hooks from --file, or from the policy
+// file at the repository root
+func loadGitHooks(cmd *cobra.Command, t hookTarget) (map[string][]string, string, error) {
+    path, _ := cmd.Flags().GetString("file")
+    if !cmd.Flags().Changed("file") {
+        path = filepath.Join(t.dir, path)
+    }
+    cfg, err := policy.Load(path)
+    if err != nil { return nil, "", fmt.Errorf("load policy: %w", err) }
+    if len(cfg.Workflow.GitHooks) == 0 {
+        return nil, "", fmt.Errorf("%s declares no workflow.git_hooks", path)
+    }
+    return cfg.Workflow.GitHooks, filepath.Base(path), nil
+}
+
+func withRepo(results []hooks.Result, repo string) []hooks.Result {
+    for i := range results {
+        results[i].Repo = repo
+    }
+    return results
+}
+
+func printHookResults(results []hooks.Result) error {
+    var failed int
+    for _, r := range results {
+        if r.Error != "" { failed++ }
+    }
+    return printer.Print(ui.Output{
+        Data:   results,
+        Failed: failed > 0,
+        Table: func() error {
+            if len(results) == 0 {
+                fmt.Println("No ds hooks found")
+                return nil
+            }
+            for _, r := range results {
+                name := r.Hook
+                if r.Repo != "" { name = r.Repo + " " + name }
+                switch {
+                case r.Error != "":
+                    fmt.Printf("  ✗ %s: %s\n", name, r.Error)
+                case r.Chained && r.Action != "restored":
+                    fmt.Printf("  ✓ %s %s (chains existing hook)\n", name, r.Action)
+                default:
+                    fmt.Printf("  ✓ %s %s\n", name, r.Action)
+                }
+            }
+            return nil
+        },
+    })
+}
+
 func init() {
     hooksCmd.AddCommand(hooksInstallCmd)
+    hooksCmd.AddCommand(hooksStatusCmd)
+    hooksCmd.AddCommand(hooksUninstallCmd)
+    hooksCmd.PersistentFlags().String("file", ".project-compliance.yaml", "policy file declaring workflow.git_hooks (default: at each repository root)")
+    hooksCmd.PersistentFlags().Bool("all", false, "operate on every repository in the workspace")
+    hooksCmd.PersistentFlags().StringVar(&scanPath, "path", "", "path to scan with --all (default: ~/Projects)")
+    hooksCmd.PersistentFlags().StringVarP(&accountFilter, "account", "a", "", "with --all, only repositories for this account")
 }
 
 var execCmd = &cobra.Command{
     Use:   "exec -- <command>",
     Short: "Run a shell command across repositories",
-    Long:  "Execute a shell command in each repository. Supports filtering by account and dirty state.",
+    Long:  "Execute a shell command in each repository. Supports filtering by account and dirty state.\nUse --saved to run a named command from the config's commands section, with --param name=value for its parameters.",
     RunE: func(cmd *cobra.Command, args []string) error {
-        if len(args) == 0 {
-            return fmt.Errorf("provide a command after --")
+        saved, _ := cmd.Flags().GetString("saved")
+        if len(args) == 0 && saved == "" {
+            return fmt.Errorf("provide a command after -- or --saved <name>")
         }
         cfg, err := config.Load(cfgFile)
         if err != nil { return fmt.Errorf("loading config: %w", err) }
+        command := strings.Join(args, " ")
+        timeoutSec, _ := cmd.Flags().GetInt("timeout")
+        timeout := time.Duration(timeoutSec) * time.Second
+        if saved != "" {
+            tmpl, ok := cfg.Commands[saved]
+            if !ok {
+                return fmt.Errorf("no saved command %q in config", saved)
+            }
+            pairs, _ := cmd.Flags().GetStringArray("param")
+            params := map[string]string{}
+            for _, p := range pairs {
+                k, v, ok := strings.Cut(p, "=")
+                if !ok { return fmt.Errorf("invalid --param %q (use name=value)", p) }
+                params[k] = v
+            }
+            if command, err = tmpl.Render(params); err != nil {
+                return fmt.Errorf("command %q: %w", saved, err)
+            }
+            if timeout == 0 { timeout = tmpl.TimeoutDuration() }
+        }
         scanner := scan.New(cfg, workerCount)
         repos, err := scanner.Scan(scanPath)
         if err != nil { return fmt.Errorf("scanning repos: %w", err) }
         if dirtyOnly { repos = filterDirty(repos) }
         if accountFilter != "" { repos = filterByAccount(repos, accountFilter) }
-        timeoutSec, _ := cmd.Flags().GetInt("timeout")
-        results := runner.ExecInRepos(repos, strings.Join(args, " "), time.Duration(timeoutSec)*time.Second)
-        if jsonOutput {
-            return ui.PrintJSONResponse(true, results, nil)
-        }
+        results := runner.ExecInRepos(repos, command, timeout)
         var ok, fail int
         for _, r := range results {
             if r.Success { ok++ } else { fail++ }
         }
+        if !printer.IsTable() {
+            return printer.Print(ui.Output{Data: results, Failed: fail > 0})
+        }
         fmt.Printf("Executed in %d repos: %d ok, %d failed\n", len(results), ok, fail)
         if fail > 0 { os.Exit(30) }
         return nil
@@ -526,7 +975,8 @@ func init() {
     execCmd.Flags().StringVarP(&accountFilter, "account", "a", "", "filter by account")
     execCmd.Flags().BoolVarP(&dirtyOnly, "dirty", "d", false, "only dirty repositories")
     execCmd.Flags().Int("timeout", 0, "timeout in seconds for each command (0=none)")
-    execCmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON")
+    execCmd.Flags().String("saved", "", "run a named command from the config's commands section")
+    execCmd.Flags().StringArray("param", nil, "parameter for a saved command as name=value (repeatable)")
 }
 
 func filterDirty(repos []scan.Repository) []scan.Repository {
@@ -0,0 +1,131 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "os"
+
+    "github.com/spf13/cobra"
+    "github.com/verlyn13/ds-go/internal/config"
+    "github.com/verlyn13/ds-go/internal/manifest"
+    "github.com/verlyn13/ds-go/internal/scan"
+    "github.com/verlyn13/ds-go/internal/ui"
+)
+
+var manifestCmd = &cobra.Command{
+    Use:   "manifest",
+    Short: "Export or apply a workspace manifest",
+    Long:  `Capture every repository's remotes, account, folder, default branch and tags in a manifest, and reproduce that layout on another machine.`,
+}
+
+var manifestExportCmd = &cobra.Command{
+    Use:   "export",
+    Short: "Write a manifest of all scanned repositories",
+    RunE: func(cmd *cobra.Command, args []string) error {
+        cfg, err := config.Load(cfgFile)
+        if err != nil { return fmt.Errorf("loading config: %w", err) }
+        scanner := scan.New(cfg, workerCount)
+        repos, err := scanner.Scan(scanPath)
+        if err != nil { return fmt.Errorf("scanning repos: %w", err) }
+
+        m := manifest.Build(repos, cfg, scanPath, workerCount)
+
+        // The manifest itself is written unwrapped so it can be applied
+        // later; other output formats render one row per repository
+        file, _ := cmd.Flags().GetString("file")
+        format := ""
+        switch printer.Format {
+        case ui.FormatTable:
+            if file != "" { format = manifest.FormatFromPath(file) }
+        case ui.FormatJSON, ui.FormatYAML:
+            format = string(printer.Format)
+        default:
+            if file != "" {
+                return fmt.Errorf("--file supports only yaml or json output")
+            }
+            return printer.Print(ui.Output{Data: m, Items: m.Repos})
+        }
+        if file == "" {
+            return manifest.Write(os.Stdout, m, format)
+        }
+        f, err := os.Create(file)
+        if err != nil { return fmt.Errorf("creating manifest: %w", err) }
+        defer f.Close()
+        if err := manifest.Write(f, m, format); err != nil { return err }
+        if !quietMode {
+            fmt.Fprintf(os.Stderr, "Wrote %d repositories to %s\n", len(m.Repos), file)
+        }
+        return nil
+    },
+}
+
+var manifestApplyCmd = &cobra.Command{
+    Use:   "apply <manifest-file>",
+    Short: "Clone repositories from a manifest that are missing locally",
+    Args:  cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        cfg, err := config.Load(cfgFile)
+        if err != nil { return fmt.Errorf("loading config: %w", err) }
+        m, err := manifest.Load(args[0])
+        if err != nil { return fmt.Errorf("load manifest: %w", err) }
+        scanner := scan.New(cfg, workerCount)
+        repos, err := scanner.Scan(scanPath)
+        if err != nil { return fmt.Errorf("scanning repos: %w", err) }
+
+        dryRun, _ := cmd.Flags().GetBool("dry-run")
+        report := manifest.Apply(context.Background(), m, repos, cfg, workerCount, dryRun)
+        return printer.Print(ui.Output{
+            Data:   report,
+            Items:  report.Results,
+            Failed: report.Failed > 0,
+            Table:  func() error { printManifestReport(report, dryRun); return nil },
+        })
+    },
+}
+
+func printManifestReport(report *manifest.ApplyReport, dryRun bool) {
+    for _, r := range report.Results {
+        switch {
+        case r.Action == "present":
+            continue
+        case r.Error != "":
+            fmt.Printf("  ✗ %s: %s\n", r.Name, r.Error)
+        case r.DryRun:
+            fmt.Printf("  [CLONE] %s → %s\n", r.URL, r.Target)
+        default:
+            fmt.Printf("  ✓ Cloned %s → %s\n", r.Name, r.Target)
+        }
+    }
+    if dryRun {
+        fmt.Printf("\n[DRY RUN] %d to clone, %d present\n", countAction(report, "clone"), report.Present)
+    } else {
+        fmt.Printf("\n✓ Manifest applied: %d cloned, %d present, %d failed\n", report.Cloned, report.Present, report.Failed)
+    }
+    if len(report.Extra) > 0 {
+        fmt.Printf("\n%d repositories not in manifest:\n", len(report.Extra))
+        for _, e := range report.Extra {
+            fmt.Printf("  %s (%s)\n", e.Path, e.RemoteURL)
+        }
+    }
+}
+
+func countAction(r *manifest.ApplyReport, action string) int {
+    n := 0
+    for _, res := range r.Results {
+        if res.Action == action { n++ }
+    }
+    return n
+}
+
+func init() {
+    manifestCmd.AddCommand(manifestExportCmd)
+    manifestCmd.AddCommand(manifestApplyCmd)
+
+    manifestExportCmd.Flags().StringVar(&scanPath, "path", "", "path to scan (default: ~/Projects)")
+    manifestExportCmd.Flags().StringP("file", "f", "", "write manifest to file instead of stdout (format from extension or --output yaml|json)")
+
+    manifestApplyCmd.Flags().StringVar(&scanPath, "path", "", "path to scan for existing repos (default: ~/Projects)")
+    manifestApplyCmd.Flags().Bool("dry-run", false, "show what would be cloned without cloning")
+
+    rootCmd.AddCommand(manifestCmd)
+}
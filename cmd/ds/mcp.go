@@ -0,0 +1,53 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "runtime/debug"
+
+    "github.com/spf13/cobra"
+    "github.com/verlyn13/ds-go/internal/config"
+    "github.com/verlyn13/ds-go/internal/events"
+    "github.com/verlyn13/ds-go/internal/mcp"
+    "github.com/verlyn13/ds-go/internal/scan"
+    "github.com/verlyn13/ds-go/internal/server"
+)
+
+var mcpCmd = &cobra.Command{
+    Use:   "mcp",
+    Short: "Serve ds to AI agents over the Model Context Protocol on stdio",
+    Long: `Speak MCP (JSON-RPC 2.0, one message per line) on stdin and stdout, for agents
+that launch ds as a subprocess. Tools: status, scan, fetch, organize_plan,
+organize_apply, policy_check, list_commands and exec (command templates only).
+Resources: ds://index and ds://repo/{account}/{name}.
+
+Tools run the same handlers as ds serve, without auth. exec calls are written to
+the audit log. Register it with an MCP client as: {"command": "ds", "args": ["mcp"]}`,
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        cfg, err := config.Load(cfgFile)
+        if err != nil { return fmt.Errorf("loading config: %w", err) }
+        auditPath, _ := cmd.Flags().GetString("audit-log")
+        ttl, _ := cmd.Flags().GetDuration("cache-ttl")
+        s := server.New(cfg, workerCount).WithCacheTTL(ttl).WithRawExec(false).WithAuditLog(auditPath)
+        bus := events.NewBus(cfg.Events)
+        defer bus.Close()
+        s.WithEvents(bus, events.NewDetector(events.DefaultState(), cfg.Events.StaleAfterDuration()))
+        return mcp.New(s.Handler(), scan.New(cfg, workerCount), buildVersion()).Serve(context.Background(), os.Stdin, os.Stdout)
+    },
+}
+
+// buildVersion is the module version ds was built from, or "dev"
+func buildVersion() string {
+    if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+        return info.Main.Version
+    }
+    return "dev"
+}
+
+func init() {
+    mcpCmd.Flags().Duration("cache-ttl", server.DefaultCacheTTL, "reuse repository scans for this long; 0 rescans on every call")
+    mcpCmd.Flags().String("audit-log", server.DefaultAuditLog(), "file receiving a JSON line per exec call (empty disables)")
+    rootCmd.AddCommand(mcpCmd)
+}
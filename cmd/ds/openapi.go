@@ -0,0 +1,30 @@
+package main
+
+import (
+    "os"
+
+    "github.com/spf13/cobra"
+    "github.com/verlyn13/ds-go/internal/api"
+)
+
+var openapiCmd = &cobra.Command{
+    Use:   "openapi",
+    Short: "Print the OpenAPI document for ds serve",
+    Long: `Print the OpenAPI document generated from the API route table. ds serve embeds
+the same document; regenerate it after changing routes with go generate ./internal/server.`,
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        spec, err := api.OpenAPI()
+        if err != nil { return err }
+        if file, _ := cmd.Flags().GetString("file"); file != "" {
+            return os.WriteFile(file, spec, 0644)
+        }
+        _, err = os.Stdout.Write(spec)
+        return err
+    },
+}
+
+func init() {
+    openapiCmd.Flags().StringP("file", "f", "", "write the document to file instead of stdout")
+    rootCmd.AddCommand(openapiCmd)
+}
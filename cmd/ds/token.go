@@ -0,0 +1,113 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/jedib0t/go-pretty/v6/table"
+    "github.com/spf13/cobra"
+    "github.com/verlyn13/ds-go/internal/auth"
+    "github.com/verlyn13/ds-go/internal/ui"
+)
+
+var tokenCmd = &cobra.Command{
+    Use:   "token",
+    Short: "Manage scoped API tokens for ds serve",
+    Long: `Create, list and revoke named API tokens. Tokens are stored hashed in the token file and carry scopes:
+  read      status, scan, plans and discovery
+  fetch     git fetch across repositories
+  exec      command templates and policy checks
+  organize  organize apply and manifest apply
+A running ds serve picks up changes to the token file without a restart.`,
+}
+
+var tokenCreateCmd = &cobra.Command{
+    Use:   "create <name>",
+    Short: "Create a token and print its secret once",
+    Args:  cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        store, err := openTokenStore(cmd)
+        if err != nil { return err }
+        scopes, _ := cmd.Flags().GetStringSlice("scope")
+        ttl, _ := cmd.Flags().GetDuration("expires")
+        secret, tok, err := store.Create(args[0], scopes, ttl)
+        if err != nil { return err }
+        if !printer.IsTable() {
+            return printer.Print(ui.Output{Data: map[string]interface{}{"token": tok, "secret": secret}})
+        }
+        fmt.Println(secret)
+        if !quietMode {
+            expiry := "never expires"
+            if tok.ExpiresAt != nil { expiry = "expires " + tok.ExpiresAt.Local().Format(time.RFC3339) }
+            fmt.Fprintf(os.Stderr, "Created token %q with scopes %s (%s). Store the secret now; it is not shown again.\n",
+                tok.Name, strings.Join(tok.Scopes, ","), expiry)
+        }
+        return nil
+    },
+}
+
+var tokenListCmd = &cobra.Command{
+    Use:   "list",
+    Short: "List tokens (secrets are never shown)",
+    RunE: func(cmd *cobra.Command, args []string) error {
+        store, err := openTokenStore(cmd)
+        if err != nil { return err }
+        tokens := store.Tokens()
+        return printer.Print(ui.Output{
+            Data: tokens,
+            Table: func() error {
+                if len(tokens) == 0 {
+                    fmt.Println("No tokens. Create one with 'ds token create <name> --scope read'.")
+                    return nil
+                }
+                now := time.Now()
+                t := table.NewWriter()
+                t.SetOutputMirror(os.Stdout)
+                t.SetStyle(table.StyleRounded)
+                t.AppendHeader(table.Row{"Name", "Scopes", "Created", "Expires"})
+                for _, tok := range tokens {
+                    expires := "never"
+                    if tok.ExpiresAt != nil {
+                        expires = tok.ExpiresAt.Local().Format("2006-01-02 15:04")
+                        if tok.Expired(now) { expires += " (expired)" }
+                    }
+                    t.AppendRow(table.Row{tok.Name, strings.Join(tok.Scopes, ","), tok.CreatedAt.Local().Format("2006-01-02 15:04"), expires})
+                }
+                t.Render()
+                return nil
+            },
+        })
+    },
+}
+
+var tokenRevokeCmd = &cobra.Command{
+    Use:   "revoke <name>",
+    Short: "Revoke a token",
+    Args:  cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        store, err := openTokenStore(cmd)
+        if err != nil { return err }
+        if err := store.Revoke(args[0]); err != nil { return err }
+        if !quietMode { fmt.Fprintf(os.Stderr, "Revoked token %q\n", args[0]) }
+        return nil
+    },
+}
+
+func openTokenStore(cmd *cobra.Command) (*auth.Store, error) {
+    path, _ := cmd.Flags().GetString("file")
+    store, err := auth.Open(path)
+    if err != nil { return nil, fmt.Errorf("loading tokens: %w", err) }
+    return store, nil
+}
+
+func init() {
+    tokenCmd.AddCommand(tokenCreateCmd)
+    tokenCmd.AddCommand(tokenListCmd)
+    tokenCmd.AddCommand(tokenRevokeCmd)
+    tokenCmd.PersistentFlags().String("file", auth.DefaultPath(), "token file")
+    tokenCreateCmd.Flags().StringSlice("scope", []string{auth.ScopeRead}, "scopes to grant: "+strings.Join(auth.Scopes, ", "))
+    tokenCreateCmd.Flags().Duration("expires", 0, "lifetime, e.g. 720h (0 = never expires)")
+    rootCmd.AddCommand(tokenCmd)
+}
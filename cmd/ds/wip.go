@@ -0,0 +1,153 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "time"
+
+    "github.com/spf13/cobra"
+    "github.com/verlyn13/ds-go/internal/config"
+    "github.com/verlyn13/ds-go/internal/scan"
+    "github.com/verlyn13/ds-go/internal/ui"
+    "github.com/verlyn13/ds-go/internal/wip"
+)
+
+var snapshotWIPCmd = &cobra.Command{
+    Use:   "snapshot-wip",
+    Short: "Snapshot uncommitted work in every dirty repository",
+    Long: `Record the working tree, index and untracked files of each dirty repository
+as a commit under refs/ds/wip/<id>, or on the stash with --stash, without
+changing the working tree or index. Every repository in one run shares the
+same id, a timestamp such as 20261018-153000, so the run can be restored or
+dropped as a whole with ds wip.`,
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        repos, err := wipRepos()
+        if err != nil { return err }
+        stash, _ := cmd.Flags().GetBool("stash")
+        id := wip.NewID(time.Now())
+        return printWIPResults(wip.Snapshot(filterDirty(repos), id, stash), "No dirty repositories")
+    },
+}
+
+var wipCmd = &cobra.Command{
+    Use:   "wip",
+    Short: "List, restore or drop WIP snapshots",
+    Long:  `Manage the snapshots ds snapshot-wip takes. A snapshot is named by its id, or by latest for the newest one in each repository.`,
+}
+
+var wipListCmd = &cobra.Command{
+    Use:   "list",
+    Short: "List WIP snapshots across repositories",
+    Args:  cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        repos, err := wipRepos()
+        if err != nil { return err }
+        entries, failed := wip.List(repos)
+        for _, f := range failed {
+            fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", f.Repo, f.Error)
+        }
+        return printer.Print(ui.Output{
+            Data:   entries,
+            Failed: len(failed) > 0,
+            Table: func() error {
+                if len(entries) == 0 {
+                    fmt.Println("No WIP snapshots")
+                    return nil
+                }
+                for _, e := range entries {
+                    fmt.Printf("%-20s %-18s %s  %s  %s\n", e.Repo, e.ID, e.Commit[:min(7, len(e.Commit))], e.Time.Local().Format("2006-01-02 15:04"), e.Ref)
+                }
+                return nil
+            },
+        })
+    },
+}
+
+var wipRestoreCmd = &cobra.Command{
+    Use:   "restore <id|latest>",
+    Short: "Apply a WIP snapshot in each repository that has it",
+    Long: `Apply a snapshot with git stash apply, restoring its working tree changes
+and untracked files, and with --index its staged changes too. Repositories
+with uncommitted work are skipped unless --force is given. The snapshot is
+kept; remove it with ds wip drop.`,
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        repos, err := wipRepos()
+        if err != nil { return err }
+        index, _ := cmd.Flags().GetBool("index")
+        force, _ := cmd.Flags().GetBool("force")
+        return printWIPResults(wip.Restore(repos, args[0], index, force), "No repositories")
+    },
+}
+
+var wipDropCmd = &cobra.Command{
+    Use:   "drop <id|latest>",
+    Short: "Delete a WIP snapshot from each repository that has it",
+    Args:  cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        repos, err := wipRepos()
+        if err != nil { return err }
+        return printWIPResults(wip.Drop(repos, args[0]), "No repositories")
+    },
+}
+
+// wipRepos scans the workspace, narrowed by --account
+func wipRepos() ([]scan.Repository, error) {
+    cfg, err := config.Load(cfgFile)
+    if err != nil { return nil, fmt.Errorf("loading config: %w", err) }
+    repos, err := scan.New(cfg, workerCount).Scan(scanPath)
+    if err != nil { return nil, fmt.Errorf("scanning repos: %w", err) }
+    if accountFilter != "" { repos = filterByAccount(repos, accountFilter) }
+    scan.SortRepos(repos, "name")
+    return repos, nil
+}
+
+// printWIPResults prints per-repository results, leaving out repositories
+// without the snapshot from the table, and exits 30 if any failed
+func printWIPResults(results []wip.Result, empty string) error {
+    var fail int
+    for _, r := range results {
+        if !r.Success { fail++ }
+    }
+    err := printer.Print(ui.Output{
+        Data:   results,
+        Failed: fail > 0,
+        Table: func() error {
+            shown := 0
+            for _, r := range results {
+                switch {
+                case !r.Success:
+                    fmt.Printf("  ✗ %s: %s\n", r.Repo, r.Error)
+                case r.Action == wip.ActionMissing:
+                    continue
+                case r.Action == wip.ActionClean:
+                    fmt.Printf("  - %s: nothing to snapshot\n", r.Repo)
+                default:
+                    fmt.Printf("  ✓ %s %s %s (%s)\n", r.Repo, r.Action, r.Ref, r.Commit[:min(7, len(r.Commit))])
+                }
+                shown++
+            }
+            if shown == 0 { fmt.Println(empty) }
+            return nil
+        },
+    })
+    if err != nil { return err }
+    if fail > 0 { os.Exit(30) }
+    return nil
+}
+
+func init() {
+    snapshotWIPCmd.Flags().Bool("stash", false, "push snapshots onto the stash instead of refs/ds/wip/")
+    wipRestoreCmd.Flags().Bool("index", false, "restore staged changes to the index as well")
+    wipRestoreCmd.Flags().Bool("force", false, "restore into repositories with uncommitted work")
+    for _, c := range []*cobra.Command{snapshotWIPCmd, wipCmd} {
+        c.PersistentFlags().StringVar(&scanPath, "path", "", "path to scan (default: ~/Projects)")
+        c.PersistentFlags().StringVarP(&accountFilter, "account", "a", "", "only repositories for this account")
+    }
+    wipCmd.AddCommand(wipListCmd)
+    wipCmd.AddCommand(wipRestoreCmd)
+    wipCmd.AddCommand(wipDropCmd)
+    rootCmd.AddCommand(snapshotWIPCmd)
+    rootCmd.AddCommand(wipCmd)
+}
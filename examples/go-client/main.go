@@ -83,7 +83,7 @@ func main() {
 
     // 5. Repository status
     fmt.Println("\n=== Repository Status ===")
-    repos, err := c.Status(ctx, "", "", true)
+    repos, err := c.Status(ctx, dsclient.StatusOptions{Dirty: true})
     if err != nil {
         fmt.Fprintf(os.Stderr, "status error: %v\n", err)
         os.Exit(1)
@@ -96,6 +96,16 @@ func main() {
         b, _ := json.MarshalIndent(repos.Data[0], "  ", "  ")
         fmt.Printf("  %s\n", string(b))
     }
+
+    // 6. Stream repositories behind upstream
+    fmt.Println("\n=== Behind Upstream ===")
+    for repo, err := range c.StreamStatus(ctx, dsclient.StatusOptions{Behind: true, Sort: "-behind"}) {
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "stream error: %v\n", err)
+            os.Exit(1)
+        }
+        fmt.Printf("  %s/%s: %d behind\n", repo.Account, repo.Name, repo.Behind)
+    }
 }
 
 func getenv(k, def string) string {
@@ -113,7 +113,7 @@ fetch_all() {
 # Function: Check policy compliance
 check_policy() {
     echo -e "${BLUE}=== Policy Compliance Check ===${NC}"
-    local result=$(api_call GET "/policy/check?fail_on=critical")
+    local result=$(api_call POST "/policy/check?fail_on=critical")
 
     if [ "$JQ_AVAILABLE" = "yes" ]; then
         local total=$(echo "$result" | jq -r '.data.summary.total')
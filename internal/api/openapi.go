@@ -0,0 +1,339 @@
+package api
+
+import (
+    "bytes"
+    "fmt"
+    "reflect"
+    "strings"
+    "time"
+
+    "gopkg.in/yaml.v3"
+)
+
+// Description introduces the generated OpenAPI document
+const Description = `Local-only API for repository status, fetch, organize, policy, and exec.
+
+Two wire schemas are served. ds.v1 (the default) emits repositories with the
+PascalCase keys of Repository. ds.v2 uses snake_case keys (RepositoryV2,
+FetchResultV2) and string errors. Select ds.v2 with the /v2/ paths, or by sending
+Accept: application/vnd.ds.v2+json to a /v1/ path. Every response names its schema
+in schema_version and the DS-Schema-Version header.
+
+Generated from internal/api by "ds openapi"; do not edit by hand.
+`
+
+// schemaNames names component schemas for types defined in other packages
+var schemaNames = map[reflect.Type]string{
+    reflect.TypeOf(MovePlan{}):            "MovePlan",
+    reflect.TypeOf(OrganizeResult{}):      "OrganizeResult",
+    reflect.TypeOf(PolicyReport{}):        "PolicyReport",
+    reflect.TypeOf(PolicyCheckResult{}):   "PolicyCheckResult",
+    reflect.TypeOf(PolicySummary{}):       "PolicySummary",
+    reflect.TypeOf(PolicyWaiver{}):        "PolicyWaiver",
+    reflect.TypeOf(CommandTemplate{}):     "CommandTemplate",
+    reflect.TypeOf(CommandParam{}):        "CommandParam",
+    reflect.TypeOf(ExecResult{}):          "ExecResult",
+    reflect.TypeOf(Manifest{}):            "Manifest",
+    reflect.TypeOf(ManifestEntry{}):       "ManifestEntry",
+    reflect.TypeOf(ManifestApplyResult{}): "ManifestApplyResult",
+    reflect.TypeOf(ExtraRepo{}):           "ExtraRepo",
+    reflect.TypeOf(Event{}):               "Event",
+}
+
+var (
+    timeType     = reflect.TypeOf(time.Time{})
+    durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// Schema is an OpenAPI schema object, which is also JSON Schema
+type Schema struct {
+    Ref                  string             `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+    Type                 interface{}        `yaml:"type,omitempty" json:"type,omitempty"`
+    Format               string             `yaml:"format,omitempty" json:"format,omitempty"`
+    Description          string             `yaml:"description,omitempty" json:"description,omitempty"`
+    Enum                 []string           `yaml:"enum,omitempty" json:"enum,omitempty"`
+    Minimum              *int               `yaml:"minimum,omitempty" json:"minimum,omitempty"`
+    Maximum              *int               `yaml:"maximum,omitempty" json:"maximum,omitempty"`
+    Items                *Schema            `yaml:"items,omitempty" json:"items,omitempty"`
+    Properties           map[string]*Schema `yaml:"properties,omitempty" json:"properties,omitempty"`
+    Required             []string           `yaml:"required,omitempty" json:"required,omitempty"`
+    AdditionalProperties *Schema            `yaml:"additionalProperties,omitempty" json:"additionalProperties,omitempty"`
+    Defs                 map[string]*Schema `yaml:"$defs,omitempty" json:"$defs,omitempty"`
+}
+
+type document struct {
+    OpenAPI    string                           `yaml:"openapi"`
+    Info       info                             `yaml:"info"`
+    Servers    []map[string]string              `yaml:"servers"`
+    Security   []map[string][]string            `yaml:"security"`
+    Paths      map[string]map[string]*operation `yaml:"paths"`
+    Components components                       `yaml:"components"`
+}
+
+type info struct {
+    Title       string `yaml:"title"`
+    Version     string `yaml:"version"`
+    Description string `yaml:"description"`
+}
+
+type operation struct {
+    OperationID string               `yaml:"operationId"`
+    Summary     string               `yaml:"summary"`
+    Description string               `yaml:"description,omitempty"`
+    Tags        []string             `yaml:"tags,omitempty"`
+    Parameters  []parameter          `yaml:"parameters,omitempty"`
+    RequestBody *body                `yaml:"requestBody,omitempty"`
+    Responses   map[string]*response `yaml:"responses"`
+}
+
+type parameter struct {
+    In          string  `yaml:"in"`
+    Name        string  `yaml:"name"`
+    Description string  `yaml:"description,omitempty"`
+    Schema      *Schema `yaml:"schema"`
+}
+
+type body struct {
+    Required bool                  `yaml:"required"`
+    Content  map[string]*mediaType `yaml:"content"`
+}
+
+type response struct {
+    Ref         string                `yaml:"$ref,omitempty"`
+    Description string                `yaml:"description,omitempty"`
+    Content     map[string]*mediaType `yaml:"content,omitempty"`
+}
+
+type mediaType struct {
+    Schema *Schema `yaml:"schema"`
+}
+
+type components struct {
+    Responses       map[string]*response          `yaml:"responses"`
+    Schemas         map[string]*Schema            `yaml:"schemas"`
+    SecuritySchemes map[string]map[string]string `yaml:"securitySchemes"`
+}
+
+// OpenAPI generates the OpenAPI 3.1 document for Routes
+func OpenAPI() ([]byte, error) {
+    g := newGenerator("#/components/schemas/")
+    doc := document{
+        OpenAPI:  "3.1.0",
+        Info:     info{Title: "ds Local API", Version: "1.0.0", Description: Description},
+        Servers:  []map[string]string{{"url": "http://127.0.0.1:7777"}},
+        Security: []map[string][]string{{}, {"bearerAuth": {}}},
+        Paths:    map[string]map[string]*operation{},
+        Components: components{
+            Responses: map[string]*response{"Error": {
+                Description: "Error envelope. Status codes: 400 bad_request, 401 unauthorized, 403 forbidden, 404 not_found, " +
+                    "405 method_not_allowed, 409 conflict, 422 validation_failed, 500 internal.",
+                Content: map[string]*mediaType{"application/json": {Schema: g.schema(reflect.TypeOf(ErrorResponse{}))}},
+            }},
+            SecuritySchemes: map[string]map[string]string{"bearerAuth": {"type": "http", "scheme": "bearer"}},
+        },
+    }
+    for _, rt := range Routes {
+        g.add(doc.Paths, rt, rt.Path, rt.Response, "")
+        if rest, ok := strings.CutPrefix(rt.Path, "/v1/"); ok {
+            resp := rt.Response
+            if rt.ResponseV2 != nil { resp = rt.ResponseV2 }
+            g.add(doc.Paths, rt, "/v2/"+rest, resp, "V2")
+        }
+    }
+    if g.err != nil { return nil, g.err }
+    doc.Components.Schemas = g.schemas
+
+    var buf bytes.Buffer
+    enc := yaml.NewEncoder(&buf)
+    enc.SetIndent(2)
+    if err := enc.Encode(doc); err != nil { return nil, err }
+    return buf.Bytes(), enc.Close()
+}
+
+// JSONSchema describes the JSON form of v as a standalone JSON Schema:
+// a struct inline, with the named types it uses under $defs
+func JSONSchema(v interface{}) (*Schema, error) {
+    g := newGenerator("#/$defs/")
+    t := reflect.TypeOf(v)
+    var s *Schema
+    if t.Kind() == reflect.Struct {
+        s = g.object(t)
+    } else {
+        s = g.schema(t)
+    }
+    if g.err != nil { return nil, g.err }
+    if len(g.schemas) > 0 { s.Defs = g.schemas }
+    return s, nil
+}
+
+// Schema returns the JSON Schema of the parameter's value
+func (p Param) Schema() *Schema {
+    s := paramSchema(p)
+    s.Description = p.Description
+    return s
+}
+
+type generator struct {
+    ref     string // Prefix of schema references
+    schemas map[string]*Schema
+    names   map[reflect.Type]string
+    err     error
+}
+
+func newGenerator(ref string) *generator {
+    return &generator{ref: ref, schemas: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+func (g *generator) add(paths map[string]map[string]*operation, rt Route, path string, resp interface{}, suffix string) {
+    op := &operation{
+        OperationID: rt.Name + suffix,
+        Summary:     rt.Summary,
+        Description: rt.Description,
+        Tags:        []string{tag(rt.Path)},
+        Responses:   map[string]*response{"default": {Ref: "#/components/responses/Error"}},
+    }
+    if rt.Scope != "" {
+        op.Description = strings.TrimSpace(op.Description + "\n\nRequires the " + rt.Scope + " scope when auth is enabled.")
+    }
+    for _, p := range rt.Params {
+        op.Parameters = append(op.Parameters, parameter{In: "query", Name: p.Name, Description: p.Description, Schema: paramSchema(p)})
+    }
+    if rt.Body != nil {
+        types := rt.BodyTypes
+        if len(types) == 0 { types = []string{"application/json"} }
+        op.RequestBody = &body{Required: true, Content: map[string]*mediaType{}}
+        for _, ct := range types {
+            op.RequestBody.Content[ct] = &mediaType{Schema: g.schema(reflect.TypeOf(rt.Body))}
+        }
+    }
+    ok := &response{Description: "OK", Content: map[string]*mediaType{}}
+    switch {
+    case rt.Stream == "text/event-stream":
+        ok.Description = "Server-Sent Events; each data line is a " + g.name(reflect.TypeOf(resp))
+        ok.Content[rt.Stream] = &mediaType{Schema: &Schema{Type: "string"}}
+    case rt.Stream != "":
+        ok.Description = "One JSON " + g.name(reflect.TypeOf(resp)) + " per line"
+        ok.Content[rt.Stream] = &mediaType{Schema: g.schema(reflect.TypeOf(resp))}
+    case resp != nil:
+        ok.Content["application/json"] = &mediaType{Schema: g.schema(reflect.TypeOf(resp))}
+    }
+    for _, ct := range rt.Produces {
+        ok.Content[ct] = &mediaType{Schema: &Schema{Type: "string"}}
+    }
+    op.Responses["200"] = ok
+    if rt.Cached {
+        op.Responses["304"] = &response{Description: "Not modified since the ETag (If-None-Match) or Last-Modified (If-Modified-Since)"}
+    }
+
+    if paths[path] == nil { paths[path] = map[string]*operation{} }
+    paths[path][strings.ToLower(rt.Method)] = op
+}
+
+// tag groups operations by their first path segment after the version
+func tag(path string) string {
+    parts := strings.Split(strings.Trim(path, "/"), "/")
+    if len(parts) > 1 && (parts[0] == "v1" || parts[0] == "api") { return parts[1] }
+    return "discovery"
+}
+
+func paramSchema(p Param) *Schema {
+    s := &Schema{Type: p.Type, Enum: p.Enum}
+    if s.Type == "" { s.Type = "string" }
+    if p.Min != 0 { s.Minimum = &p.Min }
+    if p.Max != 0 { s.Maximum = &p.Max }
+    return s
+}
+
+// name returns the component name of a named struct type
+func (g *generator) name(t reflect.Type) string {
+    if n, ok := g.names[t]; ok { return n }
+    n, ok := schemaNames[t]
+    if !ok {
+        n = t.Name()
+        if t.PkgPath() != reflect.TypeOf(Route{}).PkgPath() {
+            g.fail(fmt.Errorf("api: %s has no component name in schemaNames", t))
+        }
+    }
+    for other, name := range g.names {
+        if name == n && other != t { g.fail(fmt.Errorf("api: %s and %s are both named %s", t, other, n)) }
+    }
+    g.names[t] = n
+    return n
+}
+
+func (g *generator) fail(err error) {
+    if g.err == nil { g.err = err }
+}
+
+// schema returns the schema of t, adding named structs to the components
+func (g *generator) schema(t reflect.Type) *Schema {
+    switch t {
+    case timeType:
+        return &Schema{Type: "string", Format: "date-time"}
+    case durationType:
+        return &Schema{Type: "integer", Description: "Nanoseconds"}
+    }
+    switch t.Kind() {
+    case reflect.Ptr:
+        s := g.schema(t.Elem())
+        if t.Elem().Kind() == reflect.Struct && t.Elem().Name() == "" { return &Schema{Type: []string{"object", "null"}} }
+        if s.Ref == "" { s.Type = []interface{}{s.Type, "null"} }
+        return s
+    case reflect.Struct:
+        if t.Name() == "" { return g.object(t) }
+        name := g.name(t)
+        if _, ok := g.schemas[name]; !ok {
+            g.schemas[name] = nil // Reserve the name before recursing
+            g.schemas[name] = g.object(t)
+        }
+        return &Schema{Ref: g.ref + name}
+    case reflect.Slice, reflect.Array:
+        return &Schema{Type: "array", Items: g.schema(t.Elem())}
+    case reflect.Map:
+        return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+    case reflect.Interface:
+        return &Schema{}
+    case reflect.String:
+        return &Schema{Type: "string"}
+    case reflect.Bool:
+        return &Schema{Type: "boolean"}
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+        reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+        return &Schema{Type: "integer"}
+    case reflect.Float32, reflect.Float64:
+        return &Schema{Type: "number"}
+    }
+    g.fail(fmt.Errorf("api: no schema for %s", t))
+    return &Schema{}
+}
+
+// object describes a struct by its JSON fields. Fields without omitempty
+// are always present, so they are listed as required.
+func (g *generator) object(t reflect.Type) *Schema {
+    s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+    for i := 0; i < t.NumField(); i++ {
+        f := t.Field(i)
+        tag := f.Tag.Get("json")
+        if tag == "-" || (!f.IsExported() && !f.Anonymous) { continue }
+        name, opts, _ := strings.Cut(tag, ",")
+        if f.Anonymous && name == "" {
+            ft := f.Type
+            if ft.Kind() == reflect.Ptr { ft = ft.Elem() }
+            embedded := g.object(ft)
+            for k, v := range embedded.Properties {
+                s.Properties[k] = v
+            }
+            s.Required = append(s.Required, embedded.Required...)
+            continue
+        }
+        if name == "" { name = f.Name }
+        prop := g.schema(f.Type)
+        if doc := f.Tag.Get("doc"); doc != "" {
+            if prop.Ref != "" { prop = &Schema{Ref: prop.Ref} }
+            prop.Description = doc
+        }
+        s.Properties[name] = prop
+        if !strings.Contains(opts, "omitempty") { s.Required = append(s.Required, name) }
+    }
+    return s
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+    "net/http"
+    "slices"
+    "strings"
+
+    "github.com/verlyn13/ds-go/internal/auth"
+    "github.com/verlyn13/ds-go/internal/policy"
+    "github.com/verlyn13/ds-go/internal/scan"
+)
+
+// Route is one API operation. Routes under /v1/ are also served under
+// /v2/, where ResponseV2 (when set) replaces Response.
+type Route struct {
+    Name        string // OpenAPI operationId
+    Method      string
+    Path        string
+    Scope       string // Token scope required when auth is enabled
+    Summary     string
+    Description string
+    Params      []Param
+    Body        interface{} // JSON request body, nil for none
+    BodyTypes   []string    // Request content types; application/json by default
+    Response    interface{} // 200 JSON body, or one stream item when Stream is set
+    ResponseV2  interface{}
+    Stream      string   // Content type of a 200 stream of Response items
+    Produces    []string // Alternative 200 content types, documented as strings
+    Cached      bool     // Sends ETag and Last-Modified and answers 304
+    Client      string   // dsclient.Client method calling this route, "" for none
+}
+
+// Param is a query parameter
+type Param struct {
+    Name        string
+    Type        string // string (default), boolean or integer
+    Description string
+    Enum        []string
+    Min, Max    int // Integer bounds; 0 for none
+}
+
+var (
+    pathParam    = Param{Name: "path", Description: "Scan this directory instead of the configured base_dir"}
+    refreshParam = Param{Name: "refresh", Type: "boolean", Description: "Rescan instead of serving the cached scan (also Cache-Control: no-cache)"}
+    filterParams = []Param{
+        {Name: "account", Description: "Only repos of this account"},
+        {Name: "branch", Description: "Only repos on this branch"},
+        {Name: "folder", Description: "Only repos in this account folder"},
+        {Name: "tag", Description: "Only repos with this config tag"},
+        {Name: "dirty", Type: "boolean", Description: "Only repos with uncommitted changes"},
+        {Name: "ahead", Type: "boolean", Description: "Only repos with unpushed commits"},
+        {Name: "behind", Type: "boolean", Description: "Only repos behind their upstream"},
+        {Name: "has_stash", Type: "boolean", Description: "Only repos with stashed changes"},
+        {Name: "no_upstream", Type: "boolean", Description: "Only repos whose branch has no upstream"},
+    }
+    shapeParams = []Param{
+        {Name: "sort", Description: "Sort key; prefix - to reverse. Ties are broken by name.", Enum: sortEnum()},
+        {Name: "fields", Description: "Comma-separated repository fields to return, named as in the response schema (case-insensitive)"},
+    }
+    pageParams = []Param{
+        {Name: "limit", Type: "integer", Description: "Page size; omit to return every matching repository", Min: 1, Max: 1000},
+        {Name: "cursor", Description: "Opaque next_cursor from the previous page; keep the other parameters unchanged"},
+    }
+    requireCleanParam = Param{Name: "require_clean", Type: "boolean", Description: "Fail with 409 if any repository has uncommitted changes"}
+    dryRunParam       = Param{Name: "dry_run", Type: "boolean", Description: "Report what would happen without changing anything"}
+)
+
+// Routes is the API. The server must register a handler for every path.
+var Routes = []Route{
+    {Name: "getCapabilities", Method: http.MethodGet, Path: "/v1/capabilities", Scope: auth.ScopeRead, Summary: "List supported capabilities",
+        Response: CapabilitiesResponse{}, Client: "Capabilities"},
+    {Name: "getHealth", Method: http.MethodGet, Path: "/v1/health", Scope: auth.ScopeRead, Summary: "Health status",
+        Response: HealthResponse{}, Client: "Health"},
+    {Name: "getStatus", Method: http.MethodGet, Path: "/v1/status", Scope: auth.ScopeRead, Summary: "Repository status",
+        Description: "Filtered, sorted and optionally paged repositories. With fields, each item holds only the selected keys.",
+        Params: join([]Param{refreshParam, pathParam}, filterParams, shapeParams, pageParams),
+        Response: StatusResponse{}, ResponseV2: StatusResponseV2{}, Cached: true, Client: "Status"},
+    {Name: "streamStatus", Method: http.MethodGet, Path: "/v1/status/stream", Scope: auth.ScopeRead, Summary: "NDJSON stream of repositories",
+        Description: "One repository per line; not paged.",
+        Params: join([]Param{refreshParam, pathParam}, filterParams, shapeParams),
+        Response: Repository{}, ResponseV2: RepositoryV2{}, Stream: "application/x-ndjson", Cached: true, Client: "StreamStatus"},
+    {Name: "sseStatus", Method: http.MethodGet, Path: "/v1/status/sse", Scope: auth.ScopeRead, Summary: "SSE stream of repositories",
+        Description: "One repo event per repository; not paged. Event ids count repositories; a reconnect with Last-Event-ID: n resumes after the nth.",
+        Params: join([]Param{refreshParam, pathParam}, filterParams, shapeParams),
+        Response: Repository{}, ResponseV2: RepositoryV2{}, Stream: "text/event-stream", Client: "SubscribeStatus"},
+    {Name: "scan", Method: http.MethodGet, Path: "/v1/scan", Scope: auth.ScopeRead, Summary: "Rescan and update the index",
+        Params: []Param{pathParam}, Response: ScanResponse{}, Client: "Scan"},
+    {Name: "planOrganize", Method: http.MethodGet, Path: "/v1/organize/plan", Scope: auth.ScopeRead, Summary: "Plan repository moves",
+        Params: []Param{refreshParam, pathParam, requireCleanParam}, Response: OrganizePlanResponse{}, Cached: true, Client: "OrganizePlan"},
+    {Name: "applyOrganize", Method: http.MethodPost, Path: "/v1/organize/apply", Scope: auth.ScopeOrganize, Summary: "Move repositories to their planned folders",
+        Params: []Param{pathParam, requireCleanParam, {Name: "force", Type: "boolean", Description: "Overwrite existing destinations"}, dryRunParam},
+        Response: OrganizeApplyResponse{}, Client: "OrganizeApply"},
+    {Name: "fetch", Method: http.MethodGet, Path: "/v1/fetch", Scope: auth.ScopeFetch, Summary: "Fetch repositories",
+        Params: join([]Param{pathParam}, filterParams), Response: FetchResponse{}, ResponseV2: FetchResponseV2{}, Client: "Fetch"},
+    {Name: "sseFetch", Method: http.MethodGet, Path: "/v1/fetch/sse", Scope: auth.ScopeFetch, Summary: "SSE stream of fetch results",
+        Description: "One fetch event per repository as it completes. A reconnect fetches every repository again; clients skip those already received.",
+        Params: join([]Param{pathParam}, filterParams), Response: FetchResult{}, ResponseV2: FetchResultV2{}, Stream: "text/event-stream", Client: "SubscribeFetch"},
+    {Name: "checkPolicy", Method: http.MethodPost, Path: "/v1/policy/check", Scope: auth.ScopeExec, Summary: "Run policy checks",
+        Description: "Policy checks run shell commands, so they need the exec scope and are POST-only.",
+        Params: []Param{
+            {Name: "file", Description: "Policy file; .project-compliance.yaml by default. Other files need raw commands enabled, so authentication"},
+            {Name: "fail_on", Description: "Lowest severity that fails the check", Enum: []string{string(policy.SevCritical), string(policy.SevHigh), string(policy.SevMedium), string(policy.SevLow)}},
+            {Name: "format", Description: "Report format; defaults to the Accept header, then json", Enum: policy.ReportFormats},
+            {Name: "all", Type: "boolean", Description: "Check every scanned repository instead of the working directory"},
+            {Name: "timeout", Description: "Per-check timeout as a Go duration, e.g. 30s"},
+            refreshParam, pathParam,
+        },
+        Response: PolicyResponse{}, Produces: reportTypes(), Client: "PolicyCheck"},
+    {Name: "listCommands", Method: http.MethodGet, Path: "/v1/commands", Scope: auth.ScopeRead, Summary: "List command templates",
+        Response: CommandsResponse{}, Client: "Commands"},
+    {Name: "exec", Method: http.MethodPost, Path: "/v1/exec", Scope: auth.ScopeExec, Summary: "Run a command template across repositories",
+        Description: "Raw cmd is accepted only when auth is enabled and the server runs without --no-raw-exec.",
+        Body: ExecRequest{}, Response: ExecResponse{}, Client: "Exec"},
+    {Name: "exportManifest", Method: http.MethodGet, Path: "/v1/manifest", Scope: auth.ScopeRead, Summary: "Export the workspace manifest",
+        Description: "base_dir is the scanned path and entry paths are relative to it. default_branch is origin's default branch, omitted when origin has none.",
+        Params: []Param{refreshParam, pathParam}, Response: ManifestResponse{}, Cached: true, Client: "Manifest"},
+    {Name: "applyManifest", Method: http.MethodPost, Path: "/v1/manifest", Scope: auth.ScopeOrganize, Summary: "Clone repositories missing from the workspace",
+        Description: "Entry paths are relative to the base directory and may not leave it. Remotes must be https, ssh or user@host:path URLs; entries breaking either rule fail.",
+        Params: []Param{pathParam, dryRunParam}, Body: Manifest{}, BodyTypes: []string{"application/json", "application/yaml"},
+        Response: ManifestApplyResponse{}, Client: "ApplyManifest"},
+    {Name: "subscribeEvents", Method: http.MethodGet, Path: "/v1/events", Scope: auth.ScopeRead, Summary: "SSE stream of workspace events",
+        Description: "Events as they are logged, each named by its type with its ID as the SSE id. A reconnect with Last-Event-ID resumes after that event. 404 when the server runs without events.",
+        Params: []Param{
+            {Name: "types", Description: "Comma-separated event types to send; all by default"},
+            {Name: "after", Description: "Replay logged events after this event ID before following; by default only new events are sent"},
+        },
+        Response: Event{}, Stream: "text/event-stream", Client: "SubscribeEvents"},
+
+    {Name: "getOpenAPI", Method: http.MethodGet, Path: "/openapi.yaml", Scope: auth.ScopeRead, Summary: "This document",
+        Produces: []string{"application/yaml"}},
+    {Name: "getDiscoveryOpenAPI", Method: http.MethodGet, Path: "/api/discovery/openapi", Scope: auth.ScopeRead, Summary: "This document",
+        Produces: []string{"application/yaml"}},
+    {Name: "getDiscoveryCapabilities", Method: http.MethodGet, Path: "/api/discovery/capabilities", Scope: auth.ScopeRead, Summary: "Discovery metadata",
+        Response: map[string]interface{}{}},
+    {Name: "getDiscoveryServices", Method: http.MethodGet, Path: "/api/discovery/services", Scope: auth.ScopeRead, Summary: "Service descriptor",
+        Response: map[string]interface{}{}, Client: "Discovery"},
+    {Name: "getWellKnown", Method: http.MethodGet, Path: "/.well-known/obs-bridge.json", Scope: auth.ScopeRead, Summary: "Bridge descriptor",
+        Response: map[string]interface{}{}},
+    {Name: "getSelfStatus", Method: http.MethodGet, Path: "/api/self-status", Scope: auth.ScopeRead, Summary: "Self-status for probes",
+        Response: map[string]interface{}{}, Client: "SelfStatus"},
+}
+
+// Endpoints lists the distinct /v1/ paths in Routes order
+func Endpoints() []string {
+    var paths []string
+    seen := map[string]bool{}
+    for _, rt := range Routes {
+        if !strings.HasPrefix(rt.Path, "/v1/") || seen[rt.Path] { continue }
+        seen[rt.Path] = true
+        paths = append(paths, rt.Path)
+    }
+    return paths
+}
+
+// RouteNamed returns the route whose Name is name
+func RouteNamed(name string) (Route, bool) {
+    for _, rt := range Routes {
+        if rt.Name == name { return rt, true }
+    }
+    return Route{}, false
+}
+
+// Methods returns the methods Routes defines for path
+func Methods(path string) []string {
+    var methods []string
+    for _, rt := range Routes {
+        if rt.Path == path { methods = append(methods, rt.Method) }
+    }
+    return methods
+}
+
+func join(sets ...[]Param) []Param {
+    var out []Param
+    for _, set := range sets {
+        out = append(out, set...)
+    }
+    return out
+}
+
+func sortEnum() []string {
+    keys := slices.Clone(scan.SortKeys)
+    for _, k := range scan.SortKeys {
+        keys = append(keys, "-"+k)
+    }
+    return keys
+}
+
+func reportTypes() []string {
+    var types []string
+    for _, f := range policy.ReportFormats {
+        if f != policy.FormatJSON { types = append(types, policy.ContentType(f)) }
+    }
+    return types
+}
@@ -0,0 +1,276 @@
+// Package api defines the ds HTTP API once: its routes, request and
+// response types, and the OpenAPI document generated from them. The server
+// registers its handlers from Routes and pkg/dsclient aliases these types.
+package api
+
+import (
+    "time"
+
+    "github.com/verlyn13/ds-go/internal/config"
+    "github.com/verlyn13/ds-go/internal/events"
+    "github.com/verlyn13/ds-go/internal/manifest"
+    "github.com/verlyn13/ds-go/internal/policy"
+    "github.com/verlyn13/ds-go/internal/runner"
+    "github.com/verlyn13/ds-go/internal/scan"
+)
+
+// Wire schema versions, reported as schema_version in every JSON body
+const (
+    SchemaV1 = "ds.v1"
+    SchemaV2 = "ds.v2"
+)
+
+// MediaTypeV2 selects ds.v2 through the Accept header
+const MediaTypeV2 = "application/vnd.ds.v2+json"
+
+// Types produced by other packages, under their API names
+type (
+    MovePlan            = scan.MovePlan
+    OrganizeResult      = scan.OrganizeResult
+    PolicyReport        = policy.Report
+    PolicyCheckResult   = policy.CheckResult
+    PolicySummary       = policy.Summary
+    PolicyWaiver        = policy.Waiver
+    CommandTemplate     = config.CommandTemplate
+    CommandParam        = config.CommandParam
+    ExecResult          = runner.ExecResult
+    Manifest            = manifest.Manifest
+    ManifestEntry       = manifest.Entry
+    ManifestApplyResult = manifest.ApplyResult
+    ExtraRepo           = manifest.ExtraRepo
+    Event               = events.Event
+)
+
+// ErrorResponse is the body of every 4xx and 5xx JSON response
+type ErrorResponse struct {
+    OK            bool        `json:"ok"`
+    Error         ErrorDetail `json:"error"`
+    SchemaVersion string      `json:"schema_version"`
+}
+
+// ErrorDetail describes a failed request
+type ErrorDetail struct {
+    Code    string      `json:"code" doc:"Stable error code: bad_request, unauthorized, forbidden, not_found, method_not_allowed, conflict, validation_failed or internal"`
+    Message string      `json:"message"`
+    Details interface{} `json:"details,omitempty"`
+}
+
+// CapabilitiesResponse is returned by /v1/capabilities
+type CapabilitiesResponse struct {
+    Version        int       `json:"version"`
+    Schema         string    `json:"schema"`
+    SchemaVersions []string  `json:"schema_versions"`
+    Endpoints      []string  `json:"endpoints"`
+    Timestamp      time.Time `json:"timestamp"`
+    OpenAPIURL     string    `json:"openapi_url"`
+    SchemaVersion  string    `json:"schema_version"`
+}
+
+// HealthResponse is returned by /v1/health
+type HealthResponse struct {
+    OK            bool      `json:"ok"`
+    Version       int       `json:"version"`
+    UptimeSec     int       `json:"uptime_sec"`
+    Workers       int       `json:"workers"`
+    Auth          bool      `json:"auth"`
+    Timestamp     time.Time `json:"timestamp"`
+    SchemaVersion string    `json:"schema_version"`
+}
+
+// Repository is a scanned repository in ds.v1
+type Repository struct {
+    Path         string     `json:"Path"`
+    Name         string     `json:"Name"`
+    Account      string     `json:"Account"`
+    FolderName   string     `json:"FolderName"`
+    IsOrg        bool       `json:"IsOrg"`
+    RemoteURL    string     `json:"RemoteURL"`
+    Branch       string     `json:"Branch"`
+    IsClean      bool       `json:"IsClean"`
+    Uncommitted  int        `json:"Uncommitted"`
+    Ahead        int        `json:"Ahead"`
+    Behind       int        `json:"Behind"`
+    LastCommit   string     `json:"LastCommit"`
+    LastCommitAt *time.Time `json:"LastCommitAt"`
+    LastFetch    *time.Time `json:"LastFetch"`
+    HasStash     bool       `json:"HasStash"`
+    HasUpstream  bool       `json:"HasUpstream"`
+    Tags         []string   `json:"Tags"`
+    ScanTime     time.Time  `json:"scan_time"`
+}
+
+// RepositoryV2 is the ds.v2 form of a repository: snake_case keys, tags
+// always an array
+type RepositoryV2 struct {
+    Path         string     `json:"path"`
+    Name         string     `json:"name"`
+    Account      string     `json:"account"`
+    Folder       string     `json:"folder"`
+    IsOrg        bool       `json:"is_org"`
+    RemoteURL    string     `json:"remote_url"`
+    Branch       string     `json:"branch"`
+    IsClean      bool       `json:"is_clean"`
+    Uncommitted  int        `json:"uncommitted"`
+    Ahead        int        `json:"ahead"`
+    Behind       int        `json:"behind"`
+    LastCommit   string     `json:"last_commit"`
+    LastCommitAt *time.Time `json:"last_commit_at"`
+    LastFetch    *time.Time `json:"last_fetch"`
+    HasStash     bool       `json:"has_stash"`
+    HasUpstream  bool       `json:"has_upstream"`
+    Tags         []string   `json:"tags"`
+    ScanTime     time.Time  `json:"scan_time"`
+}
+
+// NewRepository converts a scanned repository to its ds.v1 form
+func NewRepository(r scan.Repository) Repository {
+    return Repository{
+        Path: r.Path, Name: r.Name, Account: r.Account, FolderName: r.FolderName, IsOrg: r.IsOrg,
+        RemoteURL: r.RemoteURL, Branch: r.Branch, IsClean: r.IsClean, Uncommitted: r.Uncommitted,
+        Ahead: r.Ahead, Behind: r.Behind, LastCommit: r.LastCommit, LastCommitAt: r.LastCommitAt,
+        LastFetch: r.LastFetch, HasStash: r.HasStash, HasUpstream: r.HasUpstream, Tags: r.Tags, ScanTime: r.ScanTime,
+    }
+}
+
+// NewRepositoryV2 converts a scanned repository to its ds.v2 form
+func NewRepositoryV2(r scan.Repository) RepositoryV2 {
+    tags := r.Tags
+    if tags == nil { tags = []string{} }
+    return RepositoryV2{
+        Path: r.Path, Name: r.Name, Account: r.Account, Folder: r.FolderName, IsOrg: r.IsOrg,
+        RemoteURL: r.RemoteURL, Branch: r.Branch, IsClean: r.IsClean, Uncommitted: r.Uncommitted,
+        Ahead: r.Ahead, Behind: r.Behind, LastCommit: r.LastCommit, LastCommitAt: r.LastCommitAt,
+        LastFetch: r.LastFetch, HasStash: r.HasStash, HasUpstream: r.HasUpstream, Tags: tags, ScanTime: r.ScanTime,
+    }
+}
+
+// StatusResponse is returned by /v1/status. With fields selected, each
+// item holds only those keys.
+type StatusResponse struct {
+    SchemaVersion string       `json:"schema_version"`
+    Data          []Repository `json:"data"`
+    Total         int          `json:"total" doc:"Repositories matching the filters across all pages"`
+    NextCursor    string       `json:"next_cursor,omitempty" doc:"Cursor of the next page; absent on the last page"`
+}
+
+// StatusResponseV2 is /v2/status
+type StatusResponseV2 struct {
+    SchemaVersion string         `json:"schema_version"`
+    Data          []RepositoryV2 `json:"data"`
+    Total         int            `json:"total" doc:"Repositories matching the filters across all pages"`
+    NextCursor    string         `json:"next_cursor,omitempty" doc:"Cursor of the next page; absent on the last page"`
+}
+
+// ScanResponse is returned by /v1/scan
+type ScanResponse struct {
+    SchemaVersion string `json:"schema_version"`
+    Count         int    `json:"count"`
+}
+
+// OrganizePlanResponse is returned by /v1/organize/plan
+type OrganizePlanResponse struct {
+    SchemaVersion string     `json:"schema_version"`
+    Data          []MovePlan `json:"data"`
+}
+
+// OrganizeApplyResponse is returned by /v1/organize/apply
+type OrganizeApplyResponse struct {
+    SchemaVersion string           `json:"schema_version"`
+    Moved         int              `json:"moved"`
+    Failed        int              `json:"failed"`
+    Results       []OrganizeResult `json:"results"`
+}
+
+// FetchResult is one repository's fetch in ds.v1. Error is {} when the
+// fetch failed; the message is only available in ds.v2.
+type FetchResult struct {
+    RepoName string        `json:"RepoName"`
+    Success  bool          `json:"Success"`
+    Error    *struct{}     `json:"Error"`
+    Duration time.Duration `json:"Duration"`
+}
+
+// FetchResultV2 is the ds.v2 form of a fetch result, with the error as a
+// string and the duration in milliseconds
+type FetchResultV2 struct {
+    Repo       string `json:"repo"`
+    Success    bool   `json:"success"`
+    Error      string `json:"error,omitempty"`
+    DurationMs int64  `json:"duration_ms"`
+}
+
+// NewFetchResult converts a fetch result to its ds.v1 form
+func NewFetchResult(r scan.FetchResult) FetchResult {
+    out := FetchResult{RepoName: r.RepoName, Success: r.Success, Duration: r.Duration}
+    if r.Error != nil { out.Error = &struct{}{} }
+    return out
+}
+
+// NewFetchResultV2 converts a fetch result to its ds.v2 form
+func NewFetchResultV2(r scan.FetchResult) FetchResultV2 {
+    out := FetchResultV2{Repo: r.RepoName, Success: r.Success, DurationMs: r.Duration.Milliseconds()}
+    if r.Error != nil { out.Error = r.Error.Error() }
+    return out
+}
+
+// FetchResponse is returned by /v1/fetch
+type FetchResponse struct {
+    SchemaVersion string        `json:"schema_version"`
+    Results       []FetchResult `json:"results"`
+}
+
+// FetchResponseV2 is /v2/fetch
+type FetchResponseV2 struct {
+    SchemaVersion string          `json:"schema_version"`
+    Results       []FetchResultV2 `json:"results"`
+}
+
+// PolicyResponse is returned by /v1/policy/check in JSON format
+type PolicyResponse struct {
+    SchemaVersion   string       `json:"schema_version"`
+    Report          PolicyReport `json:"report"`
+    FailedThreshold bool         `json:"failed_threshold"`
+}
+
+// CommandsResponse is returned by /v1/commands
+type CommandsResponse struct {
+    SchemaVersion string                     `json:"schema_version"`
+    Commands      map[string]CommandTemplate `json:"commands"`
+    RawExec       bool                       `json:"raw_exec" doc:"Whether the server accepts raw cmd"`
+}
+
+// ExecRequest is the body of /v1/exec. Set Command (a config template
+// name) with Params, or Cmd for a raw command.
+type ExecRequest struct {
+    Command string            `json:"command,omitempty" doc:"Name of a template in config commands"`
+    Params  map[string]string `json:"params,omitempty" doc:"Template parameters"`
+    Cmd     string            `json:"cmd,omitempty" doc:"Raw shell command, when the server allows it"`
+    Path    string            `json:"path,omitempty"`
+    Account string            `json:"account,omitempty"`
+    Dirty   bool              `json:"dirty,omitempty"`
+    Timeout int               `json:"timeout,omitempty" doc:"Seconds per repository; overrides the template's timeout"`
+}
+
+// ExecResponse is returned by /v1/exec
+type ExecResponse struct {
+    SchemaVersion string       `json:"schema_version"`
+    Command       string       `json:"command,omitempty"`
+    Rendered      string       `json:"rendered" doc:"The shell command that ran"`
+    Results       []ExecResult `json:"results"`
+}
+
+// ManifestResponse is returned by GET /v1/manifest
+type ManifestResponse struct {
+    SchemaVersion string   `json:"schema_version"`
+    Data          Manifest `json:"data"`
+}
+
+// ManifestApplyResponse is returned by POST /v1/manifest
+type ManifestApplyResponse struct {
+    SchemaVersion string                `json:"schema_version"`
+    Cloned        int                   `json:"cloned"`
+    Present       int                   `json:"present"`
+    Failed        int                   `json:"failed"`
+    Results       []ManifestApplyResult `json:"results"`
+    Extra         []ExtraRepo           `json:"extra" doc:"Repositories on disk that the manifest does not list"`
+}
@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+	"gopkg.in/yaml.v3"
+)
+
+// Scopes grant access to groups of API endpoints
+const (
+	ScopeRead     = "read"     // Status, scan, plans, discovery
+	ScopeFetch    = "fetch"    // git fetch across repositories
+	ScopeExec     = "exec"     // Command templates and policy checks
+	ScopeOrganize = "organize" // Moving repositories and applying manifests
+)
+
+// Scopes lists every scope, in order of increasing privilege
+var Scopes = []string{ScopeRead, ScopeFetch, ScopeExec, ScopeOrganize}
+
+// tokenPrefix marks ds API tokens so they are easy to spot in configs and logs
+const tokenPrefix = "ds_"
+
+// Token is a named API token. Only a SHA-256 hash of the secret is stored.
+type Token struct {
+	Name      string     `yaml:"name" json:"name"`
+	Hash      string     `yaml:"hash" json:"-"`
+	Scopes    []string   `yaml:"scopes" json:"scopes"`
+	CreatedAt time.Time  `yaml:"created_at" json:"created_at"`
+	ExpiresAt *time.Time `yaml:"expires_at,omitempty" json:"expires_at,omitempty"`
+}
+
+// Expired reports whether the token has expired at now
+func (t Token) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// Allows reports whether the token grants scope
+func (t Token) Allows(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+// Store is a token file. It reloads itself when the file changes, so
+// tokens created or revoked by `ds token` apply to a running server.
+type Store struct {
+	path string
+
+	mu      sync.Mutex
+	tokens  []Token
+	modTime time.Time
+}
+
+type tokenFile struct {
+	Tokens []Token `yaml:"tokens"`
+}
+
+// DefaultPath returns the default token file path using XDG
+func DefaultPath() string {
+	return filepath.Join(xdg.ConfigHome, "ds", "tokens.yaml")
+}
+
+// Open loads the token file at path; a missing file is an empty store
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+	if err := s.reload(true); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reload re-reads the file when its modification time changed
+func (s *Store) reload(force bool) error {
+	info, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		s.tokens, s.modTime = nil, time.Time{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !force && info.ModTime().Equal(s.modTime) {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var f tokenFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("parsing %s: %w", s.path, err)
+	}
+	s.tokens, s.modTime = f.Tokens, info.ModTime()
+	return nil
+}
+
+func (s *Store) save() error {
+	data, err := yaml.Marshal(tokenFile{Tokens: s.tokens})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return err
+	}
+	return s.reload(true)
+}
+
+// Tokens returns the stored tokens
+func (s *Store) Tokens() []Token {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.reload(false)
+	return slices.Clone(s.tokens)
+}
+
+// Exists reports whether the token file exists
+func (s *Store) Exists() bool {
+	_, err := os.Stat(s.path)
+	return err == nil
+}
+
+// Empty reports whether the store holds no tokens
+func (s *Store) Empty() bool {
+	return len(s.Tokens()) == 0
+}
+
+// Create adds a token and returns its secret, which is not stored and
+// cannot be shown again. A zero ttl never expires.
+func (s *Store) Create(name string, scopes []string, ttl time.Duration) (string, Token, error) {
+	if name == "" {
+		return "", Token{}, fmt.Errorf("token name is required")
+	}
+	if len(scopes) == 0 {
+		return "", Token{}, fmt.Errorf("at least one scope is required (%s)", strings.Join(Scopes, ", "))
+	}
+	for _, sc := range scopes {
+		if !slices.Contains(Scopes, sc) {
+			return "", Token{}, fmt.Errorf("unknown scope %q (use %s)", sc, strings.Join(Scopes, ", "))
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(false); err != nil {
+		return "", Token{}, err
+	}
+	for _, t := range s.tokens {
+		if t.Name == name {
+			return "", Token{}, fmt.Errorf("token %q already exists; revoke it first", name)
+		}
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", Token{}, err
+	}
+	secret := tokenPrefix + hex.EncodeToString(buf)
+	tok := Token{Name: name, Hash: hash(secret), Scopes: scopes, CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	if ttl > 0 {
+		exp := tok.CreatedAt.Add(ttl)
+		tok.ExpiresAt = &exp
+	}
+	s.tokens = append(s.tokens, tok)
+	if err := s.save(); err != nil {
+		return "", Token{}, err
+	}
+	return secret, tok, nil
+}
+
+// Revoke removes the named token
+func (s *Store) Revoke(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(false); err != nil {
+		return err
+	}
+	i := slices.IndexFunc(s.tokens, func(t Token) bool { return t.Name == name })
+	if i < 0 {
+		return fmt.Errorf("no token named %q", name)
+	}
+	s.tokens = slices.Delete(s.tokens, i, i+1)
+	return s.save()
+}
+
+// Verify returns the token matching secret. Every stored hash is compared
+// in constant time so the result does not leak which token matched.
+func (s *Store) Verify(secret string, now time.Time) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(false); err != nil {
+		return nil, err
+	}
+	sum := []byte(hash(secret))
+	var found *Token
+	for i := range s.tokens {
+		if subtle.ConstantTimeCompare(sum, []byte(s.tokens[i].Hash)) == 1 {
+			t := s.tokens[i]
+			found = &t
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("invalid token")
+	}
+	if found.Expired(now) {
+		return nil, fmt.Errorf("token %q expired", found.Name)
+	}
+	return found, nil
+}
+
+// Equal compares two secrets in constant time
+func Equal(a, b string) bool {
+	ha, hb := sha256.Sum256([]byte(a)), sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(ha[:], hb[:]) == 1
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "tokens.yaml"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	reader, _, err := s.Create("reader", []string{ScopeRead}, 0)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	shortLived, _, err := s.Create("short", []string{ScopeRead, ScopeExec}, time.Hour)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		secret  string
+		now     time.Time
+		want    string // Token name, or "" for an error
+		wantErr string
+	}{
+		{"valid", reader, now, "reader", ""},
+		{"valid before expiry", shortLived, now, "short", ""},
+		{"expired", shortLived, now.Add(2 * time.Hour), "", `token "short" expired`},
+		{"bad secret", "ds_" + strings.Repeat("0", 64), now, "", "invalid token"},
+		{"empty secret", "", now, "", "invalid token"},
+		{"secret with a character changed", reader[:len(reader)-1] + "x", now, "", "invalid token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tok, err := s.Verify(tt.secret, tt.now)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got %v, %v; want error %q", tok, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if tok.Name != tt.want {
+				t.Fatalf("got token %q, want %q", tok.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllows(t *testing.T) {
+	tok := Token{Name: "t", Scopes: []string{ScopeRead, ScopeFetch}}
+	for scope, want := range map[string]bool{
+		ScopeRead:     true,
+		ScopeFetch:    true,
+		ScopeExec:     false,
+		ScopeOrganize: false,
+		"":            false,
+	} {
+		if got := tok.Allows(scope); got != want {
+			t.Errorf("Allows(%q) = %v, want %v", scope, got, want)
+		}
+	}
+}
+
+func TestRevoked(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "tokens.yaml"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	secret, _, err := s.Create("gone", []string{ScopeRead}, 0)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := s.Revoke("gone"); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, err := s.Verify(secret, time.Now()); err == nil {
+		t.Fatal("revoked token still verifies")
+	}
+	if !s.Empty() || !s.Exists() {
+		t.Fatalf("after revoking the only token: empty=%v exists=%v", s.Empty(), s.Exists())
+	}
+}
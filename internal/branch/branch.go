@@ -0,0 +1,203 @@
+// Package branch creates, switches and deletes a branch across
+// repositories and syncs their default branches, reporting one result per
+// repository. Operations that would check out over uncommitted work refuse
+// dirty repositories unless told otherwise.
+package branch
+
+import (
+    "fmt"
+    "time"
+
+    "github.com/verlyn13/ds-go/internal/git"
+    "github.com/verlyn13/ds-go/internal/scan"
+)
+
+// Actions reported in Result and SyncResult
+const (
+    ActionCreated       = "created"
+    ActionSwitched      = "switched"
+    ActionDeleted       = "deleted"
+    ActionExists        = "exists"     // create found the branch already there
+    ActionCurrent       = "current"    // switch found the branch checked out
+    ActionMissing       = "missing"    // The repository has no such branch
+    ActionUpToDate      = "up-to-date"
+    ActionFastForwarded = "fast-forwarded"
+    ActionRebased       = "rebased"
+)
+
+// Result is the outcome of a branch operation in one repository
+type Result struct {
+    Repo       string `json:"repo"`
+    Path       string `json:"path"`
+    Branch     string `json:"branch"`
+    Action     string `json:"action,omitempty"`
+    Switched   bool   `json:"switched"` // The branch was checked out
+    Success    bool   `json:"success"`
+    Error      string `json:"error,omitempty"`
+    DurationMs int64  `json:"duration_ms"`
+}
+
+// SyncResult is the outcome of Sync in one repository
+type SyncResult struct {
+    Repo          string `json:"repo"`
+    Path          string `json:"path"`
+    Branch        string `json:"branch"`            // The default branch
+    Current       string `json:"current,omitempty"` // The checked out branch
+    Action        string `json:"action,omitempty"`
+    FastForwarded int    `json:"fast_forwarded"` // Commits the default branch gained
+    Rebased       int    `json:"rebased"`        // Commits of the current branch replayed
+    Success       bool   `json:"success"`
+    Error         string `json:"error,omitempty"`
+    DurationMs    int64  `json:"duration_ms"`
+}
+
+// SyncOptions controls Sync
+type SyncOptions struct {
+    Fetch      bool // Fetch origin first
+    Rebase     bool // Rebase the current branch onto the default branch
+    AllowDirty bool // Update the checked out branch despite uncommitted work
+}
+
+// Create creates branch name in each repository, at from or HEAD, and
+// checks it out when switchTo is set. A repository that already has the
+// branch is left as it is apart from the checkout.
+func Create(repos []scan.Repository, name, from string, switchTo, allowDirty bool) []Result {
+    g := git.New()
+    return each(repos, name, func(r scan.Repository, res *Result) error {
+        if switchTo {
+            if err := clean(r, allowDirty); err != nil { return err }
+        }
+        res.Action = ActionExists
+        if !g.HasRef(r.Path, "refs/heads/"+name) {
+            if from != "" && !g.HasRef(r.Path, from) { return fmt.Errorf("no %s to branch from", from) }
+            if err := g.CreateBranch(r.Path, name, from); err != nil { return err }
+            res.Action = ActionCreated
+        }
+        if !switchTo { return nil }
+        cur, err := g.CurrentBranch(r.Path)
+        if err != nil || cur == name { return err }
+        if err := g.SwitchBranch(r.Path, name); err != nil { return err }
+        res.Switched = true
+        if res.Action == ActionExists { res.Action = ActionSwitched }
+        return nil
+    })
+}
+
+// Switch checks out branch name in each repository that has it locally or
+// on origin
+func Switch(repos []scan.Repository, name string, allowDirty bool) []Result {
+    g := git.New()
+    return each(repos, name, func(r scan.Repository, res *Result) error {
+        cur, err := g.CurrentBranch(r.Path)
+        if err != nil { return err }
+        switch {
+        case cur == name:
+            res.Action = ActionCurrent
+            return nil
+        case !g.HasRef(r.Path, "refs/heads/"+name) && !g.HasRef(r.Path, "refs/remotes/origin/"+name):
+            res.Action = ActionMissing
+            return nil
+        }
+        if err := clean(r, allowDirty); err != nil { return err }
+        if err := g.SwitchBranch(r.Path, name); err != nil { return err }
+        res.Action, res.Switched = ActionSwitched, true
+        return nil
+    })
+}
+
+// Delete deletes branch name from each repository that has it; force
+// deletes unmerged branches too. A checked out branch is never deleted.
+func Delete(repos []scan.Repository, name string, force bool) []Result {
+    g := git.New()
+    return each(repos, name, func(r scan.Repository, res *Result) error {
+        if !g.HasRef(r.Path, "refs/heads/"+name) {
+            res.Action = ActionMissing
+            return nil
+        }
+        if cur, _ := g.CurrentBranch(r.Path); cur == name {
+            return fmt.Errorf("%s is checked out", name)
+        }
+        if err := g.DeleteBranch(r.Path, name, force); err != nil { return err }
+        res.Action = ActionDeleted
+        return nil
+    })
+}
+
+// Sync fast-forwards each repository's default branch to origin's, and
+// with opts.Rebase rebases the checked out branch onto it. The default
+// branch is origin's HEAD, main or master, never the checked out branch;
+// one missing locally is created from origin's.
+func Sync(repos []scan.Repository, opts SyncOptions) []SyncResult {
+    g := git.New()
+    results := make([]SyncResult, 0, len(repos))
+    for _, r := range repos {
+        start := time.Now()
+        res := SyncResult{Repo: r.Name, Path: r.Path, Action: ActionUpToDate}
+        if err := syncRepo(g, r, opts, &res); err != nil {
+            res.Error = err.Error()
+        } else {
+            res.Success = true
+        }
+        res.DurationMs = time.Since(start).Milliseconds()
+        results = append(results, res)
+    }
+    return results
+}
+
+func syncRepo(g *git.Git, r scan.Repository, opts SyncOptions, res *SyncResult) error {
+    if opts.Fetch {
+        if err := g.FetchRemote(r.Path, "origin"); err != nil { return fmt.Errorf("fetch: %w", err) }
+    }
+    def, err := g.OriginDefaultBranch(r.Path)
+    if err != nil { return err }
+    res.Branch = def
+    upstream := "refs/remotes/origin/" + def
+    if !g.HasRef(r.Path, upstream) { return fmt.Errorf("no origin/%s", def) }
+    cur, err := g.CurrentBranch(r.Path)
+    if err != nil { return err }
+    res.Current = cur
+
+    if !g.HasRef(r.Path, "refs/heads/"+def) {
+        if err := g.CreateBranch(r.Path, def, "origin/"+def); err != nil { return err }
+        res.Action = ActionCreated
+    } else {
+        if cur == def {
+            if err := clean(r, opts.AllowDirty); err != nil { return err }
+        }
+        n, err := g.FastForward(r.Path, def, upstream, cur == def)
+        if err != nil { return err }
+        res.FastForwarded = n
+        if n > 0 { res.Action = ActionFastForwarded }
+    }
+
+    if !opts.Rebase || cur == "" || cur == def { return nil }
+    if !r.IsClean { return fmt.Errorf("%d uncommitted changes on %s, not rebasing", r.Uncommitted, cur) }
+    n, err := g.Rebase(r.Path, def)
+    if err != nil { return err }
+    res.Rebased = n
+    if n > 0 { res.Action = ActionRebased }
+    return nil
+}
+
+// clean refuses a repository with uncommitted work unless allowDirty is set
+func clean(r scan.Repository, allowDirty bool) error {
+    if allowDirty || r.IsClean { return nil }
+    return fmt.Errorf("%d uncommitted changes (commit or snapshot them, or use --allow-dirty)", r.Uncommitted)
+}
+
+// each runs fn for every repository, timing it and recording its error
+func each(repos []scan.Repository, name string, fn func(scan.Repository, *Result) error) []Result {
+    results := make([]Result, 0, len(repos))
+    for _, r := range repos {
+        start := time.Now()
+        res := Result{Repo: r.Name, Path: r.Path, Branch: name}
+        if err := fn(r, &res); err != nil {
+            res.Error = err.Error()
+        } else {
+            res.Success = true
+        }
+        res.DurationMs = time.Since(start).Milliseconds()
+        results = append(results, res)
+    }
+    return results
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CommandTemplate is a named command for `ds exec --saved`, the dashboard
+// and the exec API. A plain string in config is shorthand for {run: ...}.
+//
+//	commands:
+//	  test: go test ./...
+//	  lint:
+//	    run: golangci-lint run {{.target}}
+//	    params:
+//	      target: {type: string, default: ./..., pattern: '^[./\w-]+$'}
+type CommandTemplate struct {
+	Run         string                  `yaml:"run" json:"run"`
+	Description string                  `yaml:"description,omitempty" json:"description,omitempty"`
+	Params      map[string]CommandParam `yaml:"params,omitempty" json:"params,omitempty"`
+	Timeout     string                  `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// CommandParam declares a typed template parameter
+type CommandParam struct {
+	Type     string   `yaml:"type,omitempty" json:"type,omitempty"`       // string (default), int, bool or enum
+	Values   []string `yaml:"values,omitempty" json:"values,omitempty"`   // Allowed enum values
+	Pattern  string   `yaml:"pattern,omitempty" json:"pattern,omitempty"` // Regex a string value must match
+	Default  string   `yaml:"default,omitempty" json:"default,omitempty"`
+	Required bool     `yaml:"required,omitempty" json:"required,omitempty"`
+}
+
+// UnmarshalYAML accepts either a command string or a template mapping
+func (t *CommandTemplate) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = CommandTemplate{Run: node.Value}
+		return nil
+	}
+	type plain CommandTemplate
+	return node.Decode((*plain)(t))
+}
+
+// MarshalYAML writes templates without parameters as a plain string
+func (t CommandTemplate) MarshalYAML() (interface{}, error) {
+	if t.Description == "" && len(t.Params) == 0 && t.Timeout == "" {
+		return t.Run, nil
+	}
+	type plain CommandTemplate
+	return plain(t), nil
+}
+
+// UnmarshalJSON accepts either a command string or a template object
+func (t *CommandTemplate) UnmarshalJSON(data []byte) error {
+	var run string
+	if err := json.Unmarshal(data, &run); err == nil {
+		*t = CommandTemplate{Run: run}
+		return nil
+	}
+	type plain CommandTemplate
+	return json.Unmarshal(data, (*plain)(t))
+}
+
+// String returns the unrendered command
+func (t CommandTemplate) String() string { return t.Run }
+
+// TimeoutDuration returns the template's timeout, or 0 when unset or invalid
+func (t CommandTemplate) TimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(t.Timeout)
+	return d
+}
+
+// Render validates args against the declared parameters and returns the
+// command with every value shell-quoted. Unknown and missing required
+// parameters are errors.
+func (t CommandTemplate) Render(args map[string]string) (string, error) {
+	for name := range args {
+		if _, ok := t.Params[name]; !ok {
+			return "", fmt.Errorf("unknown parameter %q (declared: %s)", name, strings.Join(t.ParamNames(), ", "))
+		}
+	}
+
+	values := make(map[string]string, len(t.Params))
+	for name, p := range t.Params {
+		v, ok := args[name]
+		if !ok {
+			if p.Required {
+				return "", fmt.Errorf("missing required parameter %q", name)
+			}
+			v = p.Default
+		}
+		v, err := p.check(v)
+		if err != nil {
+			return "", fmt.Errorf("parameter %q: %w", name, err)
+		}
+		values[name] = ShellQuote(v)
+	}
+
+	tmpl, err := template.New("command").Option("missingkey=error").Parse(t.Run)
+	if err != nil {
+		return "", fmt.Errorf("invalid command template: %w", err)
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, values); err != nil {
+		return "", fmt.Errorf("rendering command: %w", err)
+	}
+	return b.String(), nil
+}
+
+// ParamNames returns the declared parameter names, sorted
+func (t CommandTemplate) ParamNames() []string {
+	names := make([]string, 0, len(t.Params))
+	for name := range t.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// check validates and normalises one value
+func (p CommandParam) check(v string) (string, error) {
+	switch p.Type {
+	case "", "string":
+		if p.Pattern != "" {
+			re, err := regexp.Compile("^(?:" + p.Pattern + ")$")
+			if err != nil {
+				return "", fmt.Errorf("invalid pattern: %w", err)
+			}
+			if !re.MatchString(v) {
+				return "", fmt.Errorf("%q does not match %s", v, p.Pattern)
+			}
+		}
+		return v, nil
+	case "int":
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return "", fmt.Errorf("%q is not an integer", v)
+		}
+		return strconv.Itoa(n), nil
+	case "bool":
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a boolean", v)
+		}
+		return strconv.FormatBool(b), nil
+	case "enum":
+		for _, allowed := range p.Values {
+			if v == allowed {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("%q is not one of %s", v, strings.Join(p.Values, ", "))
+	}
+	return "", fmt.Errorf("unknown type %q", p.Type)
+}
+
+// ShellQuote quotes s for safe use as a single /bin/sh word
+func ShellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,+@%", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
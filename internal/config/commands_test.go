@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tmpl := CommandTemplate{
+		Run: "run {{.target}} -n {{.count}} -v={{.verbose}} --mode {{.mode}}",
+		Params: map[string]CommandParam{
+			"target":  {Default: "./...", Pattern: `[./\w-]+`},
+			"count":   {Type: "int", Default: "1"},
+			"verbose": {Type: "bool", Default: "false"},
+			"mode":    {Type: "enum", Values: []string{"fast", "full"}, Required: true},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		tmpl    CommandTemplate
+		args    map[string]string
+		want    string
+		wantErr string // Substring of the expected error
+	}{
+		{"defaults", tmpl, map[string]string{"mode": "fast"}, "run ./... -n 1 -v=false --mode fast", ""},
+		{"all given", tmpl, map[string]string{"target": "./cmd", "count": "007", "verbose": "1", "mode": "full"}, "run ./cmd -n 7 -v=true --mode full", ""},
+		{"unknown param", tmpl, map[string]string{"mode": "fast", "extra": "x"}, "", `unknown parameter "extra"`},
+		{"missing required", tmpl, map[string]string{}, "", `missing required parameter "mode"`},
+		{"not an int", tmpl, map[string]string{"mode": "fast", "count": "1; rm -rf /"}, "", "is not an integer"},
+		{"not a bool", tmpl, map[string]string{"mode": "fast", "verbose": "yes"}, "", "is not a boolean"},
+		{"not in enum", tmpl, map[string]string{"mode": "slow"}, "", "is not one of fast, full"},
+		{"pattern mismatch", tmpl, map[string]string{"mode": "fast", "target": "./x $(id)"}, "", "does not match"},
+		{"pattern anchored", tmpl, map[string]string{"mode": "fast", "target": "./x\n"}, "", "does not match"},
+		{"unknown type", CommandTemplate{Run: "{{.n}}", Params: map[string]CommandParam{"n": {Type: "float"}}}, nil, "", `unknown type "float"`},
+		{"undeclared key", CommandTemplate{Run: "echo {{.missing}}"}, nil, "", "rendering command"},
+		{"bad template", CommandTemplate{Run: "echo {{.x"}, nil, "", "invalid command template"},
+		{"quoted value", CommandTemplate{Run: "echo {{.msg}}", Params: map[string]CommandParam{"msg": {}}}, map[string]string{"msg": "it's $(id); ls"}, `echo 'it'\''s $(id); ls'`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.tmpl.Render(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{"./cmd/ds-go", "./cmd/ds-go"},
+		{"", "''"},
+		{"two words", "'two words'"},
+		{"it's", `'it'\''s'`},
+		{"$(id)", "'$(id)'"},
+		{"a; rm -rf /", "'a; rm -rf /'"},
+		{"`id` && $HOME", "'`id` && $HOME'"},
+		{"line\nbreak", "'line\nbreak'"},
+		{"'", `''\'''`},
+	}
+	for _, tt := range tests {
+		if got := ShellQuote(tt.in); got != tt.want {
+			t.Errorf("ShellQuote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestShellQuoteWord checks with /bin/sh that each quoted value is read
+// back as exactly one word, unchanged
+func TestShellQuoteWord(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	for _, in := range []string{"it's", "$(id)", "a; b", "two  spaces", "'$HOME' \"x\"", "", "tab\there", "back\\slash"} {
+		out, err := exec.Command("sh", "-c", "set -- "+ShellQuote(in)+`; printf '%s|%s' "$#" "$1"`).Output()
+		if err != nil {
+			t.Fatalf("sh for %q: %v", in, err)
+		}
+		if want := "1|" + in; string(out) != want {
+			t.Errorf("sh read %q back as %q, want %q", in, out, want)
+		}
+	}
+}
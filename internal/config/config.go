@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/adrg/xdg"
 	"gopkg.in/yaml.v3"
@@ -18,6 +20,72 @@ type Config struct {
 	Accounts map[string]AccountConfig     `yaml:"accounts" json:"accounts"`
 	Orgs     map[string]string           `yaml:"organizations" json:"organizations"`
 	Folders  map[string][]string         `yaml:"folder_structure" json:"folder_structure"`
+	Tags     map[string][]string         `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Commands map[string]CommandTemplate  `yaml:"commands,omitempty" json:"commands,omitempty"`
+	Display  DisplayConfig               `yaml:"display,omitempty" json:"display,omitempty"`
+	Events   EventsConfig                `yaml:"events,omitempty" json:"events,omitempty"`
+}
+
+// DisplayConfig holds defaults for the status table
+type DisplayConfig struct {
+	Columns []string `yaml:"columns,omitempty" json:"columns,omitempty"` // Column keys, in order
+	SortBy  string   `yaml:"sort,omitempty" json:"sort,omitempty"`       // Sort key; prefix with - to reverse
+	GroupBy string   `yaml:"group_by,omitempty" json:"group_by,omitempty"` // account, folder, tag or none
+}
+
+// EventsConfig holds where ds events are delivered
+type EventsConfig struct {
+	Log        string          `yaml:"log,omitempty" json:"log,omitempty"`                 // Event log; default $XDG_STATE_HOME/ds/events.log
+	DeadLetter string          `yaml:"dead_letter,omitempty" json:"dead_letter,omitempty"` // Undeliverable webhook events; default $XDG_STATE_HOME/ds/webhooks.dead.jsonl
+	Webhooks   []WebhookConfig `yaml:"webhooks,omitempty" json:"webhooks,omitempty"`
+	Hooks      []EventHook     `yaml:"hooks,omitempty" json:"hooks,omitempty"`
+	StaleAfter string          `yaml:"stale_after,omitempty" json:"stale_after,omitempty"` // How long a repository stays dirty before repo.stale; default 72h, "0" disables
+}
+
+// EventHook runs a shell command for each matching event. The event is
+// passed as JSON on stdin and as DS_* environment variables.
+//
+//	events:
+//	  hooks:
+//	    - on: [repo.stale]
+//	      run: notify-send "ds" "$DS_REPO has had uncommitted changes since $DS_DIRTY_SINCE"
+//	    - on: [repo.behind]
+//	      branch: main
+//	      run: ./scripts/new-commits.sh
+//	      timeout: 2m
+type EventHook struct {
+	Name    string   `yaml:"name,omitempty" json:"name,omitempty"`       // Shown in logs; defaults to the command
+	On      []string `yaml:"on" json:"on"`                               // Event types
+	Repos   []string `yaml:"repos,omitempty" json:"repos,omitempty"`     // Only these repositories (names or globs)
+	Branch  string   `yaml:"branch,omitempty" json:"branch,omitempty"`   // Only events on this branch
+	Run     string   `yaml:"run" json:"run"`                             // Shell command, run in the repository when the event has one
+	Timeout string   `yaml:"timeout,omitempty" json:"timeout,omitempty"` // Go duration; default 30s
+}
+
+// WebhookConfig is an endpoint that receives events as JSON POSTs
+type WebhookConfig struct {
+	URL    string   `yaml:"url" json:"url"`
+	Secret string   `yaml:"secret,omitempty" json:"secret,omitempty"` // HMAC-SHA256 key for DS-Signature; a value that is only $VAR or ${VAR} is read from the environment
+	Events []string `yaml:"events,omitempty" json:"events,omitempty"` // Event types to send; empty sends all
+}
+
+// StaleAfterDuration returns how long a repository stays dirty before a
+// repo.stale event: 72h when unset or invalid, 0 when disabled
+func (e EventsConfig) StaleAfterDuration() time.Duration {
+	d, err := time.ParseDuration(e.StaleAfter)
+	if err != nil || d < 0 {
+		return 72 * time.Hour
+	}
+	return d
+}
+
+// TimeoutDuration returns the hook's timeout, 30s when unset or invalid
+func (h EventHook) TimeoutDuration() time.Duration {
+	d, err := time.ParseDuration(h.Timeout)
+	if err != nil || d <= 0 {
+		return 30 * time.Second
+	}
+	return d
 }
 
 // AccountConfig holds account-specific configuration
@@ -27,6 +95,21 @@ type AccountConfig struct {
 	Email   string `yaml:"email" json:"email"`
 }
 
+// TagsFor returns the tags assigned to a repository name, sorted
+func (c *Config) TagsFor(repoName string) []string {
+	var tags []string
+	for tag, repos := range c.Tags {
+		for _, name := range repos {
+			if name == repoName {
+				tags = append(tags, tag)
+				break
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
 // DefaultPath returns the default config file path using XDG
 func DefaultPath() string {
 	return filepath.Join(xdg.ConfigHome, "ds", "config.yaml")
@@ -0,0 +1,190 @@
+package events
+
+import (
+    "bytes"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strconv"
+    "sync"
+    "time"
+
+    "github.com/verlyn13/ds-go/internal/config"
+)
+
+// Webhook delivery: each event is POSTed as JSON, retried with doubling
+// backoff on network errors, 429 and 5xx, and written to the dead-letter
+// file once the attempts run out
+const (
+    webhookAttempts = 4
+    webhookBackoff  = 500 * time.Millisecond
+    webhookTimeout  = 10 * time.Second
+)
+
+// Bus publishes events to the log, the configured webhooks and the hook
+// commands. A nil *Bus discards events.
+type Bus struct {
+    log       *Log
+    webhooks  []config.WebhookConfig
+    hooks     []config.EventHook
+    hookSlots chan struct{}
+    dead      string
+    client    *http.Client
+
+    mu sync.Mutex // Guards dead-letter appends
+    wg sync.WaitGroup
+}
+
+// NewBus returns a Bus for cfg, logging to DefaultLog and dead-lettering
+// to DefaultDeadLetter unless cfg names other files
+func NewBus(cfg config.EventsConfig) *Bus {
+    path := cfg.Log
+    if path == "" { path = DefaultLog() }
+    dead := cfg.DeadLetter
+    if dead == "" { dead = DefaultDeadLetter() }
+    for _, h := range cfg.Webhooks {
+        for _, typ := range h.Events {
+            if !ValidType(typ) { log.Printf("events: webhook %s names unknown event type %q", h.URL, typ) }
+        }
+    }
+    for _, h := range cfg.Hooks {
+        for _, typ := range h.On {
+            if !ValidType(typ) { log.Printf("events: hook %q names unknown event type %q", h.Run, typ) }
+        }
+    }
+    return &Bus{
+        log:       OpenLog(path),
+        webhooks:  cfg.Webhooks,
+        hooks:     cfg.Hooks,
+        hookSlots: make(chan struct{}, maxHooks),
+        dead:      dead,
+        client:    &http.Client{Timeout: webhookTimeout},
+    }
+}
+
+// Log returns the event log the bus appends to
+func (b *Bus) Log() *Log {
+    if b == nil { return nil }
+    return b.log
+}
+
+// Publish logs each event and starts its webhook deliveries and hook
+// commands. Failures are reported on the standard logger; publishing never
+// fails the caller.
+func (b *Bus) Publish(evs ...Event) {
+    if b == nil { return }
+    for _, e := range evs {
+        if err := b.log.Append(e); err != nil { log.Printf("events: %v", err) }
+        for _, h := range b.webhooks {
+            if !e.Match(h.Events) { continue }
+            b.wg.Add(1)
+            go func() {
+                defer b.wg.Done()
+                b.deliver(h, e)
+            }()
+        }
+        for _, h := range b.hooks {
+            if !hookMatches(h, e) { continue }
+            b.wg.Add(1)
+            go func() {
+                defer b.wg.Done()
+                b.runHook(h, e)
+            }()
+        }
+    }
+}
+
+// Close waits for pending webhook deliveries, including their retries, and
+// for running hook commands
+func (b *Bus) Close() {
+    if b == nil { return }
+    b.wg.Wait()
+}
+
+// deliver posts e to h, retrying transient failures
+func (b *Bus) deliver(h config.WebhookConfig, e Event) {
+    body, err := json.Marshal(e)
+    if err != nil { b.deadLetter(h, e, 0, err); return }
+    secret := expandSecret(h.Secret)
+    backoff := webhookBackoff
+    for attempt := 1; ; attempt++ {
+        retry, err := b.post(h.URL, secret, e, body)
+        if err == nil { return }
+        if !retry || attempt == webhookAttempts { b.deadLetter(h, e, attempt, err); return }
+        time.Sleep(backoff)
+        backoff *= 2
+    }
+}
+
+// post sends one delivery. It reports whether a failure is worth retrying.
+func (b *Bus) post(url, secret string, e Event, body []byte) (bool, error) {
+    req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+    if err != nil { return false, err }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("User-Agent", "ds-events")
+    req.Header.Set("DS-Event", e.Type)
+    req.Header.Set("DS-Delivery", e.ID)
+    if secret != "" {
+        // Each attempt is signed afresh so receivers can reject old timestamps
+        ts := strconv.FormatInt(time.Now().Unix(), 10)
+        req.Header.Set("DS-Timestamp", ts)
+        req.Header.Set("DS-Signature", Sign(secret, ts, body))
+    }
+    resp, err := b.client.Do(req)
+    if err != nil { return true, err }
+    resp.Body.Close()
+    if resp.StatusCode < 300 { return false, nil }
+    retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+    return retry, fmt.Errorf("webhook returned %s", resp.Status)
+}
+
+// Sign returns the DS-Signature header for body sent with the DS-Timestamp
+// header ts: sha256= and the hex HMAC-SHA256 of ts + "." + body keyed with
+// secret. Covering the timestamp stops a captured delivery being replayed
+// later under a new one.
+func Sign(secret, ts string, body []byte) string {
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write([]byte(ts + "."))
+    mac.Write(body)
+    return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// secretVarPattern matches a secret that is wholly $VAR or ${VAR}
+var secretVarPattern = regexp.MustCompile(`^\$(?:([A-Za-z_][A-Za-z0-9_]*)|\{([A-Za-z_][A-Za-z0-9_]*)\})$`)
+
+// expandSecret reads a secret given as $VAR or ${VAR} from the environment;
+// anything else is the secret itself, $ signs included
+func expandSecret(secret string) string {
+    m := secretVarPattern.FindStringSubmatch(secret)
+    if m == nil { return secret }
+    return os.Getenv(m[1] + m[2])
+}
+
+// DeadLetter is one line of the dead-letter file: a delivery given up on
+type DeadLetter struct {
+    Time     time.Time `json:"time"`
+    URL      string    `json:"url"`
+    Attempts int       `json:"attempts"`
+    Error    string    `json:"error"`
+    Event    Event     `json:"event"`
+}
+
+func (b *Bus) deadLetter(h config.WebhookConfig, e Event, attempts int, cause error) {
+    log.Printf("events: giving up on %s for %s %s: %v", h.URL, e.Type, e.ID, cause)
+    line, err := json.Marshal(DeadLetter{Time: time.Now().UTC(), URL: h.URL, Attempts: attempts, Error: cause.Error(), Event: e})
+    if err != nil { return }
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if err := os.MkdirAll(filepath.Dir(b.dead), 0700); err != nil { log.Printf("events: %v", err); return }
+    f, err := os.OpenFile(b.dead, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+    if err != nil { log.Printf("events: %v", err); return }
+    defer f.Close()
+    if _, err := f.Write(append(line, '\n')); err != nil { log.Printf("events: %v", err) }
+}
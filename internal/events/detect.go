@@ -0,0 +1,128 @@
+package events
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "sync"
+    "time"
+
+    "github.com/verlyn13/ds-go/internal/scan"
+)
+
+// Detector turns scans into repo.dirty, repo.stale and repo.behind events
+// by comparing each repository with its state after the previous scan.
+// The state is kept in a file so scans by the CLI and the server share it.
+type Detector struct {
+    mu         sync.Mutex
+    path       string
+    staleAfter time.Duration
+}
+
+// repoState is what the Detector remembers about one repository
+type repoState struct {
+    Dirty      bool       `json:"dirty"`
+    DirtySince *time.Time `json:"dirty_since,omitempty"`
+    Stale      bool       `json:"stale,omitempty"` // repo.stale was sent for this dirty spell
+    Behind     int        `json:"behind"`
+}
+
+// NewDetector returns a Detector keeping its state at path. A repository
+// dirty for staleAfter produces one repo.stale event; 0 disables them.
+func NewDetector(path string, staleAfter time.Duration) *Detector {
+    return &Detector{path: path, staleAfter: staleAfter}
+}
+
+// Observe records repos and returns the events their changes produce. A
+// repository seen for the first time only sets the baseline. Repositories
+// missing from repos keep their state unless their directory is gone, so
+// scans of part of the workspace do not reset the rest.
+func (d *Detector) Observe(repos []scan.Repository) ([]Event, error) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    state, err := d.load()
+    if err != nil { return nil, err }
+
+    var evs []Event
+    now := time.Now().UTC()
+    seen := map[string]bool{}
+    for _, r := range repos {
+        seen[r.Path] = true
+        cur := repoState{Dirty: !r.IsClean, Behind: r.Behind}
+        prev, known := state[r.Path]
+        if cur.Dirty {
+            cur.DirtySince = &now
+            if known && prev.DirtySince != nil { cur.DirtySince, cur.Stale = prev.DirtySince, prev.Stale }
+        }
+        if cur.Dirty && !cur.Stale && d.staleAfter > 0 && now.Sub(*cur.DirtySince) >= d.staleAfter {
+            cur.Stale = true
+            evs = append(evs, New(RepoStale, r.Name, r.Path, map[string]interface{}{
+                "branch":      r.Branch,
+                "uncommitted": r.Uncommitted,
+                "dirty_since": cur.DirtySince.Format(time.RFC3339),
+                "dirty_for":   age(now.Sub(*cur.DirtySince)),
+            }))
+        }
+        state[r.Path] = cur
+        if !known { continue }
+        if cur.Dirty && !prev.Dirty {
+            evs = append(evs, New(RepoDirty, r.Name, r.Path, map[string]interface{}{
+                "branch":      r.Branch,
+                "uncommitted": r.Uncommitted,
+            }))
+        }
+        if cur.Behind > prev.Behind {
+            evs = append(evs, New(RepoBehind, r.Name, r.Path, map[string]interface{}{
+                "branch":      r.Branch,
+                "behind":      cur.Behind,
+                "new_commits": cur.Behind - prev.Behind,
+            }))
+        }
+    }
+    for path := range state {
+        if seen[path] { continue }
+        if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) { delete(state, path) }
+    }
+    return evs, d.save(state)
+}
+
+// age formats d coarsely for messages: 3d4h, 5h12m, 12m or 40s
+func age(d time.Duration) string {
+    switch {
+    case d >= 24*time.Hour:
+        return fmt.Sprintf("%dd%dh", d/(24*time.Hour), d%(24*time.Hour)/time.Hour)
+    case d >= time.Hour:
+        return fmt.Sprintf("%dh%dm", d/time.Hour, d%time.Hour/time.Minute)
+    case d >= time.Minute:
+        return fmt.Sprintf("%dm", d/time.Minute)
+    }
+    return fmt.Sprintf("%ds", d/time.Second)
+}
+
+func (d *Detector) load() (map[string]repoState, error) {
+    state := map[string]repoState{}
+    data, err := os.ReadFile(d.path)
+    if errors.Is(err, fs.ErrNotExist) { return state, nil }
+    if err != nil { return nil, err }
+    if err := json.Unmarshal(data, &state); err != nil {
+        // A damaged state file only costs one round of events
+        return map[string]repoState{}, nil
+    }
+    return state, nil
+}
+
+// save replaces the state file atomically so a concurrent reader never
+// sees a partial write
+func (d *Detector) save(state map[string]repoState) error {
+    data, err := json.MarshalIndent(state, "", "  ")
+    if err != nil { return err }
+    if err := os.MkdirAll(filepath.Dir(d.path), 0700); err != nil { return err }
+    tmp, err := os.CreateTemp(filepath.Dir(d.path), ".events-state-*")
+    if err != nil { return err }
+    if _, err := tmp.Write(data); err != nil { tmp.Close(); os.Remove(tmp.Name()); return err }
+    if err := tmp.Close(); err != nil { os.Remove(tmp.Name()); return err }
+    return os.Rename(tmp.Name(), d.path)
+}
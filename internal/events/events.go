@@ -0,0 +1,147 @@
+// Package events records what happens to the workspace as typed events:
+// repositories becoming dirty or falling behind, failed fetches and
+// policy checks, applied organize plans. A Bus appends each event to a
+// local log, which ds events and /v1/events tail, posts it to the
+// configured webhooks and runs the matching hook commands.
+package events
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "fmt"
+    "path/filepath"
+    "slices"
+    "time"
+
+    "github.com/adrg/xdg"
+    "github.com/verlyn13/ds-go/internal/policy"
+    "github.com/verlyn13/ds-go/internal/scan"
+)
+
+// Event types
+const (
+    RepoDirty       = "repo.dirty"       // A clean repository has uncommitted changes
+    RepoStale       = "repo.stale"       // A repository has stayed dirty for events.stale_after
+    RepoBehind      = "repo.behind"      // The upstream gained commits the branch lacks
+    FetchFailed     = "fetch.failed"     // git fetch failed for a repository
+    PolicyFailed    = "policy.failed"    // A policy check reached its fail-on threshold
+    OrganizeApplied = "organize.applied" // An organize plan moved repositories
+)
+
+// Types lists every event type
+var Types = []string{RepoDirty, RepoStale, RepoBehind, FetchFailed, PolicyFailed, OrganizeApplied}
+
+// Event is one occurrence. IDs sort in publishing order, so a reader can
+// resume after the last ID it saw.
+type Event struct {
+    ID   string                 `json:"id"`
+    Type string                 `json:"type" doc:"repo.dirty, repo.stale, repo.behind, fetch.failed, policy.failed or organize.applied"`
+    Time time.Time              `json:"time"`
+    Repo string                 `json:"repo,omitempty" doc:"Repository name, for events about one repository"`
+    Path string                 `json:"path,omitempty" doc:"Repository path, for events about one repository"`
+    Data map[string]interface{} `json:"data,omitempty" doc:"Type-specific details"`
+}
+
+// New returns an event of type typ stamped with the current time and a
+// fresh ID
+func New(typ, repo, path string, data map[string]interface{}) Event {
+    now := time.Now().UTC()
+    return Event{ID: newID(now), Type: typ, Time: now, Repo: repo, Path: path, Data: data}
+}
+
+// newID is the time in hex nanoseconds plus random bits against
+// collisions between processes
+func newID(t time.Time) string {
+    var b [4]byte
+    _, _ = rand.Read(b[:])
+    return fmt.Sprintf("%016x%s", t.UnixNano(), hex.EncodeToString(b[:]))
+}
+
+// ValidType reports whether typ is a known event type
+func ValidType(typ string) bool { return slices.Contains(Types, typ) }
+
+// Match reports whether e has one of types; no types matches every event
+func (e Event) Match(types []string) bool {
+    return len(types) == 0 || slices.Contains(types, e.Type)
+}
+
+// DefaultLog returns the default event log path using XDG
+func DefaultLog() string {
+    return filepath.Join(xdg.StateHome, "ds", "events.log")
+}
+
+// DefaultDeadLetter returns the default path of webhook deliveries that
+// were given up on
+func DefaultDeadLetter() string {
+    return filepath.Join(xdg.StateHome, "ds", "webhooks.dead.jsonl")
+}
+
+// DefaultState returns the default path of the state the Detector
+// compares scans against
+func DefaultState() string {
+    return filepath.Join(xdg.StateHome, "ds", "events-state.json")
+}
+
+// FetchFailures returns a fetch.failed event for each failed result.
+// Results are matched to repos by name.
+func FetchFailures(repos []scan.Repository, results []scan.FetchResult) []Event {
+    paths := map[string]string{}
+    for _, r := range repos {
+        if _, ok := paths[r.Name]; !ok { paths[r.Name] = r.Path }
+    }
+    var evs []Event
+    for _, res := range results {
+        if res.Success || res.RepoName == "" { continue }
+        data := map[string]interface{}{}
+        if res.Error != nil { data["error"] = res.Error.Error() }
+        evs = append(evs, New(FetchFailed, res.RepoName, paths[res.RepoName], data))
+    }
+    return evs
+}
+
+// PolicyFailures returns a policy.failed event for each target with
+// checks failing at or above the report's threshold
+func PolicyFailures(report *policy.Report, targets []policy.Target) []Event {
+    if !report.FailedThreshold { return nil }
+    failed := map[string][]string{}
+    var order []string
+    for _, res := range report.Results {
+        if res.Status != policy.StatusFailed { continue }
+        if _, ok := failed[res.Repo]; !ok { order = append(order, res.Repo) }
+        failed[res.Repo] = append(failed[res.Repo], res.Name)
+    }
+    var evs []Event
+    for _, repo := range order {
+        path := ""
+        for _, t := range targets {
+            if t.Name == repo { path = t.Dir; break }
+        }
+        evs = append(evs, New(PolicyFailed, repo, path, map[string]interface{}{
+            "file":    report.File,
+            "fail_on": string(report.FailOn),
+            "checks":  failed[repo],
+        }))
+    }
+    return evs
+}
+
+// Organized returns the organize.applied event for results, or none when
+// nothing moved
+func Organized(results []scan.OrganizeResult) []Event {
+    var moves []map[string]string
+    failed := 0
+    for _, r := range results {
+        switch {
+        case r.Applied && !r.DryRun:
+            moves = append(moves, map[string]string{"name": r.Name, "old_path": r.OldPath, "new_path": r.NewPath})
+        case r.Error != "":
+            failed++
+        }
+    }
+    if len(moves) == 0 { return nil }
+    return []Event{New(OrganizeApplied, "", "", map[string]interface{}{
+        "moved":  len(moves),
+        "failed": failed,
+        "moves":  moves,
+    })}
+}
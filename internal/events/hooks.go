@@ -0,0 +1,94 @@
+package events
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "os"
+    "path"
+    "slices"
+    "strings"
+    "time"
+
+    "github.com/verlyn13/ds-go/internal/config"
+    "github.com/verlyn13/ds-go/internal/runner"
+)
+
+// maxHooks bounds the hook commands running at once
+const maxHooks = 4
+
+// hookMatches reports whether h wants e
+func hookMatches(h config.EventHook, e Event) bool {
+    if !slices.Contains(h.On, e.Type) { return false }
+    if h.Branch != "" && e.Data["branch"] != h.Branch { return false }
+    if len(h.Repos) > 0 && !slices.ContainsFunc(h.Repos, func(pat string) bool {
+        ok, _ := path.Match(pat, e.Repo)
+        return ok
+    }) {
+        return false
+    }
+    return true
+}
+
+// runHook runs h for e through runner, with the event as JSON on stdin and
+// in the environment. Repository events run in the repository.
+func (b *Bus) runHook(h config.EventHook, e Event) {
+    b.hookSlots <- struct{}{}
+    defer func() { <-b.hookSlots }()
+    payload, err := json.Marshal(e)
+    if err != nil { log.Printf("events: %v", err); return }
+    dir := ""
+    if fi, err := os.Stat(e.Path); err == nil && fi.IsDir() { dir = e.Path }
+    var out bytes.Buffer
+    res := runner.Run(context.Background(), e.Repo, runner.Command{
+        Run:     h.Run,
+        Dir:     dir,
+        Env:     hookEnv(e),
+        Stdin:   bytes.NewReader(payload),
+        Stdout:  &out,
+        Stderr:  &out,
+        Timeout: h.TimeoutDuration(),
+    })
+    if res.Success { return }
+    name := h.Name
+    if name == "" { name = h.Run }
+    msg := strings.TrimSpace(out.String())
+    if len(msg) > 500 { msg = "…" + msg[len(msg)-500:] }
+    log.Printf("events: hook %q for %s %s failed after %dms: %s %s", name, e.Type, e.ID, res.DurationMs, res.Error, msg)
+}
+
+// hookEnv describes e as DS_EVENT, DS_EVENT_ID, DS_EVENT_TIME, DS_REPO,
+// DS_REPO_PATH and a DS_<KEY> variable per data key. Lists of strings are
+// joined with commas; other structured values are JSON.
+func hookEnv(e Event) []string {
+    env := []string{
+        "DS_EVENT=" + e.Type,
+        "DS_EVENT_ID=" + e.ID,
+        "DS_EVENT_TIME=" + e.Time.Format(time.RFC3339),
+        "DS_REPO=" + e.Repo,
+        "DS_REPO_PATH=" + e.Path,
+    }
+    keys := make([]string, 0, len(e.Data))
+    for k := range e.Data {
+        keys = append(keys, k)
+    }
+    slices.Sort(keys)
+    for _, k := range keys {
+        var v string
+        switch x := e.Data[k].(type) {
+        case string:
+            v = x
+        case []string:
+            v = strings.Join(x, ",")
+        case int, int64, float64, bool:
+            v = fmt.Sprint(x)
+        default:
+            b, _ := json.Marshal(x)
+            v = string(b)
+        }
+        env = append(env, "DS_"+strings.ToUpper(k)+"="+v)
+    }
+    return env
+}
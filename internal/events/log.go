@@ -0,0 +1,110 @@
+package events
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "io"
+    "io/fs"
+    "iter"
+    "os"
+    "path/filepath"
+    "sync"
+    "time"
+)
+
+// PollInterval is how often Follow checks the log for new events
+const PollInterval = 500 * time.Millisecond
+
+// Log is an append-only file of events, one JSON object per line. Several
+// processes may append to the same log.
+type Log struct {
+    mu   sync.Mutex
+    path string
+}
+
+// OpenLog returns the log at path; the file is created on first append
+func OpenLog(path string) *Log { return &Log{path: path} }
+
+// Path returns the log's file path
+func (l *Log) Path() string { return l.path }
+
+// Append writes e as one line. A single write keeps lines from different
+// processes whole.
+func (l *Log) Append(e Event) error {
+    line, err := json.Marshal(e)
+    if err != nil { return err }
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil { return err }
+    f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+    if err != nil { return err }
+    defer f.Close()
+    _, err = f.Write(append(line, '\n'))
+    return err
+}
+
+// Read returns the events logged after the one with ID after ("" for
+// all), oldest first. A missing log has no events.
+func (l *Log) Read(after string) ([]Event, error) {
+    var list []Event
+    _, err := l.readFrom(0, after, func(e Event) bool { list = append(list, e); return true })
+    return list, err
+}
+
+// Follow iterates over the events logged after the one with ID after,
+// then waits for new ones until ctx ends; with after empty it starts at
+// the end of the log. A log that shrinks was rotated and is read again
+// from the start, skipping events already yielded.
+func (l *Log) Follow(ctx context.Context, after string) iter.Seq2[Event, error] {
+    return func(yield func(Event, error) bool) {
+        var offset int64
+        if fi, err := os.Stat(l.path); err == nil && after == "" { offset = fi.Size() }
+        last := after
+        stopped := false
+        emit := func(e Event) bool {
+            if e.ID > last { last = e.ID }
+            if !yield(e, nil) { stopped = true }
+            return !stopped
+        }
+        tick := time.NewTicker(PollInterval)
+        defer tick.Stop()
+        for {
+            if fi, err := os.Stat(l.path); err == nil && fi.Size() < offset { offset, after = 0, last }
+            next, err := l.readFrom(offset, after, emit)
+            if stopped { return }
+            if err != nil { yield(Event{}, err); return }
+            // Past the first read, order in the file decides what is new:
+            // another process may append an event with a slightly older ID
+            offset, after = next, ""
+            select {
+            case <-ctx.Done():
+                return
+            case <-tick.C:
+            }
+        }
+    }
+}
+
+// readFrom calls fn for each complete line from offset on whose event ID
+// is greater than after, and returns the offset past the last complete
+// line. Lines that do not parse are skipped.
+func (l *Log) readFrom(offset int64, after string, fn func(Event) bool) (int64, error) {
+    f, err := os.Open(l.path)
+    if errors.Is(err, fs.ErrNotExist) { return 0, nil }
+    if err != nil { return offset, err }
+    defer f.Close()
+    if _, err := f.Seek(offset, io.SeekStart); err != nil { return offset, err }
+    rd := bufio.NewReader(f)
+    for {
+        line, err := rd.ReadBytes('\n')
+        if err == io.EOF { return offset, nil } // A partial line waits for its newline
+        if err != nil { return offset, err }
+        offset += int64(len(line))
+        var e Event
+        if json.Unmarshal(bytes.TrimSpace(line), &e) != nil || e.ID <= after { continue }
+        if !fn(e) { return offset, nil }
+    }
+}
@@ -0,0 +1,126 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CurrentBranch returns the checked out branch, or "" on a detached HEAD
+func (g *Git) CurrentBranch(repoPath string) (string, error) {
+	out, err := g.runCommand(repoPath, "symbolic-ref", "-q", "--short", "HEAD")
+	if err != nil {
+		if _, herr := g.runCommand(repoPath, "rev-parse", "--verify", "-q", "HEAD"); herr == nil {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// OriginDefaultBranch returns the branch origin/HEAD points at, falling
+// back to main or master when origin has one. Unlike DefaultBranch it
+// never takes the checked out branch for the default.
+func (g *Git) OriginDefaultBranch(repoPath string) (string, error) {
+	ref, err := g.runCommand(repoPath, "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+	if err == nil {
+		return strings.TrimPrefix(strings.TrimSpace(ref), "origin/"), nil
+	}
+	for _, name := range []string{"main", "master"} {
+		if g.HasRef(repoPath, "refs/remotes/origin/"+name) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no default branch: origin/HEAD is not set and origin has no main or master")
+}
+
+// HasRef reports whether ref resolves in a repository
+func (g *Git) HasRef(repoPath, ref string) bool {
+	_, err := g.runCommand(repoPath, "rev-parse", "--verify", "-q", ref+"^{commit}")
+	return err == nil
+}
+
+// CreateBranch creates branch name at start, or at HEAD when start is ""
+func (g *Git) CreateBranch(repoPath, name, start string) error {
+	args := []string{"branch", "--", name}
+	if start != "" {
+		args = append(args, start)
+	}
+	_, err := g.runCommand(repoPath, args...)
+	return err
+}
+
+// SwitchBranch checks out branch name. A branch that exists only on origin
+// is created tracking it. git refuses when local changes would be lost.
+func (g *Git) SwitchBranch(repoPath, name string) error {
+	_, err := g.runCommand(repoPath, "switch", "--quiet", name)
+	return err
+}
+
+// DeleteBranch deletes branch name; force deletes it even if unmerged
+func (g *Git) DeleteBranch(repoPath, name string, force bool) error {
+	flag := "-d"
+	if force {
+		flag = "-D"
+	}
+	_, err := g.runCommand(repoPath, "branch", flag, "--", name)
+	return err
+}
+
+// FetchRemote fetches one remote
+func (g *Git) FetchRemote(repoPath, remote string) error {
+	_, err := g.runCommand(repoPath, "fetch", "--quiet", remote)
+	return err
+}
+
+// FastForward moves branch to target if that is a fast-forward and
+// returns how many commits it gained. The checked out branch is merged
+// with --ff-only so the working tree follows; any other branch only has
+// its ref moved.
+func (g *Git) FastForward(repoPath, branch, target string, checkedOut bool) (int, error) {
+	local := "refs/heads/" + branch
+	out, err := g.runCommand(repoPath, "rev-list", "--count", local+".."+target)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(out))
+	if n == 0 {
+		return 0, nil
+	}
+	if _, err := g.runCommand(repoPath, "merge-base", "--is-ancestor", local, target); err != nil {
+		return 0, fmt.Errorf("%s has diverged from %s", branch, target)
+	}
+	if checkedOut {
+		_, err = g.runCommand(repoPath, "merge", "--ff-only", "--quiet", target)
+	} else {
+		_, err = g.runCommand(repoPath, "update-ref", "-m", "ds sync: fast-forward", local, target)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Rebase rebases the checked out branch onto upstream, aborting and
+// leaving the branch as it was if the rebase stops on a conflict. It
+// returns how many commits were replayed.
+func (g *Git) Rebase(repoPath, upstream string) (int, error) {
+	out, err := g.runCommand(repoPath, "rev-list", "--count", "HEAD.."+upstream)
+	if err != nil {
+		return 0, err
+	}
+	if strings.TrimSpace(out) == "0" {
+		return 0, nil
+	}
+	out, err = g.runCommand(repoPath, "rev-list", "--count", upstream+"..HEAD")
+	if err != nil {
+		return 0, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(out))
+	if _, err := g.runCommandEnv(repoPath, g.identEnv(repoPath), "rebase", "--quiet", upstream); err != nil {
+		g.runCommand(repoPath, "rebase", "--abort")
+		msg, _, _ := strings.Cut(err.Error(), "\n")
+		return 0, fmt.Errorf("rebase onto %s stopped and was aborted: %s", upstream, msg)
+	}
+	return n, nil
+}
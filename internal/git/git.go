@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
@@ -25,9 +26,19 @@ type Repository struct {
 	Ahead        int
 	Behind       int
 	LastCommit   string
+	LastCommitAt *time.Time // Committer date of HEAD, for sorting
 	LastFetch    *time.Time
 	HasStash     bool
 	HasUpstream  bool
+	Tags         []string // User-defined tags from config
+}
+
+// RepoDetails holds extended repository information for detail views
+type RepoDetails struct {
+	Branches      []string
+	Stashes       []string
+	RecentCommits []string
+	ChangedFiles  []string
 }
 
 // Git wraps git command execution
@@ -101,11 +112,13 @@ func (g *Git) GetStatus(repoPath string) (*Repository, error) {
 	}
 
 	// Get last commit info
-	lastCommit, err := g.runCommand(repoPath, "log", "-1", "--pretty=%cr: %s")
+	lastCommit, err := g.runCommand(repoPath, "log", "-1", "--pretty=%ct %cr: %s")
 	if err == nil {
-		repo.LastCommit = strings.TrimSpace(lastCommit)
-		if len(repo.LastCommit) > 60 {
-			repo.LastCommit = repo.LastCommit[:57] + "..."
+		stamp, summary, _ := strings.Cut(strings.TrimSpace(lastCommit), " ")
+		repo.LastCommit = summary
+		if sec, err := strconv.ParseInt(stamp, 10, 64); err == nil {
+			at := time.Unix(sec, 0)
+			repo.LastCommitAt = &at
 		}
 	} else {
 		repo.LastCommit = "No commits"
@@ -136,12 +149,119 @@ func (g *Git) Push(repoPath string) error {
 	return err
 }
 
+// GetDetails returns local branches, stashes, recent commits and changed files
+func (g *Git) GetDetails(repoPath string, commitLimit int) (*RepoDetails, error) {
+	if commitLimit <= 0 {
+		commitLimit = 10
+	}
+	details := &RepoDetails{}
+
+	branches, err := g.runCommand(repoPath, "branch", "--format=%(refname:short)")
+	if err != nil {
+		return nil, fmt.Errorf("listing branches: %w", err)
+	}
+	details.Branches = splitLines(branches)
+
+	if stashes, err := g.runCommand(repoPath, "stash", "list", "--format=%gd: %s"); err == nil {
+		details.Stashes = splitLines(stashes)
+	}
+
+	if commits, err := g.runCommand(repoPath, "log", "-n", strconv.Itoa(commitLimit), "--pretty=%h %cr: %s"); err == nil {
+		details.RecentCommits = splitLines(commits)
+	}
+
+	if status, err := g.runCommand(repoPath, "status", "--porcelain"); err == nil {
+		details.ChangedFiles = splitLines(status)
+	}
+
+	return details, nil
+}
+
+// Remotes returns the fetch URL of every configured remote keyed by name
+func (g *Git) Remotes(repoPath string) (map[string]string, error) {
+	out, err := g.runCommand(repoPath, "remote", "-v")
+	if err != nil {
+		return nil, err
+	}
+
+	remotes := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		// Lines look like: origin	git@github.com:owner/repo.git (fetch)
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[2] == "(fetch)" {
+			remotes[fields[0]] = fields[1]
+		}
+	}
+	return remotes, nil
+}
+
+// AddRemote adds a named remote to a repository
+func (g *Git) AddRemote(repoPath, name, url string) error {
+	_, err := g.runCommand(repoPath, "remote", "add", name, url)
+	return err
+}
+
+// DefaultBranch returns the branch origin/HEAD points at, falling back to
+// the currently checked out branch when origin/HEAD is not set
+func (g *Git) DefaultBranch(repoPath string) string {
+	ref, err := g.runCommand(repoPath, "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+	if err == nil {
+		return strings.TrimPrefix(strings.TrimSpace(ref), "origin/")
+	}
+
+	branch, err := g.runCommand(repoPath, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(branch)
+}
+
+// ConfigGet returns the effective value of a git config key in a repository
+func (g *Git) ConfigGet(repoPath, key string) (string, error) {
+	out, err := g.runCommand(repoPath, "config", "--get", key)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// TopLevel returns the root of the working tree containing path
+func (g *Git) TopLevel(path string) (string, error) {
+	out, err := g.runCommand(path, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// HooksDir returns the directory git runs hooks from, honouring core.hooksPath
+func (g *Git) HooksDir(repoPath string) (string, error) {
+	out, err := g.runCommand(repoPath, "rev-parse", "--path-format=absolute", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	dir := strings.TrimSpace(out)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoPath, dir)
+	}
+	return dir, nil
+}
+
 // runCommand executes a git command with timeout
 func (g *Git) runCommand(repoPath string, args ...string) (string, error) {
+	return g.runCommandEnv(repoPath, nil, args...)
+}
+
+// runCommandEnv executes a git command with timeout and extra KEY=value
+// environment variables
+func (g *Git) runCommandEnv(repoPath string, env []string, args ...string) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repoPath}, args...)...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -157,6 +277,17 @@ func (g *Git) runCommand(repoPath string, args ...string) (string, error) {
 	return stdout.String(), nil
 }
 
+// splitLines splits command output into non-empty lines
+func splitLines(out string) []string {
+	var lines []string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimRight(line, "\r"))
+		}
+	}
+	return lines
+}
+
 // isGitRepo checks if a directory is a git repository
 func (g *Git) isGitRepo(path string) bool {
 	_, err := g.runCommand(path, "rev-parse", "--git-dir")
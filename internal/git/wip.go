@@ -0,0 +1,191 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WIPRefPrefix is the namespace WIP snapshots stored as refs live under
+const WIPRefPrefix = "refs/ds/wip/"
+
+// wipMessagePrefix starts the message of every WIP snapshot, which is how
+// ds tells its stash entries from the user's own
+const wipMessagePrefix = "ds wip "
+
+// WIP is one snapshot of uncommitted work
+type WIP struct {
+	ID      string    `json:"id"`
+	Ref     string    `json:"ref"` // refs/ds/wip/<id> or stash@{n}
+	Commit  string    `json:"commit"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+	Stash   bool      `json:"stash"`
+}
+
+// WIPMessage is the message of the snapshot id taken on branch
+func WIPMessage(id, branch string) string {
+	return fmt.Sprintf("%s%s on %s", wipMessagePrefix, id, branch)
+}
+
+// CreateWIP records the working tree, index and untracked files as a
+// commit shaped like a stash entry, so git stash apply can restore it, and
+// returns its hash. The working tree, index and stash are left alone. A
+// repository without uncommitted work returns "".
+func (g *Git) CreateWIP(repoPath, message string) (string, error) {
+	if _, err := g.runCommand(repoPath, "rev-parse", "--verify", "-q", "HEAD"); err != nil {
+		return "", fmt.Errorf("no commits yet")
+	}
+	env := g.identEnv(repoPath)
+
+	out, err := g.runCommandEnv(repoPath, env, "stash", "create", message)
+	if err != nil {
+		return "", fmt.Errorf("stash create: %w", err)
+	}
+	stash := strings.TrimSpace(out)
+
+	out, err = g.runCommand(repoPath, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return "", fmt.Errorf("listing untracked files: %w", err)
+	}
+	untracked := strings.Trim(out, "\x00")
+	if untracked == "" {
+		return stash, nil
+	}
+
+	// Untracked files become a parentless third parent, as git stash -u
+	// would record them
+	tmp, err := os.MkdirTemp("", "ds-wip-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	pathspec := filepath.Join(tmp, "pathspec")
+	if err := os.WriteFile(pathspec, []byte(untracked+"\x00"), 0600); err != nil {
+		return "", err
+	}
+	indexEnv := append([]string{"GIT_INDEX_FILE=" + filepath.Join(tmp, "index")}, env...)
+	if _, err := g.runCommandEnv(repoPath, indexEnv, "add", "--pathspec-from-file="+pathspec, "--pathspec-file-nul"); err != nil {
+		return "", fmt.Errorf("adding untracked files: %w", err)
+	}
+	tree, err := g.runCommandEnv(repoPath, indexEnv, "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("writing untracked tree: %w", err)
+	}
+	untrackedCommit, err := g.runCommandEnv(repoPath, env, "commit-tree", strings.TrimSpace(tree), "-m", "untracked files: "+message)
+	if err != nil {
+		return "", fmt.Errorf("committing untracked files: %w", err)
+	}
+
+	// Without tracked changes stash create records nothing, so the index
+	// and working tree commits are HEAD's tree
+	worktree, index := "HEAD^{tree}", ""
+	if stash != "" {
+		worktree, index = stash+"^{tree}", stash+"^2"
+	} else {
+		out, err := g.runCommandEnv(repoPath, env, "commit-tree", "HEAD^{tree}", "-p", "HEAD", "-m", "index: "+message)
+		if err != nil {
+			return "", fmt.Errorf("committing index: %w", err)
+		}
+		index = strings.TrimSpace(out)
+	}
+	out, err = g.runCommandEnv(repoPath, env, "commit-tree", worktree, "-p", "HEAD", "-p", index, "-p", strings.TrimSpace(untrackedCommit), "-m", message)
+	if err != nil {
+		return "", fmt.Errorf("committing snapshot: %w", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// StoreWIP keeps commit as snapshot id, under refs/ds/wip/ or as a stash
+// entry, and returns the ref it is reachable from. An id already taken in
+// the repository gets a -2, -3, ... suffix.
+func (g *Git) StoreWIP(repoPath, id, commit, message string, stash bool) (WIP, error) {
+	w := WIP{ID: id, Commit: commit, Message: message, Time: time.Now(), Stash: stash}
+	if stash {
+		if _, err := g.runCommand(repoPath, "stash", "store", "-m", message, commit); err != nil {
+			return w, fmt.Errorf("stash store: %w", err)
+		}
+		w.Ref = "stash@{0}"
+		return w, nil
+	}
+	for n := 2; ; n++ {
+		w.Ref = WIPRefPrefix + w.ID
+		if _, err := g.runCommand(repoPath, "rev-parse", "--verify", "-q", w.Ref); err != nil {
+			break
+		}
+		w.ID = id + "-" + strconv.Itoa(n)
+	}
+	if _, err := g.runCommand(repoPath, "update-ref", "-m", message, w.Ref, commit, ""); err != nil {
+		return w, fmt.Errorf("update-ref: %w", err)
+	}
+	return w, nil
+}
+
+// ListWIP returns a repository's snapshots, refs first, then stash entries
+// made by ds, each newest first
+func (g *Git) ListWIP(repoPath string) ([]WIP, error) {
+	var list []WIP
+	out, err := g.runCommand(repoPath, "for-each-ref", "--sort=-creatordate", "--format=%(refname)%00%(objectname)%00%(creatordate:iso-strict)%00%(subject)", WIPRefPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("listing snapshots: %w", err)
+	}
+	for _, line := range splitLines(out) {
+		f := strings.Split(line, "\x00")
+		if len(f) != 4 {
+			continue
+		}
+		t, _ := time.Parse(time.RFC3339, f[2])
+		list = append(list, WIP{ID: strings.TrimPrefix(f[0], WIPRefPrefix), Ref: f[0], Commit: f[1], Time: t, Message: f[3]})
+	}
+
+	out, err = g.runCommand(repoPath, "stash", "list", "--format=%gd%x00%H%x00%cI%x00%gs")
+	if err != nil {
+		return nil, fmt.Errorf("listing stashes: %w", err)
+	}
+	for _, line := range splitLines(out) {
+		f := strings.Split(line, "\x00")
+		if len(f) != 4 || !strings.HasPrefix(f[3], wipMessagePrefix) {
+			continue
+		}
+		id, _, _ := strings.Cut(strings.TrimPrefix(f[3], wipMessagePrefix), " ")
+		t, _ := time.Parse(time.RFC3339, f[2])
+		list = append(list, WIP{ID: id, Ref: f[0], Commit: f[1], Time: t, Message: f[3], Stash: true})
+	}
+	return list, nil
+}
+
+// ApplyWIP restores a snapshot onto the working tree, and the index too
+// when index is set. git refuses rather than overwrite untracked files.
+func (g *Git) ApplyWIP(repoPath, commit string, index bool) error {
+	args := []string{"stash", "apply"}
+	if index {
+		args = append(args, "--index")
+	}
+	_, err := g.runCommandEnv(repoPath, g.identEnv(repoPath), append(args, commit)...)
+	return err
+}
+
+// DropWIP deletes a snapshot's ref or stash entry
+func (g *Git) DropWIP(repoPath string, w WIP) error {
+	if w.Stash {
+		_, err := g.runCommand(repoPath, "stash", "drop", "-q", w.Ref)
+		return err
+	}
+	_, err := g.runCommand(repoPath, "update-ref", "-d", w.Ref, w.Commit)
+	return err
+}
+
+// identEnv returns a fallback identity for the commits git stash and
+// commit-tree write when the repository has none configured
+func (g *Git) identEnv(repoPath string) []string {
+	if _, err := g.runCommand(repoPath, "var", "GIT_COMMITTER_IDENT"); err == nil {
+		return nil
+	}
+	return []string{
+		"GIT_AUTHOR_NAME=ds", "GIT_AUTHOR_EMAIL=ds@localhost",
+		"GIT_COMMITTER_NAME=ds", "GIT_COMMITTER_EMAIL=ds@localhost",
+	}
+}
@@ -0,0 +1,233 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/verlyn13/ds-go/internal/git"
+)
+
+// marker identifies hook scripts written by ds
+const marker = "# managed by ds"
+
+// prevSuffix is appended to a pre-existing hook that a ds hook chains to
+const prevSuffix = ".ds-prev"
+
+// stdinHooks receive data on stdin that both the chained and ds hook need
+var stdinHooks = map[string]bool{
+	"pre-push":              true,
+	"pre-receive":           true,
+	"post-receive":          true,
+	"post-rewrite":          true,
+	"reference-transaction": true,
+}
+
+// Hook states reported by Audit
+const (
+	StateInstalled = "installed" // ds hook matches the policy
+	StateOutdated  = "outdated"  // ds hook runs different commands than the policy
+	StateMissing   = "missing"   // No hook installed
+	StateForeign   = "foreign"   // A hook not written by ds is in place
+	StateStale     = "stale"     // ds hook for a hook the policy no longer declares
+	StateNoPolicy  = "no-policy" // Repository has no policy declaring git hooks
+)
+
+// Result describes one hook change
+type Result struct {
+	Repo    string `json:"repo,omitempty"`
+	Hook    string `json:"hook"`
+	Path    string `json:"path"`
+	Action  string `json:"action"` // installed, updated, unchanged, removed, restored
+	Chained bool   `json:"chained"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Status describes one hook's installed state
+type Status struct {
+	Repo    string `json:"repo,omitempty"`
+	Hook    string `json:"hook"`
+	Path    string `json:"path"`
+	State   string `json:"state"`
+	Chained bool   `json:"chained"`
+}
+
+// Resolve returns the working tree root and hooks directory for any path
+// inside a repository, honouring core.hooksPath
+func Resolve(path string) (root, hooksDir string, err error) {
+	g := git.New()
+	root, err = g.TopLevel(path)
+	if err != nil {
+		return "", "", fmt.Errorf("not a git repository: %s", path)
+	}
+	hooksDir, err = g.HooksDir(root)
+	if err != nil {
+		return "", "", fmt.Errorf("locating hooks directory: %w", err)
+	}
+	return root, hooksDir, nil
+}
+
+// Script renders the hook script running commands in order. An existing
+// hook moved aside during install runs first.
+func Script(hook string, commands []string, source string) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "%s: generated from workflow.git_hooks in %s\n", marker, source)
+	b.WriteString("# Reinstall with 'ds hooks install'; remove with 'ds hooks uninstall'.\n")
+	b.WriteString("set -e\n")
+	if stdinHooks[hook] {
+		b.WriteString("stdin=$(cat)\n")
+		fmt.Fprintf(&b, "if [ -x \"$0%s\" ]; then printf '%%s\\n' \"$stdin\" | \"$0%s\" \"$@\"; fi\n", prevSuffix, prevSuffix)
+	} else {
+		fmt.Fprintf(&b, "if [ -x \"$0%s\" ]; then \"$0%s\" \"$@\"; fi\n", prevSuffix, prevSuffix)
+	}
+	for _, c := range commands {
+		b.WriteString(c + "\n")
+	}
+	return b.String()
+}
+
+// Install writes a ds hook for each declared hook. A hook not written by
+// ds is kept as <hook>.ds-prev and runs before the ds hook.
+func Install(path string, declared map[string][]string, source string) ([]Result, error) {
+	_, dir, err := Resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, name := range sortedNames(declared) {
+		hookPath := filepath.Join(dir, name)
+		res := Result{Hook: name, Path: hookPath, Action: "installed"}
+		script := Script(name, declared[name], source)
+
+		current, err := os.ReadFile(hookPath)
+		switch {
+		case err == nil && string(current) == script:
+			res.Action = "unchanged"
+		case err == nil && isManaged(current):
+			res.Action = "updated"
+		case err == nil:
+			// Keep the existing hook and chain to it, unless one is already kept
+			if exists(hookPath + prevSuffix) {
+				res.Error = fmt.Sprintf("%s exists and %s%s is taken; move one aside first", name, name, prevSuffix)
+				results = append(results, res)
+				continue
+			}
+			if err := os.Rename(hookPath, hookPath+prevSuffix); err != nil {
+				res.Error = err.Error()
+				results = append(results, res)
+				continue
+			}
+		}
+		res.Chained = exists(hookPath + prevSuffix)
+
+		if res.Action != "unchanged" {
+			if err := os.WriteFile(hookPath, []byte(script), 0755); err != nil {
+				res.Error = err.Error()
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// Uninstall removes every ds hook and restores hooks they chained to
+func Uninstall(path string) ([]Result, error) {
+	_, dir, err := Resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var results []Result
+	for _, e := range entries {
+		hookPath := filepath.Join(dir, e.Name())
+		if e.IsDir() || strings.HasSuffix(e.Name(), prevSuffix) || !managedFile(hookPath) {
+			continue
+		}
+		res := Result{Hook: e.Name(), Path: hookPath, Action: "removed"}
+		if err := os.Remove(hookPath); err != nil {
+			res.Error = err.Error()
+		} else if exists(hookPath + prevSuffix) {
+			res.Chained = true
+			res.Action = "restored"
+			if err := os.Rename(hookPath+prevSuffix, hookPath); err != nil {
+				res.Error = err.Error()
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// Audit compares installed hooks with the declared ones
+func Audit(path string, declared map[string][]string, source string) ([]Status, error) {
+	_, dir, err := Resolve(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, name := range sortedNames(declared) {
+		hookPath := filepath.Join(dir, name)
+		st := Status{Hook: name, Path: hookPath, Chained: exists(hookPath + prevSuffix)}
+		current, err := os.ReadFile(hookPath)
+		switch {
+		case err != nil:
+			st.State = StateMissing
+		case string(current) == Script(name, declared[name], source):
+			st.State = StateInstalled
+		case isManaged(current):
+			st.State = StateOutdated
+		default:
+			st.State = StateForeign
+		}
+		statuses = append(statuses, st)
+	}
+
+	// ds hooks the policy no longer declares
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		hookPath := filepath.Join(dir, e.Name())
+		if _, ok := declared[e.Name()]; ok || e.IsDir() || strings.HasSuffix(e.Name(), prevSuffix) || !managedFile(hookPath) {
+			continue
+		}
+		statuses = append(statuses, Status{Hook: e.Name(), Path: hookPath, State: StateStale, Chained: exists(hookPath + prevSuffix)})
+	}
+	return statuses, nil
+}
+
+func isManaged(script []byte) bool {
+	return strings.Contains(string(script), marker)
+}
+
+func managedFile(path string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && isManaged(data)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func sortedNames(declared map[string][]string) []string {
+	names := make([]string, 0, len(declared))
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,365 @@
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/verlyn13/ds-go/internal/config"
+	"github.com/verlyn13/ds-go/internal/git"
+	"github.com/verlyn13/ds-go/internal/scan"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the manifest format version written by Build
+const Version = 1
+
+// Manifest describes a workspace layout that can be reproduced on another machine
+type Manifest struct {
+	Version     int       `yaml:"version" json:"version"`
+	GeneratedAt time.Time `yaml:"generated_at" json:"generated_at"`
+	BaseDir     string    `yaml:"base_dir" json:"base_dir"`
+	Repos       []Entry   `yaml:"repos" json:"repos"`
+}
+
+// Entry describes a single repository in the manifest
+type Entry struct {
+	Name          string            `yaml:"name" json:"name"`
+	Path          string            `yaml:"path" json:"path"` // Relative to base_dir unless absolute
+	Account       string            `yaml:"account" json:"account"`
+	Folder        string            `yaml:"folder" json:"folder"`
+	DefaultBranch string            `yaml:"default_branch,omitempty" json:"default_branch,omitempty"`
+	Remotes       map[string]string `yaml:"remotes" json:"remotes"`
+	Tags          []string          `yaml:"tags,omitempty" json:"tags,omitempty"`
+}
+
+// ApplyResult describes the outcome for one manifest entry
+type ApplyResult struct {
+	Name       string `json:"name"`
+	Target     string `json:"target"`
+	URL        string `json:"url,omitempty"`
+	Action     string `json:"action"` // "clone", "present" or "error"
+	Applied    bool   `json:"applied"`
+	DryRun     bool   `json:"dry_run"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// ExtraRepo is a local repository that the manifest does not mention
+type ExtraRepo struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	RemoteURL string `json:"remote_url"`
+}
+
+// ApplyReport summarizes a manifest apply
+type ApplyReport struct {
+	Results []ApplyResult `json:"results"`
+	Cloned  int           `json:"cloned"`
+	Present int           `json:"present"`
+	Failed  int           `json:"failed"`
+	Extra   []ExtraRepo   `json:"extra"`
+}
+
+// Build creates a manifest from repositories scanned under root, which
+// becomes its base_dir; entry paths are relative to it. An empty root means
+// the configured base directory, as for scan.Scanner.Scan.
+func Build(repos []scan.Repository, cfg *config.Config, root string, workerCount int) *Manifest {
+	if workerCount <= 0 {
+		workerCount = 10
+	}
+	if root == "" {
+		root = cfg.BaseDir
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+
+	gitClient := git.New()
+	entries := make([]Entry, len(repos))
+
+	g, ctx := errgroup.WithContext(context.Background())
+	sem := semaphore.NewWeighted(int64(workerCount))
+	for i, repo := range repos {
+		g.Go(func() error {
+			if err := sem.Acquire(ctx, 1); err != nil {
+				return err
+			}
+			defer sem.Release(1)
+
+			remotes, err := gitClient.Remotes(repo.Path)
+			if err != nil {
+				remotes = map[string]string{}
+			}
+			// Only origin's default is recorded; the checked out branch is
+			// not a default, so an undeterminable one is left empty
+			defaultBranch, _ := gitClient.OriginDefaultBranch(repo.Path)
+			entries[i] = Entry{
+				Name:          repo.Name,
+				Path:          relativePath(root, repo.Path),
+				Account:       repo.Account,
+				Folder:        repo.FolderName,
+				DefaultBranch: defaultBranch,
+				Remotes:       remotes,
+				Tags:          repo.Tags,
+			}
+			return nil
+		})
+	}
+	g.Wait()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	return &Manifest{
+		Version:     Version,
+		GeneratedAt: time.Now().UTC(),
+		BaseDir:     root,
+		Repos:       entries,
+	}
+}
+
+// Load reads a manifest from a YAML or JSON file
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes a manifest from YAML or JSON bytes
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+	// YAML is a superset of JSON, so one decoder handles both
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest format: %w", err)
+	}
+	if m.Version > Version {
+		return nil, fmt.Errorf("unsupported manifest version %d (max %d)", m.Version, Version)
+	}
+	return &m, nil
+}
+
+// Write encodes the manifest as "yaml" or "json"
+func Write(w io.Writer, m *Manifest, format string) error {
+	switch format {
+	case "", "yaml", "yml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer enc.Close()
+		return enc.Encode(m)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(m)
+	default:
+		return fmt.Errorf("unknown manifest format: %s (use yaml or json)", format)
+	}
+}
+
+// FormatFromPath guesses the manifest format from a file extension
+func FormatFromPath(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return "json"
+	}
+	return "yaml"
+}
+
+// Apply clones every manifest entry missing from the workspace and reports
+// local repositories that are not part of the manifest
+func Apply(ctx context.Context, m *Manifest, repos []scan.Repository, cfg *config.Config, workerCount int, dryRun bool) *ApplyReport {
+	if workerCount <= 0 {
+		workerCount = 10
+	}
+
+	// Index local repositories by path and by owner/repo slug
+	byPath := make(map[string]bool, len(repos))
+	bySlug := make(map[string]bool, len(repos))
+	for _, r := range repos {
+		byPath[filepath.Clean(r.Path)] = true
+		if slug := repoSlug(r.RemoteURL); slug != "" {
+			bySlug[slug] = true
+		}
+	}
+
+	report := &ApplyReport{Results: make([]ApplyResult, len(m.Repos))}
+	wanted := make(map[string]bool, len(m.Repos))
+	gitClient := git.New()
+
+	var mu sync.Mutex
+	g, ctx := errgroup.WithContext(ctx)
+	sem := semaphore.NewWeighted(int64(workerCount))
+
+	for i, entry := range m.Repos {
+		res := ApplyResult{Name: entry.Name, DryRun: dryRun}
+		// Manifests may come from untrusted API callers, so an entry can
+		// neither clone outside the base directory nor add arbitrary remotes
+		target, err := entryTarget(cfg.BaseDir, entry)
+		if err == nil {
+			err = checkRemotes(entry.Remotes)
+		}
+		if err != nil {
+			res.Action = "error"
+			res.Error = err.Error()
+			report.Results[i] = res
+			report.Failed++
+			continue
+		}
+		res.Target = target
+		origin := entry.Remotes["origin"]
+		wanted[filepath.Clean(target)] = true
+		if slug := repoSlug(origin); slug != "" {
+			wanted[slug] = true
+		}
+
+		if byPath[filepath.Clean(target)] || bySlug[repoSlug(origin)] || exists(target) {
+			res.Action = "present"
+			report.Results[i] = res
+			report.Present++
+			continue
+		}
+
+		spec, err := scan.ResolveClone(origin, cfg, target)
+		if err != nil {
+			res.Action = "error"
+			res.Error = err.Error()
+			report.Results[i] = res
+			report.Failed++
+			continue
+		}
+		spec.Branch = entry.DefaultBranch
+		res.Action = "clone"
+		res.URL = spec.URL
+
+		if dryRun {
+			report.Results[i] = res
+			continue
+		}
+
+		g.Go(func() error {
+			if err := sem.Acquire(ctx, 1); err != nil {
+				return nil // Context cancelled
+			}
+			defer sem.Release(1)
+
+			start := time.Now()
+			err := spec.Run(cfg, nil, nil)
+			if err == nil {
+				err = addExtraRemotes(gitClient, target, entry.Remotes)
+			}
+			res.DurationMs = time.Since(start).Milliseconds()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				res.Error = err.Error()
+				report.Failed++
+			} else {
+				res.Applied = true
+				report.Cloned++
+			}
+			report.Results[i] = res
+			return nil
+		})
+	}
+	g.Wait()
+
+	for _, r := range repos {
+		if wanted[filepath.Clean(r.Path)] || wanted[repoSlug(r.RemoteURL)] {
+			continue
+		}
+		report.Extra = append(report.Extra, ExtraRepo{Name: r.Name, Path: r.Path, RemoteURL: r.RemoteURL})
+	}
+	sort.Slice(report.Extra, func(i, j int) bool { return report.Extra[i].Path < report.Extra[j].Path })
+
+	return report
+}
+
+// addExtraRemotes recreates non-origin remotes after a clone
+func addExtraRemotes(g *git.Git, repoPath string, remotes map[string]string) error {
+	for name, url := range remotes {
+		if name == "origin" {
+			continue
+		}
+		if err := g.AddRemote(repoPath, name, url); err != nil {
+			return fmt.Errorf("adding remote %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// entryTarget resolves where an entry lives on this machine, refusing
+// absolute paths and any path that leaves the base directory
+func entryTarget(baseDir string, e Entry) (string, error) {
+	if filepath.IsAbs(e.Path) {
+		return "", fmt.Errorf("path %s is absolute; manifest paths are relative to the base directory", e.Path)
+	}
+	target := filepath.Join(baseDir, e.Folder, e.Name)
+	if e.Path != "" {
+		target = filepath.Join(baseDir, e.Path)
+	}
+	rel, err := filepath.Rel(baseDir, target)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("target %s is outside the base directory %s", target, baseDir)
+	}
+	return target, nil
+}
+
+// remoteNamePattern matches remote names git accepts that cannot be read
+// as options
+var remoteNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// remoteURLPattern matches network remotes: https, ssh and git URLs and
+// scp-like [user@]host:path. Local paths and transports such as ext:: and
+// file:// are not matched.
+var remoteURLPattern = regexp.MustCompile(`^((https|ssh|git)://[^\s]+|[A-Za-z0-9][A-Za-z0-9._-]*(@[A-Za-z0-9][A-Za-z0-9._-]*)?:[^\s:][^\s]*)$`)
+
+// checkRemotes rejects remote names and URLs a manifest must not carry
+func checkRemotes(remotes map[string]string) error {
+	for name, url := range remotes {
+		if !remoteNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid remote name %q", name)
+		}
+		if !remoteURLPattern.MatchString(url) {
+			return fmt.Errorf("remote %s: unsupported URL %q (use https, ssh or user@host:path)", name, url)
+		}
+	}
+	return nil
+}
+
+// relativePath stores paths under the base directory relative to it so the
+// manifest is portable between machines with different home directories
+func relativePath(baseDir, path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	rel, err := filepath.Rel(baseDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
+
+// repoSlug normalizes a remote URL to a lowercase owner/repo key
+func repoSlug(remoteURL string) string {
+	owner, name, ok := scan.ParseRepoURL(remoteURL)
+	if !ok {
+		return ""
+	}
+	return strings.ToLower(owner + "/" + name)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
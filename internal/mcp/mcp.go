@@ -0,0 +1,208 @@
+// Package mcp serves ds to AI agents over the Model Context Protocol:
+// JSON-RPC 2.0 messages, one per line, on stdin and stdout. Tools call the
+// same handlers as ds serve; resources expose the repository index and the
+// status of each repository.
+package mcp
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "io"
+    "net/http"
+    "slices"
+    "sync"
+
+    "github.com/verlyn13/ds-go/internal/scan"
+)
+
+// protocolVersions are the MCP revisions understood, newest first
+var protocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+const instructions = `ds manages the Git repositories of a workspace. Start with the status tool
+(filters: dirty, behind, account, ...) or read ds://index. fetch contacts remotes;
+organize_apply and exec change repositories, so run organize_plan or pass
+dry_run first where offered.`
+
+// JSON-RPC error codes
+const (
+    codeParse          = -32700
+    codeInvalidRequest = -32600
+    codeMethodNotFound = -32601
+    codeInvalidParams  = -32602
+    codeInternal       = -32603
+    codeNotFound       = -32002 // Unknown resource URI
+)
+
+type request struct {
+    JSONRPC string          `json:"jsonrpc"`
+    ID      json.RawMessage `json:"id,omitempty"`
+    Method  string          `json:"method"`
+    Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+    JSONRPC string          `json:"jsonrpc"`
+    ID      json.RawMessage `json:"id"`
+    Result  interface{}     `json:"result,omitempty"`
+    Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is a JSON-RPC error object
+type rpcError struct {
+    Code    int         `json:"code"`
+    Message string      `json:"message"`
+    Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// Server answers MCP requests. Tool calls go to an API handler, normally
+// the one ds serve uses.
+type Server struct {
+    api     http.Handler
+    scanner *scan.Scanner
+    version string
+    tools   []toolInfo
+
+    mu      sync.Mutex // Guards out and pending
+    out     io.Writer
+    pending map[string]context.CancelFunc // In-flight requests by id
+    wg      sync.WaitGroup
+}
+
+// New returns a Server calling api for tools and reading the index of
+// scanner. version is reported to clients.
+func New(api http.Handler, scanner *scan.Scanner, version string) *Server {
+    return &Server{api: api, scanner: scanner, version: version, tools: toolList(), pending: map[string]context.CancelFunc{}}
+}
+
+// Serve answers the requests read from r on w until r ends. Requests run
+// concurrently; a notifications/cancelled stops one and drops its reply.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+    s.out = w
+    sc := bufio.NewScanner(r)
+    sc.Buffer(make([]byte, 0, 64<<10), 16<<20)
+    for sc.Scan() {
+        line := bytes.TrimSpace(sc.Bytes())
+        if len(line) == 0 { continue }
+        var req request
+        if err := json.Unmarshal(line, &req); err != nil {
+            code := codeParse
+            if json.Valid(line) { code = codeInvalidRequest }
+            s.send(response{ID: json.RawMessage("null"), Error: &rpcError{Code: code, Message: err.Error()}})
+            continue
+        }
+        switch {
+        case req.Method == "":
+            // A reply to a server request; none are sent
+        case len(req.ID) == 0:
+            s.notification(req)
+        default:
+            s.start(ctx, req)
+        }
+    }
+    s.wg.Wait()
+    return sc.Err()
+}
+
+// start runs req in the background and replies unless it was cancelled
+func (s *Server) start(ctx context.Context, req request) {
+    ctx, cancel := context.WithCancel(ctx)
+    key := idKey(req.ID)
+    s.mu.Lock()
+    s.pending[key] = cancel
+    s.mu.Unlock()
+    s.wg.Add(1)
+    go func() {
+        defer s.wg.Done()
+        defer cancel()
+        result, err := s.call(ctx, req.Method, req.Params)
+        s.mu.Lock()
+        _, live := s.pending[key]
+        delete(s.pending, key)
+        s.mu.Unlock()
+        if !live { return }
+        resp := response{ID: req.ID, Result: result}
+        if err != nil {
+            resp.Result = nil
+            if !errors.As(err, &resp.Error) { resp.Error = &rpcError{Code: codeInternal, Message: err.Error()} }
+        }
+        s.send(resp)
+    }()
+}
+
+func (s *Server) notification(req request) {
+    if req.Method != "notifications/cancelled" { return }
+    var p struct {
+        RequestID json.RawMessage `json:"requestId"`
+    }
+    if json.Unmarshal(req.Params, &p) != nil { return }
+    key := idKey(p.RequestID)
+    s.mu.Lock()
+    if cancel, ok := s.pending[key]; ok {
+        cancel()
+        delete(s.pending, key)
+    }
+    s.mu.Unlock()
+}
+
+func (s *Server) call(ctx context.Context, method string, params json.RawMessage) (interface{}, error) {
+    switch method {
+    case "initialize":
+        return s.initialize(params), nil
+    case "ping":
+        return struct{}{}, nil
+    case "tools/list":
+        return map[string]interface{}{"tools": s.tools}, nil
+    case "tools/call":
+        return s.callTool(ctx, params)
+    case "resources/list":
+        return s.listResources()
+    case "resources/templates/list":
+        return map[string]interface{}{"resourceTemplates": []resourceTemplate{repoTemplate}}, nil
+    case "resources/read":
+        return s.readResource(ctx, params)
+    }
+    return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + method}
+}
+
+func (s *Server) initialize(params json.RawMessage) interface{} {
+    var p struct {
+        ProtocolVersion string `json:"protocolVersion"`
+    }
+    _ = json.Unmarshal(params, &p)
+    version := protocolVersions[0]
+    if slices.Contains(protocolVersions, p.ProtocolVersion) { version = p.ProtocolVersion }
+    return map[string]interface{}{
+        "protocolVersion": version,
+        "capabilities": map[string]interface{}{
+            "tools":     map[string]bool{"listChanged": false},
+            "resources": map[string]bool{"listChanged": false, "subscribe": false},
+        },
+        "serverInfo":   map[string]string{"name": "ds", "version": s.version},
+        "instructions": instructions,
+    }
+}
+
+func (s *Server) send(resp response) {
+    resp.JSONRPC = "2.0"
+    b, err := json.Marshal(resp)
+    if err != nil {
+        b, _ = json.Marshal(response{JSONRPC: "2.0", ID: resp.ID, Error: &rpcError{Code: codeInternal, Message: err.Error()}})
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    _, _ = s.out.Write(append(b, '\n'))
+}
+
+// idKey normalizes a request id so 7 and "7" stay distinct but spacing
+// does not matter
+func idKey(id json.RawMessage) string {
+    var v interface{}
+    if json.Unmarshal(id, &v) != nil { return string(id) }
+    b, _ := json.Marshal(v)
+    return string(b)
+}
@@ -0,0 +1,122 @@
+package mcp
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/url"
+    "strings"
+
+    "github.com/verlyn13/ds-go/internal/api"
+    "github.com/verlyn13/ds-go/internal/scan"
+)
+
+const (
+    indexURI   = "ds://index"
+    repoPrefix = "ds://repo/"
+)
+
+type resource struct {
+    URI         string `json:"uri"`
+    Name        string `json:"name"`
+    Title       string `json:"title,omitempty"`
+    Description string `json:"description,omitempty"`
+    MimeType    string `json:"mimeType"`
+}
+
+type resourceTemplate struct {
+    URITemplate string `json:"uriTemplate"`
+    Name        string `json:"name"`
+    Title       string `json:"title,omitempty"`
+    Description string `json:"description,omitempty"`
+    MimeType    string `json:"mimeType"`
+}
+
+type resourceContents struct {
+    URI      string `json:"uri"`
+    MimeType string `json:"mimeType"`
+    Text     string `json:"text"`
+}
+
+var repoTemplate = resourceTemplate{
+    URITemplate: repoPrefix + "{account}/{name}",
+    Name:        "repo",
+    Title:       "Repository",
+    Description: "Current status of one repository (ds.v2 schema)",
+    MimeType:    "application/json",
+}
+
+func repoURI(r scan.Repository) string {
+    return repoPrefix + url.PathEscape(r.Account) + "/" + url.PathEscape(r.Name)
+}
+
+// listResources lists the index and each repository in it. The index is
+// read from disk, so listing never scans.
+func (s *Server) listResources() (interface{}, error) {
+    repos, err := s.scanner.LoadIndex()
+    if err != nil { return nil, err }
+    list := []resource{{
+        URI:         indexURI,
+        Name:        "index",
+        Title:       "Repository index",
+        Description: "Repositories recorded by the last scan; the scan tool refreshes it",
+        MimeType:    "application/json",
+    }}
+    for _, r := range repos {
+        list = append(list, resource{URI: repoURI(r), Name: r.Account + "/" + r.Name, Description: r.Path, MimeType: "application/json"})
+    }
+    return map[string]interface{}{"resources": list}, nil
+}
+
+func (s *Server) readResource(ctx context.Context, params json.RawMessage) (interface{}, error) {
+    var p struct {
+        URI string `json:"uri"`
+    }
+    if err := json.Unmarshal(params, &p); err != nil || p.URI == "" {
+        return nil, &rpcError{Code: codeInvalidParams, Message: "uri is required"}
+    }
+    notFound := &rpcError{Code: codeNotFound, Message: "resource not found", Data: map[string]string{"uri": p.URI}}
+    var v interface{}
+    switch {
+    case p.URI == indexURI:
+        repos, err := s.scanner.LoadIndex()
+        if err != nil { return nil, err }
+        list := make([]api.RepositoryV2, len(repos))
+        for i, r := range repos {
+            list[i] = api.NewRepositoryV2(r)
+        }
+        v = list
+    case strings.HasPrefix(p.URI, repoPrefix):
+        repo, err := s.repo(ctx, strings.TrimPrefix(p.URI, repoPrefix))
+        if err != nil { return nil, err }
+        if repo == nil { return nil, notFound }
+        v = repo
+    default:
+        return nil, notFound
+    }
+    b, err := json.MarshalIndent(v, "", "  ")
+    if err != nil { return nil, err }
+    return map[string]interface{}{"contents": []resourceContents{{URI: p.URI, MimeType: "application/json", Text: string(b)}}}, nil
+}
+
+// repo finds account/name in the API's current status; nil if absent
+func (s *Server) repo(ctx context.Context, ref string) (*api.RepositoryV2, error) {
+    account, name, ok := strings.Cut(ref, "/")
+    if !ok { return nil, nil }
+    account, err := url.PathUnescape(account)
+    if err != nil { return nil, nil }
+    if name, err = url.PathUnescape(name); err != nil { return nil, nil }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/v2/status?"+url.Values{"account": {account}}.Encode(), nil)
+    if err != nil { return nil, err }
+    req.Header.Set("Accept", "application/json")
+    rec := &recorder{header: http.Header{}}
+    s.api.ServeHTTP(rec, req)
+    if rec.status >= 400 { return nil, &rpcError{Code: codeInternal, Message: strings.TrimSpace(rec.body.String())} }
+    var resp api.StatusResponseV2
+    if err := json.Unmarshal(rec.body.Bytes(), &resp); err != nil { return nil, err }
+    for i := range resp.Data {
+        if resp.Data[i].Name == name { return &resp.Data[i], nil }
+    }
+    return nil, nil
+}
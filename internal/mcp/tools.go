@@ -0,0 +1,205 @@
+package mcp
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "slices"
+    "strings"
+
+    "github.com/verlyn13/ds-go/internal/api"
+)
+
+// tool exposes an API route as an MCP tool. Its arguments are the route's
+// query parameters and body fields; results use the ds.v2 schema.
+type tool struct {
+    name        string
+    title       string
+    description string   // Replaces the route's summary and description
+    route       string   // api.Routes name
+    omit        []string // Parameters and body fields the tool does not take
+    readOnly    bool
+    destructive bool
+    openWorld   bool // Talks to remotes or runs arbitrary commands
+}
+
+var tools = []tool{
+    // Without fields every item matches the output schema
+    {name: "status", title: "Repository status", route: "getStatus", omit: []string{"fields"}, readOnly: true},
+    {name: "scan", title: "Rescan repositories", route: "scan"},
+    {name: "fetch", title: "Fetch remotes", route: "fetch", openWorld: true},
+    {name: "organize_plan", title: "Plan organize moves", route: "planOrganize", readOnly: true},
+    {name: "organize_apply", title: "Apply organize moves", route: "applyOrganize", destructive: true},
+    // A chosen policy file runs its own shell commands, so only the
+    // configured policy is offered, as exec offers only templates
+    {name: "policy_check", title: "Run policy checks", route: "checkPolicy", omit: []string{"format", "file"}, openWorld: true},
+    {name: "list_commands", title: "List command templates", route: "listCommands", readOnly: true},
+    // Raw commands are refused without auth, so only templates are offered
+    {name: "exec", title: "Run a command template", route: "exec", omit: []string{"cmd"}, destructive: true, openWorld: true,
+        description: "Run a command template from the ds config across the selected repositories. list_commands shows the templates and their params."},
+}
+
+// toolInfo is a tools/list entry
+type toolInfo struct {
+    Name         string      `json:"name"`
+    Title        string      `json:"title,omitempty"`
+    Description  string      `json:"description"`
+    InputSchema  *api.Schema `json:"inputSchema"`
+    OutputSchema *api.Schema `json:"outputSchema,omitempty"`
+    Annotations  annotations `json:"annotations"`
+}
+
+type annotations struct {
+    Title           string `json:"title,omitempty"`
+    ReadOnlyHint    bool   `json:"readOnlyHint"`
+    DestructiveHint bool   `json:"destructiveHint"`
+    IdempotentHint  bool   `json:"idempotentHint"`
+    OpenWorldHint   bool   `json:"openWorldHint"`
+}
+
+type content struct {
+    Type string `json:"type"`
+    Text string `json:"text"`
+}
+
+// callResult is the result of tools/call
+type callResult struct {
+    Content           []content   `json:"content"`
+    StructuredContent interface{} `json:"structuredContent,omitempty"`
+    IsError           bool        `json:"isError,omitempty"`
+}
+
+// toolList describes every tool; a tool naming a missing route is a bug
+func toolList() []toolInfo {
+    var list []toolInfo
+    for _, t := range tools {
+        rt := t.mustRoute()
+        in := &api.Schema{Type: "object", Properties: map[string]*api.Schema{}}
+        if rt.Body != nil {
+            body, err := api.JSONSchema(rt.Body)
+            if err != nil { panic(err) }
+            in = body
+        }
+        for _, p := range rt.Params {
+            in.Properties[p.Name] = p.Schema()
+        }
+        for _, name := range t.omit {
+            delete(in.Properties, name)
+            in.Required = slices.DeleteFunc(in.Required, func(r string) bool { return r == name })
+        }
+        desc := t.description
+        if desc == "" { desc = strings.TrimSpace(rt.Summary + ". " + rt.Description) }
+        info := toolInfo{
+            Name:        t.name,
+            Title:       t.title,
+            Description: desc,
+            InputSchema: in,
+            Annotations: annotations{
+                Title:           t.title,
+                ReadOnlyHint:    t.readOnly,
+                DestructiveHint: t.destructive,
+                IdempotentHint:  !t.destructive,
+                OpenWorldHint:   t.openWorld,
+            },
+        }
+        resp := rt.Response
+        if rt.ResponseV2 != nil { resp = rt.ResponseV2 }
+        if resp != nil {
+            out, err := api.JSONSchema(resp)
+            if err != nil { panic(err) }
+            info.OutputSchema = out
+        }
+        list = append(list, info)
+    }
+    return list
+}
+
+func (t tool) mustRoute() api.Route {
+    rt, ok := api.RouteNamed(t.route)
+    if !ok { panic("mcp: tool " + t.name + " names unknown route " + t.route) }
+    return rt
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (interface{}, error) {
+    var p struct {
+        Name      string                 `json:"name"`
+        Arguments map[string]interface{} `json:"arguments"`
+    }
+    dec := json.NewDecoder(bytes.NewReader(params))
+    dec.UseNumber()
+    if err := dec.Decode(&p); err != nil { return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()} }
+    i := slices.IndexFunc(tools, func(t tool) bool { return t.name == p.Name })
+    if i < 0 { return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + p.Name} }
+
+    req, err := tools[i].request(ctx, p.Arguments)
+    if err != nil {
+        return callResult{Content: []content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+    }
+    rec := &recorder{header: http.Header{}}
+    s.api.ServeHTTP(rec, req)
+    return rec.result(), nil
+}
+
+// request builds the API request for a call with args. Arguments naming a
+// route parameter go in the query, the rest in the JSON body.
+func (t tool) request(ctx context.Context, args map[string]interface{}) (*http.Request, error) {
+    rt := t.mustRoute()
+    q := url.Values{}
+    body := map[string]interface{}{}
+    for name, v := range args {
+        if slices.Contains(t.omit, name) { return nil, fmt.Errorf("unknown argument %q", name) }
+        if slices.ContainsFunc(rt.Params, func(p api.Param) bool { return p.Name == name }) {
+            q.Set(name, fmt.Sprint(v))
+            continue
+        }
+        if rt.Body == nil { return nil, fmt.Errorf("unknown argument %q", name) }
+        body[name] = v
+    }
+
+    path := rt.Path
+    if rest, ok := strings.CutPrefix(path, "/v1/"); ok { path = "/v2/" + rest }
+    if len(q) > 0 { path += "?" + q.Encode() }
+    var rd io.Reader
+    if rt.Body != nil {
+        b, err := json.Marshal(body)
+        if err != nil { return nil, err }
+        rd = bytes.NewReader(b)
+    }
+    req, err := http.NewRequestWithContext(ctx, rt.Method, path, rd)
+    if err != nil { return nil, err }
+    req.RemoteAddr = "mcp:stdio" // Shown in the exec audit log
+    req.Header.Set("Accept", "application/json")
+    if rt.Body != nil { req.Header.Set("Content-Type", "application/json") }
+    return req, nil
+}
+
+// recorder captures an API response in memory
+type recorder struct {
+    header http.Header
+    status int
+    body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header { return r.header }
+
+func (r *recorder) WriteHeader(status int) {
+    if r.status == 0 { r.status = status }
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+    if r.status == 0 { r.status = http.StatusOK }
+    return r.body.Write(b)
+}
+
+// result turns the response into a tool result: the body as text, a JSON
+// object body also as structured content, and error statuses flagged
+func (r *recorder) result() callResult {
+    res := callResult{Content: []content{{Type: "text", Text: r.body.String()}}, IsError: r.status >= 400}
+    var obj map[string]interface{}
+    if !res.IsError && json.Unmarshal(r.body.Bytes(), &obj) == nil { res.StructuredContent = obj }
+    return res
+}
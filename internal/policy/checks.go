@@ -0,0 +1,135 @@
+package policy
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "io/fs"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "regexp"
+    "strings"
+    "time"
+
+    "github.com/verlyn13/ds-go/internal/git"
+)
+
+// maxExcerpt caps captured stdout/stderr per check
+const maxExcerpt = 2048
+
+// excerptWriter keeps the first maxExcerpt bytes written and counts the rest
+type excerptWriter struct {
+    buf     bytes.Buffer
+    dropped int
+}
+
+func (w *excerptWriter) Write(p []byte) (int, error) {
+    room := maxExcerpt - w.buf.Len()
+    if room < len(p) {
+        if room > 0 { w.buf.Write(p[:room]) }
+        w.dropped += len(p) - max(room, 0)
+        return len(p), nil
+    }
+    return w.buf.Write(p)
+}
+
+func (w *excerptWriter) String() string {
+    s := strings.TrimRight(w.buf.String(), "\n")
+    if w.dropped > 0 {
+        s += fmt.Sprintf("\n… (%d more bytes)", w.dropped)
+    }
+    return s
+}
+
+// runCommand runs a shell command in dir, returning output excerpts
+func runCommand(ctx context.Context, command, dir string) (string, string, error) {
+    if strings.TrimSpace(command) == "" {
+        return "", "", fmt.Errorf("command check has no command")
+    }
+    var stdout, stderr excerptWriter
+    cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+    cmd.Dir = dir
+    cmd.Stdout = &stdout
+    cmd.Stderr = &stderr
+    cmd.WaitDelay = time.Second // Don't wait on children holding the pipes after a timeout
+    err := cmd.Run()
+    return stdout.String(), stderr.String(), err
+}
+
+func checkFileExists(dir, path string) error {
+    if path == "" { return fmt.Errorf("file_exists check has no path") }
+    if _, err := os.Stat(resolve(dir, path)); err != nil {
+        return fmt.Errorf("missing %s", path)
+    }
+    return nil
+}
+
+// checkGlobAbsent fails when any file matches glob. Patterns without a slash
+// match file names at any depth; patterns with one match paths relative to dir.
+func checkGlobAbsent(ctx context.Context, dir, glob string) error {
+    if glob == "" { return fmt.Errorf("glob_absent check has no glob") }
+    if _, err := filepath.Match(glob, ""); err != nil {
+        return fmt.Errorf("invalid glob %q: %w", glob, err)
+    }
+    root := resolve(dir, ".")
+    byPath := strings.Contains(glob, "/")
+
+    var found []string
+    err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+        if err != nil { return nil }
+        if ctx.Err() != nil { return ctx.Err() }
+        if d.IsDir() {
+            if d.Name() == ".git" || d.Name() == "node_modules" { return filepath.SkipDir }
+            return nil
+        }
+        rel, _ := filepath.Rel(root, path)
+        subject := d.Name()
+        if byPath { subject = filepath.ToSlash(rel) }
+        if ok, _ := filepath.Match(glob, subject); ok {
+            found = append(found, rel)
+        }
+        return nil
+    })
+    if err != nil { return err }
+    if len(found) == 0 { return nil }
+
+    shown := found
+    if len(shown) > 5 { shown = shown[:5] }
+    msg := fmt.Sprintf("%d file(s) match %s: %s", len(found), glob, strings.Join(shown, ", "))
+    if len(found) > len(shown) { msg += ", …" }
+    return fmt.Errorf("%s", msg)
+}
+
+func checkFileContains(dir, path, pattern string) error {
+    if path == "" || pattern == "" { return fmt.Errorf("file_contains check needs path and regex") }
+    re, err := regexp.Compile(pattern)
+    if err != nil { return fmt.Errorf("invalid regex %q: %w", pattern, err) }
+    data, err := os.ReadFile(resolve(dir, path))
+    if err != nil { return fmt.Errorf("missing %s", path) }
+    if !re.Match(data) {
+        return fmt.Errorf("%s does not match /%s/", path, pattern)
+    }
+    return nil
+}
+
+func checkGitConfig(dir, key, want string) error {
+    if key == "" { return fmt.Errorf("git_config check has no key") }
+    got, err := git.New().ConfigGet(resolve(dir, "."), key)
+    if err != nil { return fmt.Errorf("%s is not set", key) }
+    if got != want {
+        return fmt.Errorf("%s is %q, want %q", key, got, want)
+    }
+    return nil
+}
+
+// resolve expands ~ and joins relative paths onto dir
+func resolve(dir, path string) string {
+    if strings.HasPrefix(path, "~/") {
+        if home, err := os.UserHomeDir(); err == nil {
+            return filepath.Join(home, path[2:])
+        }
+    }
+    if filepath.IsAbs(path) || dir == "" { return path }
+    return filepath.Join(dir, path)
+}
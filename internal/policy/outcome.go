@@ -0,0 +1,96 @@
+package policy
+
+import (
+    "fmt"
+    "time"
+)
+
+// ExitFailed is the process exit code when a policy gate fails. The CLI
+// exits with it and the API reports the same outcome as failed_threshold.
+const ExitFailed = 20
+
+// Waiver temporarily accepts failures of one check, optionally for one repo
+type Waiver struct {
+    Check   string `yaml:"check" json:"check"`
+    Repo    string `yaml:"repo,omitempty" json:"repo,omitempty"` // Empty waives every repository
+    Reason  string `yaml:"reason" json:"reason"`
+    Expires string `yaml:"expires" json:"expires"` // YYYY-MM-DD, inclusive
+}
+
+// expiry returns the first instant the waiver no longer applies
+func (w Waiver) expiry() (time.Time, error) {
+    if w.Expires == "" { return time.Time{}, fmt.Errorf("expires is required (YYYY-MM-DD)") }
+    day, err := time.ParseInLocation("2006-01-02", w.Expires, time.Local)
+    if err != nil { return time.Time{}, fmt.Errorf("invalid expires %q (use YYYY-MM-DD)", w.Expires) }
+    return day.AddDate(0, 0, 1), nil
+}
+
+func (w Waiver) covers(res CheckResult) bool {
+    return w.Check == res.Name && (w.Repo == "" || w.Repo == res.Repo)
+}
+
+// classify assigns each result its status, fills the summary and decides
+// whether the gate failed. Skipped results keep their status.
+func (r *Report) classify(waivers []Waiver, opts Options) {
+    now := opts.Now
+    if now.IsZero() { now = time.Now() }
+    threshold := sevOrder[opts.FailOn]
+
+    r.Summary = Summary{Total: len(r.Results)}
+    for i := range r.Results {
+        res := &r.Results[i]
+        switch {
+        case res.Status == StatusSkipped:
+        case res.Passed:
+            res.Status = StatusPassed
+        default:
+            res.Status = StatusFailed
+            if w, expired := findWaiver(waivers, *res, now); w != nil {
+                if expired {
+                    res.Error += fmt.Sprintf(" (waiver expired %s)", w.Expires)
+                } else {
+                    res.Waiver = w
+                    res.Status = StatusWaived
+                }
+            }
+            if res.Status == StatusFailed && (res.AllowFailure || sevOrder[res.Severity] < threshold) {
+                res.Status = StatusWarning
+            }
+        }
+
+        switch res.Status {
+        case StatusPassed:
+            r.Summary.Passed++
+        case StatusFailed:
+            r.Summary.Failed++
+        case StatusWarning:
+            r.Summary.Warnings++
+        case StatusSkipped:
+            r.Summary.Skipped++
+        case StatusWaived:
+            r.Summary.Waived++
+        }
+    }
+    r.FailedThreshold = opts.FailOn != "" && r.Summary.Failed > 0
+}
+
+// findWaiver returns the waiver covering res, preferring an active one, and
+// whether the returned waiver has expired
+func findWaiver(waivers []Waiver, res CheckResult, now time.Time) (*Waiver, bool) {
+    var expired *Waiver
+    for i := range waivers {
+        w := waivers[i]
+        if !w.covers(res) { continue }
+        end, err := w.expiry()
+        if err != nil { continue }
+        if now.Before(end) { return &w, false }
+        expired = &w
+    }
+    return expired, expired != nil
+}
+
+// ExitCode returns ExitFailed when the gate failed, otherwise 0
+func (r *Report) ExitCode() int {
+    if r.FailedThreshold { return ExitFailed }
+    return 0
+}
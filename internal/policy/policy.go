@@ -1,12 +1,15 @@
 package policy
 
 import (
+    "context"
     "fmt"
-    "os/exec"
+    "os"
+    "strings"
     "time"
 
+    "golang.org/x/sync/errgroup"
+    "golang.org/x/sync/semaphore"
     "gopkg.in/yaml.v3"
-    "os"
 )
 
 type Severity string
@@ -18,24 +21,107 @@ const (
     SevLow      Severity = "low"
 )
 
+// Check types; an empty type means command
+const (
+    TypeCommand      = "command"
+    TypeFileExists   = "file_exists"
+    TypeGlobAbsent   = "glob_absent"
+    TypeFileContains = "file_contains"
+    TypeGitConfig    = "git_config"
+    TypeDirExists    = "dir_exists"
+    TypeTool         = "tool"
+    TypeGitHook      = "git_hook"
+    TypeDSAccount    = "ds_account"
+)
+
+// Result statuses. Failed checks below the fail-on threshold or marked
+// allow_failure are warnings; failures covered by an active waiver are waived.
+const (
+    StatusPassed  = "passed"
+    StatusFailed  = "failed"
+    StatusWarning = "warning"
+    StatusSkipped = "skipped"
+    StatusWaived  = "waived"
+)
+
+// DefaultTimeout bounds a single check unless the check or caller sets one
+const DefaultTimeout = 60 * time.Second
+
 type Config struct {
+    ProjectStructure struct {
+        RequiredFiles       []PathRequirement `yaml:"required_files"`
+        RequiredDirectories []PathRequirement `yaml:"required_directories"`
+    } `yaml:"project_structure"`
+    Tools    map[string]ToolRequirement `yaml:"tools"`
+    Workflow struct {
+        Commands map[string]string   `yaml:"commands"`
+        GitHooks map[string][]string `yaml:"git_hooks"` // Hook name -> commands it must run
+    } `yaml:"workflow"`
+    DSAccounts map[string]AccountRequirement `yaml:"ds_accounts"`
     Validation struct {
-        Checks []struct {
-            Name        string   `yaml:"name"`
-            Description string   `yaml:"description"`
-            Command     string   `yaml:"command"`
-            Severity    Severity `yaml:"severity"`
-        } `yaml:"checks"`
+        Checks []Check `yaml:"checks"`
     } `yaml:"validation"`
+    Waivers []Waiver `yaml:"waivers"`
+}
+
+// PathRequirement is a file or directory the project must contain
+type PathRequirement struct {
+    Path    string `yaml:"path"`
+    Purpose string `yaml:"purpose"`
+}
+
+// ToolRequirement is a tool that must be installed
+type ToolRequirement struct {
+    Version   string `yaml:"version"` // Matched against --version output; "latest" accepts any
+    ManagedBy string `yaml:"managed_by"`
+}
+
+// AccountRequirement maps a GitHub account to its directory and SSH host
+type AccountRequirement struct {
+    Type      string `yaml:"type"`
+    Directory string `yaml:"directory"`
+    SSHHost   string `yaml:"ssh_host"`
+}
+
+// Check is one validation rule. Command checks run a shell command; the
+// built-in types inspect the repository directly.
+type Check struct {
+    Name         string   `yaml:"name"`
+    Description  string   `yaml:"description"`
+    Type         string   `yaml:"type"`
+    Command      string   `yaml:"command"`
+    Path         string   `yaml:"path"`          // file_exists, file_contains
+    Glob         string   `yaml:"glob"`          // glob_absent
+    Regex        string   `yaml:"regex"`         // file_contains
+    Key          string   `yaml:"key"`           // git_config
+    Value        string   `yaml:"value"`         // git_config
+    Tool         string   `yaml:"tool"`          // tool
+    Version      string   `yaml:"version"`       // tool
+    Hook         string   `yaml:"hook"`          // git_hook
+    Commands     []string `yaml:"commands"`      // git_hook
+    Timeout      string   `yaml:"timeout"`       // e.g. 30s
+    Severity     Severity `yaml:"severity"`
+    AllowFailure bool     `yaml:"allow_failure"` // Report failures as warnings
+    SkipIf       string   `yaml:"skip_if"`       // Shell condition; exit 0 skips the check
+
+    accounts map[string]AccountRequirement // ds_account
 }
 
 type CheckResult struct {
-    Name        string   `json:"name"`
-    Description string   `json:"description"`
-    Severity    Severity `json:"severity"`
-    Passed      bool     `json:"passed"`
-    Error       string   `json:"error,omitempty"`
-    DurationMs  int64    `json:"duration_ms"`
+    Repo         string   `json:"repo,omitempty"`
+    Name         string   `json:"name"`
+    Description  string   `json:"description"`
+    Type         string   `json:"type"`
+    Severity     Severity `json:"severity"`
+    Passed       bool     `json:"passed"`
+    Status       string   `json:"status"`
+    Error        string   `json:"error,omitempty"`
+    Stdout       string   `json:"stdout,omitempty"`
+    Stderr       string   `json:"stderr,omitempty"`
+    TimedOut     bool     `json:"timed_out,omitempty"`
+    AllowFailure bool     `json:"allow_failure,omitempty"`
+    Waiver       *Waiver  `json:"waiver,omitempty"`
+    DurationMs   int64    `json:"duration_ms"`
 }
 
 type Summary struct {
@@ -43,11 +129,30 @@ type Summary struct {
     Passed   int `json:"passed"`
     Failed   int `json:"failed"`
     Warnings int `json:"warnings"`
+    Skipped  int `json:"skipped"`
+    Waived   int `json:"waived"`
 }
 
 type Report struct {
-    Results []CheckResult `json:"results"`
-    Summary Summary       `json:"summary"`
+    File            string        `json:"file,omitempty"`   // Policy file the checks came from
+    FailOn          Severity      `json:"fail_on,omitempty"`
+    FailedThreshold bool          `json:"failed_threshold"` // Any failure at or above FailOn
+    Results         []CheckResult `json:"results"`
+    Summary         Summary       `json:"summary"`
+}
+
+// Target is a directory the policy is applied to
+type Target struct {
+    Name string // Reported as CheckResult.Repo; empty for a single target
+    Dir  string
+}
+
+// Options controls check execution
+type Options struct {
+    Workers int           // Checks run concurrently; default 4
+    Timeout time.Duration // Per-check timeout when the check sets none
+    FailOn  Severity      // Failures below this severity are warnings; empty disables the gate
+    Now     time.Time     // Reference time for waiver expiry; default time.Now
 }
 
 func Load(path string) (*Config, error) {
@@ -55,45 +160,161 @@ func Load(path string) (*Config, error) {
     if err != nil { return nil, err }
     var cfg Config
     if err := yaml.Unmarshal(data, &cfg); err != nil { return nil, err }
+    for i, c := range cfg.Validation.Checks {
+        if c.Type == "" { cfg.Validation.Checks[i].Type = TypeCommand }
+    }
+    if err := cfg.Validate(); err != nil { return nil, fmt.Errorf("%s: %w", path, err) }
     return &cfg, nil
 }
 
+// Validate rejects checks with missing or unknown severities or types,
+// malformed timeouts, and waivers that are incomplete or name no check
+func (cfg *Config) Validate() error {
+    names := map[string]bool{}
+    for _, c := range cfg.Checks() {
+        names[c.Name] = true
+        if c.Name == "" { return fmt.Errorf("check with no name") }
+        if _, err := SeverityFromString(string(c.Severity)); err != nil {
+            return fmt.Errorf("check %q: severity must be one of critical, high, medium, low (got %q)", c.Name, c.Severity)
+        }
+        if !knownTypes[c.Type] { return fmt.Errorf("check %q: unknown type %q", c.Name, c.Type) }
+        if c.Timeout != "" {
+            if _, err := time.ParseDuration(c.Timeout); err != nil {
+                return fmt.Errorf("check %q: invalid timeout %q", c.Name, c.Timeout)
+            }
+        }
+    }
+    for i, w := range cfg.Waivers {
+        if !names[w.Check] { return fmt.Errorf("waiver %d: unknown check %q", i+1, w.Check) }
+        if strings.TrimSpace(w.Reason) == "" { return fmt.Errorf("waiver for %q: reason is required", w.Check) }
+        if _, err := w.expiry(); err != nil { return fmt.Errorf("waiver for %q: %w", w.Check, err) }
+    }
+    return nil
+}
+
+var knownTypes = map[string]bool{
+    TypeCommand: true, TypeFileExists: true, TypeGlobAbsent: true, TypeFileContains: true, TypeGitConfig: true,
+    TypeDirExists: true, TypeTool: true, TypeGitHook: true, TypeDSAccount: true,
+}
+
+// Checks returns the declared project structure, tools, git hooks and
+// ds_accounts as checks, followed by validation.checks
+func (cfg *Config) Checks() []Check {
+    var checks []Check
+    for _, f := range cfg.ProjectStructure.RequiredFiles {
+        checks = append(checks, Check{Name: "required_file:" + f.Path, Description: f.Purpose, Type: TypeFileExists, Path: f.Path, Severity: SevHigh})
+    }
+    for _, d := range cfg.ProjectStructure.RequiredDirectories {
+        checks = append(checks, Check{Name: "required_directory:" + d.Path, Description: d.Purpose, Type: TypeDirExists, Path: d.Path, Severity: SevHigh})
+    }
+    for _, name := range sortedKeys(cfg.Tools) {
+        t := cfg.Tools[name]
+        desc := "Tool installed"
+        if t.ManagedBy != "" { desc += " (managed by " + t.ManagedBy + ")" }
+        checks = append(checks, Check{Name: "tool:" + name, Description: desc, Type: TypeTool, Tool: name, Version: t.Version, Severity: SevMedium})
+    }
+    for _, hook := range sortedKeys(cfg.Workflow.GitHooks) {
+        checks = append(checks, Check{Name: "git_hook:" + hook, Description: "Git hook runs workflow commands", Type: TypeGitHook, Hook: hook, Commands: cfg.Workflow.GitHooks[hook], Severity: SevMedium})
+    }
+    if len(cfg.DSAccounts) > 0 {
+        checks = append(checks, Check{Name: "ds_account", Description: "Remote and location match ds_accounts", Type: TypeDSAccount, Severity: SevHigh})
+    }
+    checks = append(checks, cfg.Validation.Checks...)
+    for i := range checks {
+        if checks[i].Type == TypeDSAccount { checks[i].accounts = cfg.DSAccounts }
+    }
+    return checks
+}
+
+// RunChecks runs every check in the current directory
 func RunChecks(cfg *Config) (*Report, error) {
-    var results []CheckResult
-    var passed, failed int
-    for _, c := range cfg.Validation.Checks {
-        start := time.Now()
-        res := CheckResult{Name: c.Name, Description: c.Description, Severity: c.Severity}
-        cmd := exec.Command("/bin/sh", "-c", c.Command)
-        if err := cmd.Run(); err != nil {
-            res.Passed = false
-            res.Error = err.Error()
-            failed++
-        } else {
-            res.Passed = true
-            passed++
+    return Run(context.Background(), cfg, []Target{{Dir: "."}}, Options{})
+}
+
+// Run applies the policy to each target, running checks in parallel with
+// per-check timeouts. Results are ordered by target, then by check.
+func Run(ctx context.Context, cfg *Config, targets []Target, opts Options) (*Report, error) {
+    if opts.Workers <= 0 { opts.Workers = 4 }
+    if opts.Timeout <= 0 { opts.Timeout = DefaultTimeout }
+
+    checks := cfg.Checks()
+    results := make([]CheckResult, len(targets)*len(checks))
+
+    g, gctx := errgroup.WithContext(ctx)
+    sem := semaphore.NewWeighted(int64(opts.Workers))
+    for ti, t := range targets {
+        for ci, c := range checks {
+            idx := ti*len(checks) + ci
+            g.Go(func() error {
+                if err := sem.Acquire(gctx, 1); err != nil { return err }
+                defer sem.Release(1)
+                results[idx] = runCheck(gctx, c, t, opts.Timeout)
+                return nil
+            })
         }
-        res.DurationMs = time.Since(start).Milliseconds()
-        results = append(results, res)
     }
-    r := &Report{Results: results, Summary: Summary{Total: len(results), Passed: passed, Failed: failed}}
+    if err := g.Wait(); err != nil { return nil, err }
+
+    r := &Report{Results: results, FailOn: opts.FailOn}
+    r.classify(cfg.Waivers, opts)
     return r, nil
 }
 
-// FailIfAboveSeverity returns true if any failed check at or above threshold exists
-func FailIfAboveSeverity(r *Report, threshold Severity, cfg *Config) bool {
-    sevOrder := map[Severity]int{SevCritical: 3, SevHigh: 2, SevMedium: 1, SevLow: 0}
-    th := sevOrder[threshold]
-    for i, res := range r.Results {
-        if !res.Passed {
-            if sevOrder[cfg.Validation.Checks[i].Severity] >= th {
-                return true
-            }
+func runCheck(ctx context.Context, c Check, t Target, defaultTimeout time.Duration) CheckResult {
+    start := time.Now()
+    res := CheckResult{Repo: t.Name, Name: c.Name, Description: c.Description, Type: c.Type, Severity: c.Severity, AllowFailure: c.AllowFailure}
+    if res.Type == "" { res.Type = TypeCommand }
+
+    timeout := defaultTimeout
+    if d, err := time.ParseDuration(c.Timeout); err == nil && d > 0 { timeout = d }
+    ctx, cancel := context.WithTimeout(ctx, timeout)
+    defer cancel()
+
+    if c.SkipIf != "" {
+        if _, _, err := runCommand(ctx, c.SkipIf, t.Dir); err == nil {
+            res.Status = StatusSkipped
+            res.DurationMs = time.Since(start).Milliseconds()
+            return res
         }
     }
-    return false
+
+    var err error
+    switch res.Type {
+    case TypeCommand:
+        res.Stdout, res.Stderr, err = runCommand(ctx, c.Command, t.Dir)
+    case TypeFileExists:
+        err = checkFileExists(t.Dir, c.Path)
+    case TypeGlobAbsent:
+        err = checkGlobAbsent(ctx, t.Dir, c.Glob)
+    case TypeFileContains:
+        err = checkFileContains(t.Dir, c.Path, c.Regex)
+    case TypeGitConfig:
+        err = checkGitConfig(t.Dir, c.Key, c.Value)
+    case TypeDirExists:
+        err = checkDirExists(t.Dir, c.Path)
+    case TypeTool:
+        res.Stdout, err = checkTool(ctx, c.Tool, c.Version)
+    case TypeGitHook:
+        err = checkGitHook(t.Dir, c.Hook, c.Commands)
+    case TypeDSAccount:
+        err = checkDSAccount(t.Dir, c.accounts)
+    default:
+        err = fmt.Errorf("unknown check type %q", c.Type)
+    }
+    if ctx.Err() == context.DeadlineExceeded {
+        res.TimedOut = true
+        err = fmt.Errorf("timed out after %s", timeout)
+    }
+
+    res.Passed = err == nil
+    if err != nil { res.Error = err.Error() }
+    res.DurationMs = time.Since(start).Milliseconds()
+    return res
 }
 
+// sevOrder ranks severities; unknown severities rank with low
+var sevOrder = map[Severity]int{SevCritical: 3, SevHigh: 2, SevMedium: 1, SevLow: 0}
+
 func SeverityFromString(s string) (Severity, error) {
     switch s {
     case string(SevCritical): return SevCritical, nil
@@ -104,4 +325,3 @@ func SeverityFromString(s string) (Severity, error) {
         return SevLow, fmt.Errorf("invalid severity: %s", s)
     }
 }
-
@@ -0,0 +1,267 @@
+package policy
+
+import (
+    "encoding/json"
+    "encoding/xml"
+    "fmt"
+    "io"
+    "strings"
+)
+
+// Report formats beyond plain JSON
+const (
+    FormatJSON     = "json"
+    FormatSARIF    = "sarif"
+    FormatJUnit    = "junit"
+    FormatMarkdown = "markdown"
+)
+
+// ReportFormats lists the accepted report formats
+var ReportFormats = []string{FormatJSON, FormatSARIF, FormatJUnit, FormatMarkdown}
+
+// ContentType returns the MIME type of a report format
+func ContentType(format string) string {
+    switch format {
+    case FormatSARIF:
+        return "application/sarif+json"
+    case FormatJUnit:
+        return "application/xml"
+    case FormatMarkdown:
+        return "text/markdown; charset=utf-8"
+    }
+    return "application/json"
+}
+
+// FormatFromAccept picks a report format from an HTTP Accept header,
+// returning "" when no specific format is requested
+func FormatFromAccept(accept string) string {
+    for _, part := range strings.Split(accept, ",") {
+        mime, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+        switch strings.ToLower(mime) {
+        case "application/sarif+json":
+            return FormatSARIF
+        case "application/junit+xml", "application/xml", "text/xml":
+            return FormatJUnit
+        case "text/markdown":
+            return FormatMarkdown
+        case "application/json":
+            return FormatJSON
+        }
+    }
+    return ""
+}
+
+// Write renders the report in the given format
+func (r *Report) Write(w io.Writer, format string) error {
+    switch format {
+    case FormatSARIF:
+        return r.writeSARIF(w)
+    case FormatJUnit:
+        return r.writeJUnit(w)
+    case FormatMarkdown:
+        return r.writeMarkdown(w)
+    case FormatJSON, "":
+        enc := json.NewEncoder(w)
+        enc.SetIndent("", "  ")
+        return enc.Encode(r)
+    }
+    return fmt.Errorf("unknown report format %q (use one of %s)", format, strings.Join(ReportFormats, ", "))
+}
+
+// sarifLevel maps a check severity to a SARIF level
+func sarifLevel(sev Severity) string {
+    switch sev {
+    case SevCritical, SevHigh:
+        return "error"
+    case SevMedium:
+        return "warning"
+    }
+    return "note"
+}
+
+func (r *Report) writeSARIF(w io.Writer) error {
+    type message struct {
+        Text string `json:"text"`
+    }
+    type rule struct {
+        ID                   string            `json:"id"`
+        ShortDescription     message           `json:"shortDescription"`
+        DefaultConfiguration map[string]string `json:"defaultConfiguration"`
+    }
+    type location struct {
+        PhysicalLocation struct {
+            ArtifactLocation struct {
+                URI string `json:"uri"`
+            } `json:"artifactLocation"`
+        } `json:"physicalLocation"`
+    }
+    type result struct {
+        RuleID     string                 `json:"ruleId"`
+        Level      string                 `json:"level"`
+        Message    message                `json:"message"`
+        Locations  []location             `json:"locations,omitempty"`
+        Suppressions []map[string]string  `json:"suppressions,omitempty"`
+        Properties map[string]interface{} `json:"properties,omitempty"`
+    }
+
+    var rules []rule
+    seen := map[string]bool{}
+    results := []result{}
+    for _, c := range r.Results {
+        if !seen[c.Name] {
+            seen[c.Name] = true
+            desc := c.Description
+            if desc == "" { desc = c.Name }
+            rules = append(rules, rule{ID: c.Name, ShortDescription: message{desc}, DefaultConfiguration: map[string]string{"level": sarifLevel(c.Severity)}})
+        }
+        if c.Status == StatusPassed || c.Status == StatusSkipped { continue }
+
+        level := sarifLevel(c.Severity)
+        if c.Status == StatusWarning { level = "warning" }
+        text := c.Error
+        if text == "" { text = c.Name + " failed" }
+        if c.Repo != "" { text = c.Repo + ": " + text }
+        res := result{
+            RuleID:     c.Name,
+            Level:      level,
+            Message:    message{text},
+            Properties: map[string]interface{}{"severity": c.Severity},
+        }
+        if c.Repo != "" { res.Properties["repo"] = c.Repo }
+        if c.Waiver != nil {
+            res.Suppressions = []map[string]string{{"kind": "external", "justification": c.Waiver.Reason + " (until " + c.Waiver.Expires + ")"}}
+        }
+        if r.File != "" {
+            var loc location
+            loc.PhysicalLocation.ArtifactLocation.URI = r.File
+            res.Locations = []location{loc}
+        }
+        results = append(results, res)
+    }
+
+    doc := map[string]interface{}{
+        "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+        "version": "2.1.0",
+        "runs": []interface{}{map[string]interface{}{
+            "tool": map[string]interface{}{"driver": map[string]interface{}{
+                "name":           "ds",
+                "informationUri": "https://github.com/verlyn13/ds-go",
+                "rules":          rules,
+            }},
+            "results": results,
+        }},
+    }
+    enc := json.NewEncoder(w)
+    enc.SetIndent("", "  ")
+    return enc.Encode(doc)
+}
+
+func (r *Report) writeJUnit(w io.Writer) error {
+    type failure struct {
+        Message string `xml:"message,attr"`
+        Type    string `xml:"type,attr"`
+        Text    string `xml:",chardata"`
+    }
+    type skipped struct {
+        Message string `xml:"message,attr,omitempty"`
+    }
+    type testcase struct {
+        Name      string   `xml:"name,attr"`
+        Classname string   `xml:"classname,attr"`
+        Time      string   `xml:"time,attr"`
+        Failure   *failure `xml:"failure,omitempty"`
+        Skipped   *skipped `xml:"skipped,omitempty"`
+        SystemOut string   `xml:"system-out,omitempty"`
+        SystemErr string   `xml:"system-err,omitempty"`
+    }
+    type testsuite struct {
+        Name      string     `xml:"name,attr"`
+        Tests     int        `xml:"tests,attr"`
+        Failures  int        `xml:"failures,attr"`
+        Skipped   int        `xml:"skipped,attr"`
+        Testcases []testcase `xml:"testcase"`
+    }
+    type testsuites struct {
+        XMLName  xml.Name    `xml:"testsuites"`
+        Name     string      `xml:"name,attr"`
+        Tests    int         `xml:"tests,attr"`
+        Failures int         `xml:"failures,attr"`
+        Suites   []testsuite `xml:"testsuite"`
+    }
+
+    doc := testsuites{Name: "ds policy", Tests: len(r.Results)}
+    index := map[string]int{}
+    for _, c := range r.Results {
+        suite := c.Repo
+        if suite == "" { suite = "policy" }
+        i, ok := index[suite]
+        if !ok {
+            i = len(doc.Suites)
+            index[suite] = i
+            doc.Suites = append(doc.Suites, testsuite{Name: suite})
+        }
+        tc := testcase{
+            Name:      c.Name,
+            Classname: suite + "." + string(c.Severity),
+            Time:      fmt.Sprintf("%.3f", float64(c.DurationMs)/1000),
+            SystemOut: c.Stdout,
+            SystemErr: c.Stderr,
+        }
+        switch c.Status {
+        case StatusFailed:
+            tc.Failure = &failure{Message: c.Error, Type: string(c.Severity), Text: c.Description}
+            doc.Suites[i].Failures++
+            doc.Failures++
+        case StatusWarning:
+            tc.SystemOut = strings.TrimSpace("warning: " + c.Error + "\n" + c.Stdout)
+        case StatusWaived:
+            tc.SystemOut = strings.TrimSpace(fmt.Sprintf("waived until %s: %s\n%s\n%s", c.Waiver.Expires, c.Waiver.Reason, c.Error, c.Stdout))
+        case StatusSkipped:
+            tc.Skipped = &skipped{Message: "skip_if condition met"}
+            doc.Suites[i].Skipped++
+        }
+        doc.Suites[i].Tests++
+        doc.Suites[i].Testcases = append(doc.Suites[i].Testcases, tc)
+    }
+
+    if _, err := io.WriteString(w, xml.Header); err != nil { return err }
+    enc := xml.NewEncoder(w)
+    enc.Indent("", "  ")
+    if err := enc.Encode(doc); err != nil { return err }
+    _, err := io.WriteString(w, "\n")
+    return err
+}
+
+func (r *Report) writeMarkdown(w io.Writer) error {
+    escape := func(s string) string {
+        return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", " ")
+    }
+    icons := map[string]string{StatusPassed: "✅", StatusFailed: "❌", StatusWarning: "⚠️", StatusSkipped: "⏭️", StatusWaived: "🛡️"}
+
+    var b strings.Builder
+    b.WriteString("## Policy check\n\n")
+    fmt.Fprintf(&b, "**%d checks:** %d passed, %d failed, %d warnings, %d waived, %d skipped\n\n",
+        r.Summary.Total, r.Summary.Passed, r.Summary.Failed, r.Summary.Warnings, r.Summary.Waived, r.Summary.Skipped)
+
+    multi := false
+    for _, c := range r.Results {
+        if c.Repo != "" { multi = true; break }
+    }
+    if multi {
+        b.WriteString("| | Repository | Check | Severity | Details |\n| --- | --- | --- | --- | --- |\n")
+    } else {
+        b.WriteString("| | Check | Severity | Details |\n| --- | --- | --- | --- |\n")
+    }
+    for _, c := range r.Results {
+        details := c.Error
+        if details == "" { details = c.Description }
+        if c.Waiver != nil { details += fmt.Sprintf(" — waived until %s: %s", c.Waiver.Expires, c.Waiver.Reason) }
+        if multi {
+            fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", icons[c.Status], escape(c.Repo), escape(c.Name), c.Severity, escape(details))
+        } else {
+            fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", icons[c.Status], escape(c.Name), c.Severity, escape(details))
+        }
+    }
+    _, err := io.WriteString(w, b.String())
+    return err
+}
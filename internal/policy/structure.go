@@ -0,0 +1,130 @@
+package policy
+
+import (
+    "context"
+    "fmt"
+    "net/url"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strings"
+
+    "github.com/verlyn13/ds-go/internal/git"
+    "github.com/verlyn13/ds-go/internal/scan"
+)
+
+func checkDirExists(dir, path string) error {
+    if path == "" { return fmt.Errorf("dir_exists check has no path") }
+    info, err := os.Stat(resolve(dir, path))
+    if err != nil { return fmt.Errorf("missing %s", path) }
+    if !info.IsDir() { return fmt.Errorf("%s is not a directory", path) }
+    return nil
+}
+
+// checkTool verifies a tool is on PATH and, unless version is empty or
+// "latest", that its version output mentions version
+func checkTool(ctx context.Context, tool, version string) (string, error) {
+    if tool == "" { return "", fmt.Errorf("tool check has no tool") }
+    bin, err := exec.LookPath(tool)
+    if err != nil { return "", fmt.Errorf("%s not found in PATH", tool) }
+    if version == "" || version == "latest" { return "", nil }
+
+    var out []byte
+    for _, arg := range []string{"--version", "version"} {
+        out, err = exec.CommandContext(ctx, bin, arg).CombinedOutput()
+        if err == nil { break }
+    }
+    got := strings.TrimSpace(firstLine(string(out)))
+    if err != nil { return got, fmt.Errorf("%s: cannot determine version", tool) }
+    if !strings.Contains(got, version) {
+        return got, fmt.Errorf("%s version %q does not match %s", tool, got, version)
+    }
+    return got, nil
+}
+
+// checkGitHook verifies a hook is installed, executable and runs every
+// declared command
+func checkGitHook(dir, hook string, commands []string) error {
+    if hook == "" { return fmt.Errorf("git_hook check has no hook") }
+    hooksDir, err := git.New().HooksDir(resolve(dir, "."))
+    if err != nil { return fmt.Errorf("not a git repository") }
+    path := filepath.Join(hooksDir, hook)
+    info, err := os.Stat(path)
+    if err != nil { return fmt.Errorf("%s hook not installed", hook) }
+    if info.Mode()&0111 == 0 { return fmt.Errorf("%s hook is not executable", hook) }
+    data, err := os.ReadFile(path)
+    if err != nil { return err }
+
+    var missing []string
+    for _, c := range commands {
+        if !strings.Contains(string(data), c) { missing = append(missing, c) }
+    }
+    if len(missing) > 0 {
+        return fmt.Errorf("%s hook does not run: %s", hook, strings.Join(missing, "; "))
+    }
+    return nil
+}
+
+// checkDSAccount verifies the origin remote belongs to a declared account,
+// uses that account's SSH host, and lives under its directory
+func checkDSAccount(dir string, accounts map[string]AccountRequirement) error {
+    root, err := filepath.Abs(resolve(dir, "."))
+    if err != nil { return err }
+    remotes, err := git.New().Remotes(root)
+    if err != nil || remotes["origin"] == "" { return fmt.Errorf("no origin remote") }
+    origin := remotes["origin"]
+
+    owner, _, ok := scan.ParseRepoURL(origin)
+    if !ok { return fmt.Errorf("cannot parse origin %s", origin) }
+    acct, ok := lookupAccount(accounts, owner)
+    if !ok { return fmt.Errorf("account %s is not declared in ds_accounts", owner) }
+
+    var problems []string
+    if acct.SSHHost != "" {
+        if host := remoteHost(origin); !strings.EqualFold(host, acct.SSHHost) {
+            problems = append(problems, fmt.Sprintf("origin uses host %s, want %s", host, acct.SSHHost))
+        }
+    }
+    if acct.Directory != "" {
+        want, err := filepath.Abs(resolve("", acct.Directory))
+        if err == nil && root != want && !strings.HasPrefix(root, want+string(filepath.Separator)) {
+            problems = append(problems, fmt.Sprintf("located at %s, want under %s", root, acct.Directory))
+        }
+    }
+    if len(problems) > 0 { return fmt.Errorf("%s", strings.Join(problems, "; ")) }
+    return nil
+}
+
+func lookupAccount(accounts map[string]AccountRequirement, owner string) (AccountRequirement, bool) {
+    for name, acct := range accounts {
+        if strings.EqualFold(name, owner) { return acct, true }
+    }
+    return AccountRequirement{}, false
+}
+
+var scpHostPattern = regexp.MustCompile(`^(?:[\w.-]+@)?([\w.-]+):`)
+
+// remoteHost returns the host (or SSH alias) of a remote URL
+func remoteHost(remote string) string {
+    if u, err := url.Parse(remote); err == nil && u.Host != "" {
+        return u.Hostname()
+    }
+    if m := scpHostPattern.FindStringSubmatch(remote); m != nil {
+        return m[1]
+    }
+    return ""
+}
+
+func firstLine(s string) string {
+    line, _, _ := strings.Cut(s, "\n")
+    return line
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+    keys := make([]string, 0, len(m))
+    for k := range m { keys = append(keys, k) }
+    sort.Strings(keys)
+    return keys
+}
@@ -2,6 +2,8 @@ package runner
 
 import (
     "context"
+    "io"
+    "os"
     "os/exec"
     "time"
 
@@ -16,28 +18,46 @@ type ExecResult struct {
     DurationMs int64 `json:"duration_ms"`
 }
 
+// Command is a shell command for Run
+type Command struct {
+    Run     string
+    Dir     string
+    Env     []string  // KEY=value pairs added to ds's environment
+    Stdin   io.Reader
+    Stdout  io.Writer // nil discards output
+    Stderr  io.Writer
+    Timeout time.Duration // 0 for none
+}
+
+// Run runs c with /bin/sh -c, reporting it for repo
+func Run(ctx context.Context, repo string, c Command) ExecResult {
+    start := time.Now()
+    res := ExecResult{Repo: repo, Path: c.Dir}
+    if c.Timeout > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+        defer cancel()
+    }
+    cmd := exec.CommandContext(ctx, "/bin/sh", "-c", c.Run)
+    cmd.Dir = c.Dir
+    if len(c.Env) > 0 { cmd.Env = append(os.Environ(), c.Env...) }
+    cmd.Stdin, cmd.Stdout, cmd.Stderr = c.Stdin, c.Stdout, c.Stderr
+    // Children of the shell may hold the output pipes open after it is killed
+    cmd.WaitDelay = time.Second
+    if err := cmd.Run(); err != nil {
+        res.Success = false
+        res.Error = err.Error()
+    } else {
+        res.Success = true
+    }
+    res.DurationMs = time.Since(start).Milliseconds()
+    return res
+}
+
 func ExecInRepos(repos []scan.Repository, command string, timeout time.Duration) []ExecResult {
     results := make([]ExecResult, 0, len(repos))
     for _, r := range repos {
-        start := time.Now()
-        res := ExecResult{Repo: r.Name, Path: r.Path}
-        ctx := context.Background()
-        if timeout > 0 {
-            var cancel context.CancelFunc
-            ctx, cancel = context.WithTimeout(ctx, timeout)
-            defer cancel()
-        }
-        cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
-        cmd.Dir = r.Path
-        if err := cmd.Run(); err != nil {
-            res.Success = false
-            res.Error = err.Error()
-        } else {
-            res.Success = true
-        }
-        res.DurationMs = time.Since(start).Milliseconds()
-        results = append(results, res)
+        results = append(results, Run(context.Background(), r.Name, Command{Run: command, Dir: r.Path, Timeout: timeout}))
     }
     return results
 }
-
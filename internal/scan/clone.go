@@ -0,0 +1,179 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/verlyn13/ds-go/internal/config"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+)
+
+// CloneResult represents the result of cloning one repository
+type CloneResult struct {
+	Input      string `json:"input"`
+	Owner      string `json:"owner,omitempty"`
+	Repo       string `json:"repo,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Target     string `json:"target,omitempty"`
+	Success    bool   `json:"success"`
+	Skipped    bool   `json:"skipped"`
+	ExistingAt string `json:"existing_at,omitempty"` // Where the repo already lives when skipped
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Cloner handles concurrent cloning of repositories
+type Cloner struct {
+	config      *config.Config
+	workerCount int
+}
+
+// NewCloner creates a new Cloner
+func NewCloner(cfg *config.Config, workerCount int) *Cloner {
+	if workerCount <= 0 {
+		workerCount = 10
+	}
+	return &Cloner{
+		config:      cfg,
+		workerCount: workerCount,
+	}
+}
+
+// CloneAll clones every URL concurrently. Repositories whose owner/repo is
+// already present in existing (anywhere on disk) are skipped, as are
+// duplicate inputs. targetPath only applies when a single URL is given.
+func (c *Cloner) CloneAll(urls []string, existing []Repository, opts CloneOptions, targetPath string, showProgress bool) []CloneResult {
+	results := make([]CloneResult, len(urls))
+
+	// Index existing repositories by lowercase owner/repo
+	known := make(map[string]string, len(existing))
+	for _, r := range existing {
+		if owner, name, ok := ParseRepoURL(r.RemoteURL); ok {
+			known[strings.ToLower(owner+"/"+name)] = r.Path
+		}
+	}
+
+	var specs []int
+	seen := make(map[string]bool, len(urls))
+	resolved := make([]*CloneSpec, len(urls))
+	for i, u := range urls {
+		res := CloneResult{Input: u}
+		target := ""
+		if len(urls) == 1 {
+			target = targetPath
+		}
+		spec, err := ResolveClone(u, c.config, target)
+		if err != nil {
+			res.Error = err.Error()
+			results[i] = res
+			continue
+		}
+		spec.CloneOptions = opts
+		res.Owner, res.Repo, res.URL, res.Target = spec.Owner, spec.Repo, spec.URL, spec.Target
+
+		key := strings.ToLower(spec.Owner + "/" + spec.Repo)
+		switch {
+		case known[key] != "":
+			res.Skipped, res.Success, res.ExistingAt = true, true, known[key]
+		case seen[key]:
+			res.Skipped, res.Success = true, true
+		case pathExists(spec.Target):
+			res.Skipped, res.Success, res.ExistingAt = true, true, spec.Target
+		default:
+			specs = append(specs, i)
+			resolved[i] = spec
+		}
+		seen[key] = true
+		results[i] = res
+	}
+
+	if len(specs) == 0 {
+		return results
+	}
+
+	var completed atomic.Int32
+	var succeeded atomic.Int32
+
+	if showProgress {
+		fmt.Printf("\nCloning %d repositories...\n", len(specs))
+	}
+
+	g, ctx := errgroup.WithContext(context.Background())
+	sem := semaphore.NewWeighted(int64(c.workerCount))
+
+	for _, idx := range specs {
+		spec := resolved[idx]
+
+		g.Go(func() error {
+			if err := sem.Acquire(ctx, 1); err != nil {
+				return nil // Context cancelled
+			}
+			defer sem.Release(1)
+
+			start := time.Now()
+			err := spec.Run(c.config, nil, nil)
+			duration := time.Since(start)
+
+			res := results[idx]
+			res.Success = err == nil
+			if err != nil {
+				res.Error = err.Error()
+			}
+			res.DurationMs = duration.Milliseconds()
+			results[idx] = res
+
+			current := completed.Add(1)
+			if err == nil {
+				succeeded.Add(1)
+			}
+
+			if showProgress {
+				status := "✓"
+				if err != nil {
+					status = "✗"
+				}
+				fmt.Printf("[%d/%d] %s %s/%s (%.1fs)\n",
+					current, len(specs), status, spec.Owner, spec.Repo, duration.Seconds())
+			}
+
+			return nil
+		})
+	}
+
+	g.Wait()
+
+	if showProgress {
+		fmt.Printf("\nCompleted: %d/%d successful\n", succeeded.Load(), len(specs))
+	}
+
+	return results
+}
+
+// ReadURLList reads repository URLs one per line, ignoring blank lines and
+// # comments
+func ReadURLList(r io.Reader) ([]string, error) {
+	var urls []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	return urls, sc.Err()
+}
+
+// pathExists reports whether a non-empty directory exists at path
+func pathExists(path string) bool {
+	entries, err := os.ReadDir(filepath.Clean(path))
+	return err == nil && len(entries) > 0
+}
@@ -1,14 +1,17 @@
 package scan
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -91,6 +94,7 @@ func (s *Scanner) Scan(searchPath string) ([]Repository, error) {
 			
 			// Enhance with organization info
 			s.enhanceRepoInfo(gitRepo)
+			gitRepo.Tags = s.config.TagsFor(gitRepo.Name)
 			
 			// Add fetch time from cache
 			s.mu.RLock()
@@ -296,122 +300,178 @@ func (s *Scanner) enhanceRepoInfo(repo *git.Repository) {
 	repo.IsOrg = false
 }
 
-// CloneRepo clones a repository with the appropriate SSH configuration
-func CloneRepo(repoURL string, cfg *config.Config, targetPath string) error {
-	// Parse the repository URL to extract owner and repo name
-	// Support formats: 
+// CloneOptions holds optional git clone flags
+type CloneOptions struct {
+	Depth             int    `json:"depth,omitempty"`
+	Branch            string `json:"branch,omitempty"`
+	Filter            string `json:"filter,omitempty"` // e.g. blob:none for a partial clone
+	RecurseSubmodules bool   `json:"recurse_submodules,omitempty"`
+}
+
+// args returns the git clone flags for the options
+func (o CloneOptions) args() []string {
+	var args []string
+	if o.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(o.Depth))
+	}
+	if o.Branch != "" {
+		args = append(args, "--branch", o.Branch)
+	}
+	if o.Filter != "" {
+		args = append(args, "--filter="+o.Filter)
+	}
+	if o.RecurseSubmodules {
+		args = append(args, "--recurse-submodules")
+	}
+	return args
+}
+
+// CloneSpec describes a resolved clone: where it comes from and where it goes
+type CloneSpec struct {
+	Owner   string `json:"owner"`
+	Repo    string `json:"repo"`
+	SSHHost string `json:"ssh_host"`
+	URL     string `json:"url"`
+	Target  string `json:"target"`
+	CloneOptions
+}
+
+var (
+	githubURLPattern = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+)`)
+	scpURLPattern    = regexp.MustCompile(`^(?:[\w.-]+@)?([\w.-]+):([^/]+)/([^/]+)$`)
+)
+
+// ParseRepoURL extracts the owner and repository name from a remote URL
+func ParseRepoURL(repoURL string) (owner, repoName string, ok bool) {
+	// Support formats:
 	// - https://github.com/owner/repo
 	// - github.com/owner/repo
 	// - owner/repo
 	// - git@github.com:owner/repo.git
-	
-	var owner, repoName string
-	
-	// Remove .git suffix if present
-	repoURL = strings.TrimSuffix(repoURL, ".git")
-	
-	// Parse different URL formats
+	// - git@custom-ssh-host:owner/repo.git
+	repoURL = strings.TrimSuffix(strings.TrimSpace(repoURL), ".git")
+
 	if strings.Contains(repoURL, "github.com") {
 		// Handle full URLs
-		re := regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+)`)
-		matches := re.FindStringSubmatch(repoURL)
-		if len(matches) == 3 {
-			owner = matches[1]
-			repoName = matches[2]
+		if matches := githubURLPattern.FindStringSubmatch(repoURL); len(matches) == 3 {
+			owner, repoName = matches[1], matches[2]
 		}
+	} else if matches := scpURLPattern.FindStringSubmatch(repoURL); len(matches) == 4 {
+		// Handle SSH URLs using a host alias from ~/.ssh/config
+		owner, repoName = matches[2], matches[3]
 	} else if strings.Contains(repoURL, "/") {
 		// Handle owner/repo format
 		parts := strings.Split(repoURL, "/")
 		if len(parts) == 2 {
-			owner = parts[0]
-			repoName = parts[1]
+			owner, repoName = parts[0], parts[1]
 		}
 	}
-	
-	if owner == "" || repoName == "" {
-		return fmt.Errorf("invalid repository URL format: %s", repoURL)
-	}
-	
-	// Determine SSH host based on owner
-	sshHost := "github.com" // default
+
+	return owner, repoName, owner != "" && repoName != ""
+}
+
+// SSHHostFor returns the SSH host alias configured for an owner
+func SSHHostFor(owner string, cfg *config.Config) string {
 	if account, ok := cfg.Accounts[owner]; ok {
-		sshHost = account.SSHHost
-	} else {
-		// Check if it's an organization
-		for org, host := range cfg.Orgs {
-			if org == owner {
-				sshHost = host
-				break
-			}
-		}
+		return account.SSHHost
 	}
-	
-	// Build the SSH clone URL
-	cloneURL := fmt.Sprintf("git@%s:%s/%s.git", sshHost, owner, repoName)
-	
-	// Determine target directory
+	// Check if it's an organization
+	if host, ok := cfg.Orgs[owner]; ok {
+		return host
+	}
+	return "github.com"
+}
+
+// ResolveClone maps a repository URL to its SSH clone URL and target path
+func ResolveClone(repoURL string, cfg *config.Config, targetPath string) (*CloneSpec, error) {
+	owner, repoName, ok := ParseRepoURL(repoURL)
+	if !ok {
+		return nil, fmt.Errorf("invalid repository URL format: %s", repoURL)
+	}
+
+	sshHost := SSHHostFor(owner, cfg)
+
+	// Determine target directory, organized by account/owner name
 	if targetPath == "" {
-		// Organize by account/owner name
-		// Use the account name if it exists, otherwise use the owner name
-		folderName := owner
-		
-		// Check if this is a known account
-		if _, isAccount := cfg.Accounts[owner]; isAccount {
-			folderName = owner
-		} else {
-			// Check if it's an organization we track
-			for org := range cfg.Orgs {
-				if org == owner {
-					folderName = owner
-					break
-				}
-			}
-		}
-		
-		targetPath = filepath.Join(cfg.BaseDir, folderName, repoName)
+		targetPath = filepath.Join(cfg.BaseDir, owner, repoName)
 	}
-	
+
+	return &CloneSpec{
+		Owner:   owner,
+		Repo:    repoName,
+		SSHHost: sshHost,
+		URL:     fmt.Sprintf("git@%s:%s/%s.git", sshHost, owner, repoName),
+		Target:  targetPath,
+	}, nil
+}
+
+// Run executes git clone for the spec and applies per-account git config.
+// Output is streamed to stdout/stderr when given; otherwise stderr is
+// captured and included in the returned error.
+func (c *CloneSpec) Run(cfg *config.Config, stdout, stderr io.Writer) error {
 	// Ensure parent directory exists
-	parentDir := filepath.Dir(targetPath)
-	if err := os.MkdirAll(parentDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(c.Target), 0755); err != nil {
 		return fmt.Errorf("creating parent directory: %w", err)
 	}
-	
-	// Execute git clone
-	fmt.Printf("Cloning %s/%s to %s\n", owner, repoName, targetPath)
-	fmt.Printf("Using SSH host: %s\n", sshHost)
-	
-	cmd := exec.Command("git", "clone", cloneURL, targetPath)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	
+
+	args := append([]string{"clone"}, c.CloneOptions.args()...)
+	args = append(args, c.URL, c.Target)
+
+	var captured bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if stderr == nil {
+		cmd.Stderr = &captured
+	}
+
 	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(captured.String()); msg != "" {
+			return fmt.Errorf("git clone failed: %w: %s", err, msg)
+		}
 		return fmt.Errorf("git clone failed: %w", err)
 	}
-	
+
 	// Set up git config for the repository if we have email configured
-	if account, ok := cfg.Accounts[owner]; ok && account.Email != "" {
-		// Set user email for this repository
+	if account, ok := cfg.Accounts[c.Owner]; ok && account.Email != "" {
 		emailCmd := exec.Command("git", "config", "user.email", account.Email)
-		emailCmd.Dir = targetPath
-		if err := emailCmd.Run(); err != nil {
-			fmt.Printf("Warning: couldn't set email config: %v\n", err)
+		emailCmd.Dir = c.Target
+		if err := emailCmd.Run(); err != nil && stdout != nil {
+			fmt.Fprintf(stdout, "Warning: couldn't set email config: %v\n", err)
 		}
 	}
-	
-	fmt.Printf("✓ Successfully cloned %s/%s\n", owner, repoName)
+
+	return nil
+}
+
+// CloneRepo clones a repository with the appropriate SSH configuration
+func CloneRepo(repoURL string, cfg *config.Config, targetPath string) error {
+	spec, err := ResolveClone(repoURL, cfg, targetPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Cloning %s/%s to %s\n", spec.Owner, spec.Repo, spec.Target)
+	fmt.Printf("Using SSH host: %s\n", spec.SSHHost)
+
+	if err := spec.Run(cfg, os.Stdout, os.Stderr); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Successfully cloned %s/%s\n", spec.Owner, spec.Repo)
 	return nil
 }
 
-// OrganizeRepos reorganizes repositories into proper account/org folder structure
-func OrganizeRepos(repos []Repository, cfg *config.Config, dryRun, force bool) error {
+// OrganizeRepos reorganizes repositories into proper account/org folder
+// structure, prompting unless force is set. It returns the attempted moves.
+func OrganizeRepos(repos []Repository, cfg *config.Config, dryRun, force bool) ([]OrganizeResult, error) {
     toMove := OrganizePlan(repos, cfg)
 	
     // toMove already computed
 	
 	if len(toMove) == 0 {
 		fmt.Println("✓ All repositories are already organized")
-		return nil
+		return nil, nil
 	}
 	
 	// Display what will be moved
@@ -430,7 +490,7 @@ func OrganizeRepos(repos []Repository, cfg *config.Config, dryRun, force bool) e
 	
     if dryRun {
         fmt.Println("\n[DRY RUN] No files were moved. Remove --dry-run to apply changes.")
-        return nil
+        return nil, nil
     }
 	
 	// Confirm before moving
@@ -440,17 +500,21 @@ func OrganizeRepos(repos []Repository, cfg *config.Config, dryRun, force bool) e
 		fmt.Scanln(&response)
 		if response != "y" && response != "Y" {
 			fmt.Println("Aborted")
-			return nil
+			return nil, nil
 		}
 	}
 	
 	// Move repositories
 	var moved, failed int
+	var results []OrganizeResult
 	for _, m := range toMove {
+		res := OrganizeResult{Name: m.repo.Name, OldPath: m.oldPath, NewPath: m.newPath}
 		// Create target directory if needed
 		targetDir := filepath.Dir(m.newPath)
 		if err := os.MkdirAll(targetDir, 0755); err != nil {
 			fmt.Printf("  ✗ Failed to create directory %s: %v\n", targetDir, err)
+			res.Error = err.Error()
+			results = append(results, res)
 			failed++
 			continue
 		}
@@ -458,6 +522,8 @@ func OrganizeRepos(repos []Repository, cfg *config.Config, dryRun, force bool) e
 		// Check if destination exists
 		if _, err := os.Stat(m.newPath); err == nil && !force {
 			fmt.Printf("  ✗ Destination exists: %s (use --force to overwrite)\n", m.newPath)
+			res.Error = fmt.Sprintf("destination exists: %s", m.newPath)
+			results = append(results, res)
 			failed++
 			continue
 		}
@@ -465,12 +531,16 @@ func OrganizeRepos(repos []Repository, cfg *config.Config, dryRun, force bool) e
 		// Move the repository
 		if err := os.Rename(m.oldPath, m.newPath); err != nil {
 			fmt.Printf("  ✗ Failed to move %s: %v\n", m.repo.Name, err)
+			res.Error = err.Error()
+			results = append(results, res)
 			failed++
 			continue
 		}
 		
 		relPath, _ := filepath.Rel(cfg.BaseDir, m.newPath)
 		fmt.Printf("  ✓ Moved to %s\n", relPath)
+		res.Applied = true
+		results = append(results, res)
 		moved++
 	}
 	
@@ -480,7 +550,7 @@ func OrganizeRepos(repos []Repository, cfg *config.Config, dryRun, force bool) e
 		fmt.Println("\nRun 'ds scan' to update the repository index")
 	}
 	
-	return nil
+	return results, nil
 }
 
 // MovePlan represents a proposed move of a repository
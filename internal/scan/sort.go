@@ -0,0 +1,56 @@
+package scan
+
+import (
+	"sort"
+	"strings"
+)
+
+// SortKeys lists the accepted sort keys
+var SortKeys = []string{"name", "account", "folder", "branch", "last_commit", "behind", "ahead", "dirty"}
+
+// repoSorts orders repositories for each sort key. Count and time keys put
+// the largest or newest first.
+var repoSorts = map[string]func(a, b Repository) bool{
+	"name":    func(a, b Repository) bool { return a.Name < b.Name },
+	"account": func(a, b Repository) bool { return a.Account < b.Account },
+	"folder":  func(a, b Repository) bool { return a.FolderName < b.FolderName },
+	"branch":  func(a, b Repository) bool { return a.Branch < b.Branch },
+	"last_commit": func(a, b Repository) bool {
+		if a.LastCommitAt == nil || b.LastCommitAt == nil {
+			return a.LastCommitAt != nil
+		}
+		return a.LastCommitAt.After(*b.LastCommitAt)
+	},
+	"behind": func(a, b Repository) bool { return a.Behind > b.Behind },
+	"ahead":  func(a, b Repository) bool { return a.Ahead > b.Ahead },
+	"dirty":  func(a, b Repository) bool { return a.Uncommitted > b.Uncommitted },
+}
+
+// ValidSortKey reports whether key, with an optional leading -, is a sort key
+func ValidSortKey(key string) bool {
+	_, ok := repoSorts[strings.TrimPrefix(key, "-")]
+	return ok
+}
+
+// SortRepos sorts repositories in place by key, breaking ties by name.
+// A leading - reverses the order.
+func SortRepos(repos []Repository, key string) {
+	reverse := strings.HasPrefix(key, "-")
+	less, ok := repoSorts[strings.TrimPrefix(key, "-")]
+	if !ok {
+		less = repoSorts["name"]
+	}
+	sort.SliceStable(repos, func(i, j int) bool {
+		a, b := repos[i], repos[j]
+		if reverse {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return repos[i].Name < repos[j].Name
+	})
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "sync"
+    "time"
+
+    "github.com/adrg/xdg"
+)
+
+// AuditRecord is one line of the exec audit log
+type AuditRecord struct {
+    Time       time.Time         `json:"time"`
+    Remote     string            `json:"remote"`
+    Token      string            `json:"token,omitempty"` // Name of the authenticating token
+    Command    string            `json:"command,omitempty"` // Template name; empty for raw commands
+    Raw        bool              `json:"raw"`
+    Rendered   string            `json:"rendered,omitempty"`
+    Params     map[string]string `json:"params,omitempty"`
+    Allowed    bool              `json:"allowed"`
+    Reason     string            `json:"reason,omitempty"` // Why the call was denied
+    Repos      int               `json:"repos"`
+    OK         int               `json:"ok"`
+    Failed     int               `json:"failed"`
+    DurationMs int64             `json:"duration_ms"`
+}
+
+// DefaultAuditLog returns the default exec audit log path using XDG
+func DefaultAuditLog() string {
+    return filepath.Join(xdg.StateHome, "ds", "audit.log")
+}
+
+// auditLog appends JSON lines to a file; a nil *auditLog discards records
+type auditLog struct {
+    mu   sync.Mutex
+    path string
+}
+
+func (a *auditLog) write(rec AuditRecord) error {
+    if a == nil || a.path == "" { return nil }
+    line, err := json.Marshal(rec)
+    if err != nil { return err }
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    if err := os.MkdirAll(filepath.Dir(a.path), 0700); err != nil { return err }
+    f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+    if err != nil { return err }
+    defer f.Close()
+    _, err = f.Write(append(line, '\n'))
+    return err
+}
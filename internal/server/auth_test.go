@@ -0,0 +1,96 @@
+package server
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/verlyn13/ds-go/internal/auth"
+    "github.com/verlyn13/ds-go/internal/config"
+)
+
+// authServer serves a token file in a temp directory holding one read
+// token. It returns the token's secret, the file and a store opening it
+// separately, as `ds token` does.
+func authServer(t *testing.T) (srv *httptest.Server, cli *auth.Store, secret, path string) {
+    t.Helper()
+    dir := t.TempDir()
+    path = filepath.Join(dir, "tokens.yaml")
+    cli, err := auth.Open(path)
+    if err != nil { t.Fatalf("open tokens: %v", err) }
+    secret, _, err = cli.Create("reader", []string{auth.ScopeRead}, 0)
+    if err != nil { t.Fatalf("create token: %v", err) }
+    store, err := auth.Open(path)
+    if err != nil { t.Fatalf("open tokens: %v", err) }
+    cfg := &config.Config{BaseDir: dir, Accounts: map[string]config.AccountConfig{}}
+    srv = httptest.NewServer(New(cfg, 2).WithTokenStore(store).Handler())
+    t.Cleanup(srv.Close)
+    return srv, cli, secret, path
+}
+
+// call sends method path with secret as the bearer token ("" for none)
+func call(t *testing.T, srv *httptest.Server, method, path, secret string) *http.Response {
+    t.Helper()
+    req, err := http.NewRequest(method, srv.URL+path, nil)
+    if err != nil { t.Fatal(err) }
+    if secret != "" { req.Header.Set("Authorization", "Bearer "+secret) }
+    resp, err := http.DefaultClient.Do(req)
+    if err != nil { t.Fatalf("%s %s: %v", method, path, err) }
+    resp.Body.Close()
+    return resp
+}
+
+func TestRevokingLastTokenKeepsAuth(t *testing.T) {
+    srv, cli, secret, path := authServer(t)
+    if got := call(t, srv, http.MethodGet, "/v1/commands", secret).StatusCode; got != http.StatusOK {
+        t.Fatalf("valid token: got %d", got)
+    }
+    if err := cli.Revoke("reader"); err != nil { t.Fatalf("revoke: %v", err) }
+    for _, secret := range []string{"", secret} {
+        if got := call(t, srv, http.MethodGet, "/v1/commands", secret).StatusCode; got != http.StatusUnauthorized {
+            t.Fatalf("after revoking the last token (secret %q): got %d, want 401", secret, got)
+        }
+    }
+    if err := os.Remove(path); err != nil { t.Fatal(err) }
+    if got := call(t, srv, http.MethodGet, "/v1/commands", "").StatusCode; got != http.StatusUnauthorized {
+        t.Fatalf("after deleting the token file: got %d, want 401", got)
+    }
+}
+
+func TestScopedAuth(t *testing.T) {
+    srv, cli, reader, _ := authServer(t)
+    expired, _, err := cli.Create("expired", []string{auth.ScopeOrganize}, time.Nanosecond)
+    if err != nil { t.Fatalf("create token: %v", err) }
+    fetcher, _, err := cli.Create("fetcher", []string{auth.ScopeFetch}, 0)
+    if err != nil { t.Fatalf("create token: %v", err) }
+
+    tests := []struct {
+        name      string
+        method    string
+        path      string
+        secret    string
+        want      int
+        challenge string // Expected in WWW-Authenticate
+    }{
+        {"no token", http.MethodGet, "/v1/commands", "", http.StatusUnauthorized, "Bearer"},
+        {"bad secret", http.MethodGet, "/v1/commands", "ds_not-a-token", http.StatusUnauthorized, `error="invalid_token"`},
+        {"expired", http.MethodPost, "/v1/organize/apply?dry_run=true", expired, http.StatusUnauthorized, `error="invalid_token"`},
+        {"read token", http.MethodGet, "/v1/commands", reader, http.StatusOK, ""},
+        {"read token organizing", http.MethodPost, "/v1/organize/apply?dry_run=true", reader, http.StatusForbidden, `error="insufficient_scope", scope="organize"`},
+        {"read token running policy", http.MethodPost, "/v1/policy/check", reader, http.StatusForbidden, `error="insufficient_scope", scope="exec"`},
+        {"fetch token reading", http.MethodGet, "/v1/commands", fetcher, http.StatusForbidden, `error="insufficient_scope", scope="read"`},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            resp := call(t, srv, tt.method, tt.path, tt.secret)
+            if resp.StatusCode != tt.want { t.Fatalf("got %d, want %d", resp.StatusCode, tt.want) }
+            if got := resp.Header.Get("WWW-Authenticate"); !strings.Contains(got, tt.challenge) {
+                t.Fatalf("WWW-Authenticate %q, want it to contain %q", got, tt.challenge)
+            }
+        })
+    }
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "net/http"
+    "slices"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/verlyn13/ds-go/internal/scan"
+    "golang.org/x/sync/singleflight"
+)
+
+// DefaultCacheTTL is how long a scan is served before rescanning
+const DefaultCacheTTL = 10 * time.Second
+
+// maxCachedPaths bounds the number of distinct scan paths kept
+const maxCachedPaths = 16
+
+// snapshot is one scan result. ETag and Modified change only when the
+// repository state does, so unchanged rescans still produce 304s.
+type snapshot struct {
+    repos    []scan.Repository
+    scanned  time.Time
+    modified time.Time
+    etag     string
+}
+
+// Repos returns a copy of the snapshot's repositories that callers may
+// filter and sort
+func (s *snapshot) Repos() []scan.Repository { return slices.Clone(s.repos) }
+
+// repoCache shares one scanner across requests, serves scans younger than
+// ttl from memory and collapses concurrent scans of the same path
+type repoCache struct {
+    scanner *scan.Scanner
+    ttl     time.Duration
+    group   singleflight.Group
+    observe func([]scan.Repository) // Called with every new scan
+
+    mu      sync.Mutex
+    entries map[string]*snapshot
+}
+
+func newRepoCache(scanner *scan.Scanner, ttl time.Duration) *repoCache {
+    return &repoCache{scanner: scanner, ttl: ttl, entries: map[string]*snapshot{}}
+}
+
+// get returns the snapshot for path, rescanning when it is older than the
+// TTL or fresh is set
+func (c *repoCache) get(path string, fresh bool) (*snapshot, error) {
+    c.mu.Lock()
+    cur := c.entries[path]
+    valid := cur != nil && !fresh && time.Since(cur.scanned) < c.ttl
+    c.mu.Unlock()
+    if valid { return cur, nil }
+
+    v, err, _ := c.group.Do(path, func() (interface{}, error) {
+        repos, err := c.scanner.Scan(path)
+        if err != nil { return nil, err }
+        slices.SortFunc(repos, func(a, b scan.Repository) int { return strings.Compare(a.Path, b.Path) })
+        if c.observe != nil { c.observe(repos) }
+        now := time.Now()
+        snap := &snapshot{repos: repos, scanned: now, modified: now.Truncate(time.Second), etag: stateHash(repos)}
+
+        c.mu.Lock()
+        defer c.mu.Unlock()
+        if prev := c.entries[path]; prev != nil && prev.etag == snap.etag {
+            snap.modified = prev.modified
+        }
+        if _, ok := c.entries[path]; !ok && len(c.entries) >= maxCachedPaths { c.evictOldest() }
+        c.entries[path] = snap
+        return snap, nil
+    })
+    if err != nil { return nil, err }
+    return v.(*snapshot), nil
+}
+
+// invalidate marks every snapshot stale after a request changed the workspace
+func (c *repoCache) invalidate() {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    for _, snap := range c.entries {
+        snap.scanned = time.Time{}
+    }
+}
+
+func (c *repoCache) evictOldest() {
+    var oldest string
+    for path, snap := range c.entries {
+        if oldest == "" || snap.scanned.Before(c.entries[oldest].scanned) { oldest = path }
+    }
+    delete(c.entries, oldest)
+}
+
+// stateHash hashes repository state, ignoring per-scan timestamps
+func stateHash(repos []scan.Repository) string {
+    h := sha256.New()
+    enc := json.NewEncoder(h)
+    for _, r := range repos {
+        _ = enc.Encode(r.Repository)
+    }
+    return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// freshRequested reports whether the client asked to bypass the cache
+func freshRequested(r *http.Request) bool {
+    return r.URL.Query().Get("refresh") == "true" || strings.Contains(r.Header.Get("Cache-Control"), "no-cache")
+}
+
+// notModified sets ETag and Last-Modified for a response derived from snap
+// and writes 304 when the client's copy is current. The ETag covers the
+// query (minus refresh) and schema so differently filtered or versioned
+// views get distinct tags.
+func notModified(w http.ResponseWriter, r *http.Request, snap *snapshot) bool {
+    q := r.URL.Query()
+    q.Del("refresh")
+    sum := sha256.Sum256([]byte(snap.etag + "?" + q.Encode() + "#" + responseSchema(w)))
+    etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+    w.Header().Set("ETag", etag)
+    w.Header().Set("Last-Modified", snap.modified.UTC().Format(http.TimeFormat))
+    w.Header().Set("Cache-Control", "no-cache")
+
+    if inm := r.Header.Get("If-None-Match"); inm != "" {
+        for _, tag := range strings.Split(inm, ",") {
+            if tag = strings.TrimSpace(tag); tag == etag || tag == "*" {
+                w.WriteHeader(http.StatusNotModified)
+                return true
+            }
+        }
+        return false
+    }
+    if ims, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !snap.modified.After(ims) {
+        w.WriteHeader(http.StatusNotModified)
+        return true
+    }
+    return false
+}
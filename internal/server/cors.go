@@ -0,0 +1,107 @@
+package server
+
+import (
+    "net/http"
+    "strings"
+)
+
+// LoopbackOrigins are allowed when DS_CORS is 1 or true: local dashboards
+// on any port
+var LoopbackOrigins = []string{"http://localhost:*", "http://127.0.0.1:*", "http://[::1]:*"}
+
+// CORSOriginsFromEnv parses DS_CORS: 1/true allows LoopbackOrigins,
+// otherwise a comma-separated origin list; empty, 0 and false disable CORS
+func CORSOriginsFromEnv(v string) []string {
+    switch strings.ToLower(strings.TrimSpace(v)) {
+    case "", "0", "false":
+        return nil
+    case "1", "true":
+        return LoopbackOrigins
+    }
+    var origins []string
+    for _, o := range strings.Split(v, ",") {
+        if o = strings.TrimSpace(o); o != "" { origins = append(origins, o) }
+    }
+    return origins
+}
+
+// WithCORS allows browser requests from origins. Entries are exact origins
+// (https://dash.example), origins with any port (http://localhost:*), or
+// "*" for any origin without credentials. No origins disables CORS.
+func (s *Server) WithCORS(origins ...string) *Server { s.corsOrigins = origins; return s }
+
+// corsEnabled reports whether any origin is allowed
+func (s *Server) corsEnabled() bool { return len(s.corsOrigins) > 0 }
+
+// allowOrigin returns the Access-Control-Allow-Origin value for origin and
+// whether credentials may be sent; "" means the origin is not allowed
+func (s *Server) allowOrigin(origin string) (string, bool) {
+    wildcard := false
+    for _, pattern := range s.corsOrigins {
+        if pattern == "*" {
+            wildcard = true
+            continue
+        }
+        if matchOrigin(pattern, origin) { return origin, true }
+    }
+    // The spec forbids credentials with a wildcard origin
+    if wildcard { return "*", false }
+    return "", false
+}
+
+func matchOrigin(pattern, origin string) bool {
+    if strings.EqualFold(pattern, origin) { return true }
+    prefix, ok := strings.CutSuffix(pattern, ":*")
+    if !ok { return false }
+    if strings.EqualFold(origin, prefix) { return true }
+    port, ok := strings.CutPrefix(strings.ToLower(origin), strings.ToLower(prefix)+":")
+    if !ok || port == "" { return false }
+    for _, r := range port {
+        if r < '0' || r > '9' { return false }
+    }
+    return true
+}
+
+// wrapCORS answers preflight requests with the route's own methods and adds
+// CORS headers for allowed origins. Other origins get no CORS headers, so
+// browsers block their responses.
+func (s *Server) wrapCORS(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        origin := r.Header.Get("Origin")
+        w.Header().Add("Vary", "Origin")
+        if origin == "" {
+            next.ServeHTTP(w, r)
+            return
+        }
+        allowed, credentials := s.allowOrigin(origin)
+        preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+        if allowed == "" {
+            if preflight {
+                http.Error(w, "origin not allowed", http.StatusForbidden)
+                return
+            }
+            next.ServeHTTP(w, r)
+            return
+        }
+
+        w.Header().Set("Access-Control-Allow-Origin", allowed)
+        if credentials { w.Header().Set("Access-Control-Allow-Credentials", "true") }
+        if !preflight {
+            w.Header().Set("Access-Control-Expose-Headers", "ETag, "+schemaHeader)
+            next.ServeHTTP(w, r)
+            return
+        }
+
+        methods, ok := s.routes[r.URL.Path]
+        if !ok {
+            http.NotFound(w, r)
+            return
+        }
+        w.Header().Add("Vary", "Access-Control-Request-Method")
+        w.Header().Add("Vary", "Access-Control-Request-Headers")
+        w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", ")+", "+http.MethodOptions)
+        w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Accept, If-None-Match, Last-Event-ID")
+        w.Header().Set("Access-Control-Max-Age", "600")
+        w.WriteHeader(http.StatusNoContent)
+    })
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+    "errors"
+    "fmt"
+    "io/fs"
+    "net/http"
+    "slices"
+    "strings"
+)
+
+// Error codes returned in {ok:false, error:{code,message,details}}
+const (
+    CodeBadRequest       = "bad_request"
+    CodeUnauthorized     = "unauthorized"
+    CodeForbidden        = "forbidden"
+    CodeNotFound         = "not_found"
+    CodeMethodNotAllowed = "method_not_allowed"
+    CodeConflict         = "conflict"
+    CodeValidation       = "validation_failed"
+    CodeInternal         = "internal"
+)
+
+// APIError is an error with an HTTP status and a stable code. Handlers
+// return it for client errors; anything else is reported as 500.
+type APIError struct {
+    Status  int         `json:"-"`
+    Code    string      `json:"code"`
+    Message string      `json:"message"`
+    Details interface{} `json:"details,omitempty"`
+}
+
+func (e *APIError) Error() string { return e.Message }
+
+// badRequest is a malformed request: bad JSON, unknown query values
+func badRequest(format string, args ...interface{}) *APIError {
+    return &APIError{Status: http.StatusBadRequest, Code: CodeBadRequest, Message: fmt.Sprintf(format, args...)}
+}
+
+// notFound is a named resource that does not exist
+func notFound(format string, args ...interface{}) *APIError {
+    return &APIError{Status: http.StatusNotFound, Code: CodeNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+// conflict is a request the current workspace state prevents
+func conflict(details interface{}, format string, args ...interface{}) *APIError {
+    return &APIError{Status: http.StatusConflict, Code: CodeConflict, Message: fmt.Sprintf(format, args...), Details: details}
+}
+
+// invalid is a well-formed request whose content fails validation
+func invalid(err error) *APIError {
+    return &APIError{Status: http.StatusUnprocessableEntity, Code: CodeValidation, Message: err.Error()}
+}
+
+// forbidden is an authenticated request that is not permitted
+func forbidden(format string, args ...interface{}) *APIError {
+    return &APIError{Status: http.StatusForbidden, Code: CodeForbidden, Message: fmt.Sprintf(format, args...)}
+}
+
+// methodNotAllowed lists the methods a route supports
+func methodNotAllowed(method string, allowed []string) *APIError {
+    return &APIError{
+        Status:  http.StatusMethodNotAllowed,
+        Code:    CodeMethodNotAllowed,
+        Message: fmt.Sprintf("method %s not allowed; use %s", method, strings.Join(allowed, " or ")),
+        Details: map[string][]string{"allow": allowed},
+    }
+}
+
+// writeErr writes err as the error envelope with its status. Missing
+// files and paths are 404; other untyped errors are 500.
+func (s *Server) writeErr(w http.ResponseWriter, err error) {
+    var apiErr *APIError
+    switch {
+    case errors.As(err, &apiErr):
+    case errors.Is(err, fs.ErrNotExist):
+        apiErr = &APIError{Status: http.StatusNotFound, Code: CodeNotFound, Message: err.Error()}
+    default:
+        apiErr = &APIError{Status: http.StatusInternalServerError, Code: CodeInternal, Message: err.Error()}
+    }
+    s.writeJSON(w, apiErr.Status, map[string]interface{}{
+        "ok":             false,
+        "error":          apiErr,
+        "schema_version": responseSchema(w),
+    })
+}
+
+// methodFallback answers requests to a known path with an unsupported
+// method: 204 with Allow for OPTIONS, otherwise 405
+func (s *Server) methodFallback(methods []string) http.HandlerFunc {
+    allowed := append([]string{}, methods...)
+    if slices.Contains(methods, http.MethodGet) { allowed = append(allowed, http.MethodHead) }
+    allow := strings.Join(append(allowed, http.MethodOptions), ", ")
+    return func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Allow", allow)
+        if r.Method == http.MethodOptions {
+            w.WriteHeader(http.StatusNoContent)
+            return
+        }
+        s.writeErr(w, methodNotAllowed(r.Method, methods))
+    }
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+    "encoding/json"
+    "log"
+    "net/http"
+    "net/url"
+    "time"
+
+    "github.com/verlyn13/ds-go/internal/api"
+    "github.com/verlyn13/ds-go/internal/runner"
+)
+
+// defaultPolicyFile is the policy /v1/policy/check runs without auth
+const defaultPolicyFile = ".project-compliance.yaml"
+
+// rawExecAllowed reports whether /v1/exec accepts raw commands: only with
+// authentication enabled and when not disabled with --no-raw-exec
+func (s *Server) rawExecAllowed() bool {
+    return s.authEnabled() && !s.noRawExec
+}
+
+// handleExec runs an allow-listed command template, or a raw command when
+// permitted, across the selected repositories. Every call is audit-logged.
+func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
+    rec := AuditRecord{Time: time.Now().UTC(), Remote: r.RemoteAddr, Token: tokenName(r)}
+    deny := func(err error) {
+        rec.Reason = err.Error()
+        s.logAudit(rec)
+        s.writeErr(w, err)
+    }
+
+    var req api.ExecRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        deny(badRequest("invalid request body: %v", err))
+        return
+    }
+    rec.Command, rec.Params, rec.Raw = req.Command, req.Params, req.Cmd != ""
+
+    var command string
+    timeout := time.Duration(req.Timeout) * time.Second
+    switch {
+    case req.Command != "" && req.Cmd != "":
+        deny(badRequest("set either command or cmd, not both"))
+        return
+    case req.Cmd != "":
+        if !s.rawExecAllowed() {
+            deny(forbidden("raw commands are disabled; use a named command from /v1/commands"))
+            return
+        }
+        command = req.Cmd
+    case req.Command != "":
+        tmpl, ok := s.cfg.Commands[req.Command]
+        if !ok {
+            deny(notFound("unknown command %q", req.Command))
+            return
+        }
+        rendered, err := tmpl.Render(req.Params)
+        if err != nil {
+            deny(invalid(err))
+            return
+        }
+        command = rendered
+        if timeout == 0 { timeout = tmpl.TimeoutDuration() }
+    default:
+        deny(badRequest("missing command"))
+        return
+    }
+    rec.Rendered = command
+
+    snap, err := s.repos.get(req.Path, false)
+    if err != nil { deny(err); return }
+    filters := url.Values{"account": {req.Account}}
+    if req.Dirty { filters.Set("dirty", "true") }
+    repos := filterRepos(snap.Repos(), filters)
+
+    rec.Allowed = true
+    start := time.Now()
+    results := runner.ExecInRepos(repos, command, timeout)
+    s.repos.invalidate()
+    rec.DurationMs = time.Since(start).Milliseconds()
+    rec.Repos = len(results)
+    for _, res := range results {
+        if res.Success { rec.OK++ } else { rec.Failed++ }
+    }
+    s.logAudit(rec)
+    s.writeJSONVersioned(w, r, http.StatusOK, map[string]interface{}{
+        "command": req.Command,
+        "rendered": command,
+        "results": results,
+    })
+}
+
+func (s *Server) logAudit(rec AuditRecord) {
+    if err := s.audit.write(rec); err != nil {
+        log.Printf("audit log: %v", err)
+    }
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/sha256"
+    "crypto/tls"
+    "crypto/x509"
+    "crypto/x509/pkix"
+    "encoding/hex"
+    "encoding/pem"
+    "fmt"
+    "math/big"
+    "net"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "github.com/adrg/xdg"
+)
+
+// TLSConfig selects the server certificate. With SelfSigned and no
+// CertFile, a certificate is generated once under DefaultTLSDir and reused.
+type TLSConfig struct {
+    CertFile   string
+    KeyFile    string
+    SelfSigned bool
+}
+
+// DefaultTLSDir holds the generated self-signed certificate
+func DefaultTLSDir() string {
+    return filepath.Join(xdg.StateHome, "ds", "tls")
+}
+
+// listen opens addr, which is host:port, tcp://host:port or unix:///path.
+// Unix sockets are created mode 0600 so only the owner can connect; a
+// stale socket left by a crashed server is replaced.
+func listen(addr string) (net.Listener, error) {
+    if path, ok := strings.CutPrefix(addr, "unix://"); ok {
+        if path == "" { return nil, fmt.Errorf("unix listen address needs a path, e.g. unix:///run/user/1000/ds.sock") }
+        if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil { return nil, err }
+        if _, err := os.Stat(path); err == nil {
+            if c, err := net.Dial("unix", path); err == nil {
+                c.Close()
+                return nil, fmt.Errorf("%s is in use by another server", path)
+            }
+            if err := os.Remove(path); err != nil { return nil, err }
+        }
+        ln, err := net.Listen("unix", path)
+        if err != nil { return nil, err }
+        if err := os.Chmod(path, 0600); err != nil {
+            ln.Close()
+            return nil, err
+        }
+        return ln, nil
+    }
+    return net.Listen("tcp", strings.TrimPrefix(addr, "tcp://"))
+}
+
+// loadTLS returns the server TLS config and the certificate's SHA-256
+// fingerprint, generating a self-signed certificate when requested
+func loadTLS(cfg TLSConfig) (*tls.Config, string, error) {
+    certFile, keyFile := cfg.CertFile, cfg.KeyFile
+    if certFile == "" && cfg.SelfSigned {
+        dir := DefaultTLSDir()
+        certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+        if _, err := os.Stat(certFile); os.IsNotExist(err) {
+            if err := generateSelfSigned(certFile, keyFile); err != nil {
+                return nil, "", fmt.Errorf("generating self-signed certificate: %w", err)
+            }
+        }
+    }
+    if certFile == "" || keyFile == "" { return nil, "", fmt.Errorf("both --tls-cert and --tls-key are required") }
+    cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+    if err != nil { return nil, "", fmt.Errorf("loading TLS certificate: %w", err) }
+    return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, Fingerprint(cert.Certificate[0]), nil
+}
+
+// Fingerprint returns the colon-separated SHA-256 of a DER certificate
+func Fingerprint(der []byte) string {
+    sum := sha256.Sum256(der)
+    parts := make([]string, len(sum))
+    for i, b := range sum {
+        parts[i] = strings.ToUpper(hex.EncodeToString([]byte{b}))
+    }
+    return strings.Join(parts, ":")
+}
+
+// generateSelfSigned writes a P-256 certificate for localhost and the
+// machine's hostname, valid for one year
+func generateSelfSigned(certFile, keyFile string) error {
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil { return err }
+    serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+    if err != nil { return err }
+
+    names := []string{"localhost"}
+    if host, err := os.Hostname(); err == nil && host != "" && host != "localhost" {
+        names = append(names, host)
+    }
+    tmpl := &x509.Certificate{
+        SerialNumber: serial,
+        Subject:      pkix.Name{CommonName: "ds serve", Organization: []string{"ds"}},
+        NotBefore:    time.Now().Add(-time.Hour),
+        NotAfter:     time.Now().AddDate(1, 0, 0),
+        KeyUsage:     x509.KeyUsageDigitalSignature,
+        ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+        DNSNames:     names,
+        IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+    }
+    der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+    if err != nil { return err }
+    keyDER, err := x509.MarshalECPrivateKey(key)
+    if err != nil { return err }
+
+    if err := os.MkdirAll(filepath.Dir(certFile), 0700); err != nil { return err }
+    if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil { return err }
+    return os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+}
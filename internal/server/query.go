@@ -0,0 +1,170 @@
+package server
+
+import (
+    "encoding/base64"
+    "encoding/json"
+    "net/url"
+    "slices"
+    "strconv"
+    "strings"
+
+    "github.com/verlyn13/ds-go/internal/api"
+    "github.com/verlyn13/ds-go/internal/scan"
+)
+
+// maxStatusLimit caps ?limit= on /v1/status
+const maxStatusLimit = 1000
+
+// flagFilters keep repositories when their query parameter is "true"
+var flagFilters = map[string]func(r scan.Repository) bool{
+    "dirty":       func(r scan.Repository) bool { return !r.IsClean },
+    "ahead":       func(r scan.Repository) bool { return r.Ahead > 0 },
+    "behind":      func(r scan.Repository) bool { return r.Behind > 0 },
+    "has_stash":   func(r scan.Repository) bool { return r.HasStash },
+    "no_upstream": func(r scan.Repository) bool { return !r.HasUpstream },
+}
+
+// valueFilters keep repositories matching their query parameter's value
+var valueFilters = map[string]func(r scan.Repository, v string) bool{
+    "account": func(r scan.Repository, v string) bool { return r.Account == v },
+    "branch":  func(r scan.Repository, v string) bool { return r.Branch == v },
+    "folder":  func(r scan.Repository, v string) bool { return r.FolderName == v },
+    "tag":     func(r scan.Repository, v string) bool { return slices.Contains(r.Tags, v) },
+}
+
+// filterRepos applies every filter present in q
+func filterRepos(repos []scan.Repository, q url.Values) []scan.Repository {
+    return slices.DeleteFunc(repos, func(r scan.Repository) bool {
+        for name, keep := range flagFilters {
+            if q.Get(name) == "true" && !keep(r) { return true }
+        }
+        for name, keep := range valueFilters {
+            if v := q.Get(name); v != "" && !keep(r, v) { return true }
+        }
+        return false
+    })
+}
+
+// repoFields maps each schema's normalized repository JSON keys (see
+// fieldKey) to their spelling
+var repoFields = map[string]map[string]string{
+    api.SchemaV1: jsonKeys(api.Repository{}),
+    api.SchemaV2: jsonKeys(api.RepositoryV2{}),
+}
+
+func jsonKeys(v interface{}) map[string]string {
+    b, _ := json.Marshal(v)
+    var m map[string]json.RawMessage
+    _ = json.Unmarshal(b, &m)
+    keys := make(map[string]string, len(m))
+    for k := range m {
+        keys[fieldKey(k)] = k
+    }
+    return keys
+}
+
+// fieldKey normalizes a field name so IsClean, isclean and is_clean match
+func fieldKey(name string) string {
+    return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// statusQuery is the sorting, field selection and paging of /v1/status
+type statusQuery struct {
+    version string
+    sort    string
+    fields  []string
+    limit   int
+    offset  int
+}
+
+// parseStatusQuery reads ?sort=, ?fields=, ?limit= and ?cursor=; fields
+// are named as in the response schema version
+func parseStatusQuery(q url.Values, version string) (statusQuery, error) {
+    sq := statusQuery{version: version}
+    if sq.sort = q.Get("sort"); sq.sort != "" && !scan.ValidSortKey(sq.sort) {
+        return sq, badRequest("unknown sort key %q (use one of %s; prefix - to reverse)", sq.sort, strings.Join(scan.SortKeys, ", "))
+    }
+    if v := q.Get("fields"); v != "" {
+        for _, f := range strings.Split(v, ",") {
+            if f = strings.TrimSpace(f); f == "" { continue }
+            key, ok := repoFields[version][fieldKey(f)]
+            if !ok { return sq, badRequest("unknown field %q (use one of %s)", f, strings.Join(fieldNames(version), ", ")) }
+            if !slices.Contains(sq.fields, key) { sq.fields = append(sq.fields, key) }
+        }
+    }
+    if v := q.Get("limit"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 1 || n > maxStatusLimit { return sq, badRequest("limit must be between 1 and %d", maxStatusLimit) }
+        sq.limit = n
+    }
+    if v := q.Get("cursor"); v != "" {
+        offset, err := decodeCursor(v)
+        if err != nil { return sq, badRequest("invalid cursor") }
+        sq.offset = offset
+    }
+    return sq, nil
+}
+
+// page sorts repos and returns the requested page and the cursor of the
+// next one, "" on the last page
+func (sq statusQuery) page(repos []scan.Repository) ([]scan.Repository, string) {
+    if sq.sort != "" { scan.SortRepos(repos, sq.sort) }
+    if sq.offset >= len(repos) { return []scan.Repository{}, "" }
+    repos = repos[sq.offset:]
+    if sq.limit == 0 || len(repos) <= sq.limit { return repos, "" }
+    return repos[:sq.limit], encodeCursor(sq.offset + sq.limit)
+}
+
+// project returns repos reduced to the selected fields, or repos unchanged
+// when no fields were selected
+func (sq statusQuery) project(repos []scan.Repository) (interface{}, error) {
+    if len(sq.fields) == 0 { return repos, nil }
+    out := make([]interface{}, len(repos))
+    for i, repo := range repos {
+        v, err := sq.projectRepo(repo)
+        if err != nil { return nil, err }
+        out[i] = v
+    }
+    return out, nil
+}
+
+// projectRepo returns repo in the query's schema, reduced to the selected
+// fields
+func (sq statusQuery) projectRepo(repo scan.Repository) (interface{}, error) {
+    v := toWire(repo, sq.version)
+    if len(sq.fields) == 0 { return v, nil }
+    b, err := json.Marshal(v)
+    if err != nil { return nil, err }
+    var all map[string]json.RawMessage
+    if err := json.Unmarshal(b, &all); err != nil { return nil, err }
+    out := make(map[string]json.RawMessage, len(sq.fields))
+    for _, f := range sq.fields {
+        out[f] = all[f]
+    }
+    return out, nil
+}
+
+func fieldNames(version string) []string {
+    names := make([]string, 0, len(repoFields[version]))
+    for _, name := range repoFields[version] {
+        names = append(names, name)
+    }
+    slices.Sort(names)
+    return names
+}
+
+// Cursors are opaque to clients; they encode the offset of the next page
+// within the sorted, filtered result
+func encodeCursor(offset int) string {
+    return base64.RawURLEncoding.EncodeToString([]byte("o:" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+    b, err := base64.RawURLEncoding.DecodeString(cursor)
+    if err != nil { return 0, err }
+    v, ok := strings.CutPrefix(string(b), "o:")
+    if !ok { return 0, strconv.ErrSyntax }
+    n, err := strconv.Atoi(v)
+    if err != nil || n < 0 { return 0, strconv.ErrSyntax }
+    return n, nil
+}
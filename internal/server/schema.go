@@ -0,0 +1,71 @@
+package server
+
+import (
+    "mime"
+    "net/http"
+    "strings"
+
+    "github.com/verlyn13/ds-go/internal/api"
+    "github.com/verlyn13/ds-go/internal/scan"
+)
+
+// schemaHeader echoes the negotiated schema on every API response,
+// including streams and errors that carry no envelope
+const schemaHeader = "DS-Schema-Version"
+
+// schemaVersion picks the response schema: ds.v2 for /v2/ paths or an
+// Accept header listing api.MediaTypeV2, otherwise ds.v1
+func schemaVersion(r *http.Request) string {
+    if strings.HasPrefix(r.URL.Path, "/v2/") { return api.SchemaV2 }
+    for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+        if mt, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && mt == api.MediaTypeV2 { return api.SchemaV2 }
+    }
+    return api.SchemaV1
+}
+
+// responseSchema returns the schema negotiated for the response being
+// written to w
+func responseSchema(w http.ResponseWriter) string {
+    if v := w.Header().Get(schemaHeader); v != "" { return v }
+    return api.SchemaV1
+}
+
+// wrapSchema records the negotiated schema on the response
+func wrapSchema(h http.HandlerFunc) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set(schemaHeader, schemaVersion(r))
+        w.Header().Add("Vary", "Accept")
+        h(w, r)
+    }
+}
+
+// toWire converts repositories and fetch results, alone, in slices or as
+// values of a response map, to their API form in version
+func toWire(v interface{}, version string) interface{} {
+    switch t := v.(type) {
+    case scan.Repository:
+        if version == api.SchemaV2 { return api.NewRepositoryV2(t) }
+        return api.NewRepository(t)
+    case []scan.Repository:
+        out := make([]interface{}, len(t))
+        for i, r := range t {
+            out[i] = toWire(r, version)
+        }
+        return out
+    case scan.FetchResult:
+        if version == api.SchemaV2 { return api.NewFetchResultV2(t) }
+        return api.NewFetchResult(t)
+    case []scan.FetchResult:
+        out := make([]interface{}, len(t))
+        for i, r := range t {
+            out[i] = toWire(r, version)
+        }
+        return out
+    case map[string]interface{}:
+        for k, x := range t {
+            t[k] = toWire(x, version)
+        }
+        return t
+    }
+    return v
+}
@@ -2,18 +2,31 @@ package server
 
 import (
     "bufio"
+    "context"
+    "crypto/tls"
     "encoding/json"
+    "errors"
     "fmt"
+    "io"
+    "io/fs"
     "log"
     "net/http"
+    "os/signal"
+    "slices"
     "strconv"
+    "strings"
+    "sync/atomic"
     "time"
 
+    "github.com/verlyn13/ds-go/internal/api"
+    "github.com/verlyn13/ds-go/internal/auth"
     "github.com/verlyn13/ds-go/internal/config"
+    "github.com/verlyn13/ds-go/internal/events"
+    "github.com/verlyn13/ds-go/internal/manifest"
     "github.com/verlyn13/ds-go/internal/policy"
-    "github.com/verlyn13/ds-go/internal/runner"
     "github.com/verlyn13/ds-go/internal/scan"
     "os"
+    "syscall"
 )
 
 type Server struct {
@@ -21,73 +34,88 @@ type Server struct {
     workerCount int
     token       string
     started     time.Time
-    corsEnabled bool
+    corsOrigins []string
+    routes      map[string][]string // Path -> allowed methods, for CORS preflight
+    noRawExec   bool
+    audit       *auditLog
+    tokens      *auth.Store
+    authOn      atomic.Bool // Set once auth is on; it is never turned off
+    tls         *TLSConfig
+    scanner     *scan.Scanner
+    repos       *repoCache
+    events      *events.Bus
 }
 
 func New(cfg *config.Config, workers int) *Server {
     if workers <= 0 { workers = 10 }
-    return &Server{cfg: cfg, workerCount: workers}
+    scanner := scan.New(cfg, workers)
+    return &Server{cfg: cfg, workerCount: workers, scanner: scanner, repos: newRepoCache(scanner, DefaultCacheTTL)}
 }
 
-func (s *Server) Start(addr string) error {
+// WithEvents publishes events to bus: failed fetches and policy checks,
+// applied organize plans, and the changes detector finds between scans.
+// /v1/events serves the bus's log.
+func (s *Server) WithEvents(bus *events.Bus, detector *events.Detector) *Server {
+    s.events = bus
+    s.repos.observe = func(repos []scan.Repository) {
+        evs, err := detector.Observe(repos)
+        if err != nil { log.Printf("events: %v", err) }
+        bus.Publish(evs...)
+    }
+    return s
+}
+
+// WithCacheTTL sets how long scan results are reused; 0 rescans on every request
+func (s *Server) WithCacheTTL(ttl time.Duration) *Server { s.repos.ttl = ttl; return s }
+
+// Handler returns the API handler with a route for every api.Routes path
+func (s *Server) Handler() http.Handler {
     if s.started.IsZero() { s.started = time.Now() }
     mux := http.NewServeMux()
+    s.routes = map[string][]string{}
 
-    mux.HandleFunc("/v1/capabilities", s.wrapAuth(func(w http.ResponseWriter, r *http.Request) {
-        s.writeJSON(w, http.StatusOK, map[string]interface{}{
+    s.handle(mux, "/v1/capabilities", func(w http.ResponseWriter, r *http.Request) {
+        s.writeJSONVersioned(w, r, http.StatusOK, map[string]interface{}{
             "version": 1,
-            "schema": "v1",
-            "endpoints": []string{
-                "/v1/capabilities",
-                "/v1/health",
-                "/v1/status",
-                "/v1/status/stream",
-                "/v1/status/sse",
-                "/v1/scan",
-                "/v1/organize/plan",
-                "/v1/organize/apply",
-                "/v1/fetch",
-                "/v1/fetch/sse",
-                "/v1/policy/check",
-                "/v1/exec",
-            },
+            "schema": strings.TrimPrefix(responseSchema(w), "ds."),
+            "schema_versions": []string{api.SchemaV1, api.SchemaV2},
+            "endpoints": api.Endpoints(),
             "timestamp": time.Now().UTC(),
             "openapi_url": "/openapi.yaml",
-            "schema_version": "ds.v1",
         })
-    }))
+    })
 
     // Health endpoint
-    mux.HandleFunc("/v1/health", s.wrapAuth(func(w http.ResponseWriter, r *http.Request) {
+    s.handle(mux, "/v1/health", func(w http.ResponseWriter, r *http.Request) {
         up := time.Since(s.started).Seconds()
-        s.writeJSON(w, http.StatusOK, map[string]interface{}{
+        s.writeJSONVersioned(w, r, http.StatusOK, map[string]interface{}{
             "ok": true,
             "version": 1,
             "uptime_sec": int(up),
             "workers": s.workerCount,
-            "auth": s.token != "",
+            "auth": s.authEnabled(),
             "timestamp": time.Now().UTC(),
-            "schema_version": "ds.v1",
         })
-    }))
+    })
 
     // OpenAPI exposure
-    mux.HandleFunc("/openapi.yaml", s.wrapAuth(serveOpenAPI))
-    mux.HandleFunc("/api/discovery/openapi", s.wrapAuth(serveOpenAPI))
+    s.handle(mux, "/openapi.yaml", serveOpenAPI)
+    s.handle(mux, "/api/discovery/openapi", serveOpenAPI)
 
     // Discovery metadata (minimal)
-    mux.HandleFunc("/api/discovery/capabilities", s.wrapAuth(func(w http.ResponseWriter, r *http.Request) {
+    s.handle(mux, "/api/discovery/capabilities", func(w http.ResponseWriter, r *http.Request) {
         s.writeJSON(w, http.StatusOK, map[string]interface{}{
             "contractVersion": 1,
             "schemaVersion": "v1",
             "openapi_url": "/openapi.yaml",
-            "endpoints": []string{"/v1/status", "/v1/scan", "/v1/fetch", "/v1/organize/plan", "/v1/policy/check", "/v1/exec"},
+            "endpoints": []string{"/v1/status", "/v1/scan", "/v1/fetch", "/v1/organize/plan", "/v1/policy/check", "/v1/commands", "/v1/exec"},
         })
-    }))
+    })
 
     // Services descriptor (single-service self description for convenience)
-    mux.HandleFunc("/api/discovery/services", s.wrapAuth(func(w http.ResponseWriter, r *http.Request) {
+    s.handle(mux, "/api/discovery/services", func(w http.ResponseWriter, r *http.Request) {
         base := "http://" + r.Host
+        if r.TLS != nil { base = "https://" + r.Host }
         resp := map[string]interface{}{
             "ds": map[string]string{
                 "url":          base,
@@ -97,14 +125,14 @@ func (s *Server) Start(addr string) error {
                 "health":       base + "/v1/health",
                 "self_status":  base + "/api/self-status",
             },
-            "ds_token_present": (s.token != ""),
+            "ds_token_present": s.authEnabled(),
             "ts": time.Now().UnixMilli(),
         }
         s.writeJSON(w, http.StatusOK, resp)
-    }))
+    })
 
     // Well-known bridge descriptor
-    mux.HandleFunc("/.well-known/obs-bridge.json", s.wrapAuth(func(w http.ResponseWriter, r *http.Request) {
+    s.handle(mux, "/.well-known/obs-bridge.json", func(w http.ResponseWriter, r *http.Request) {
         s.writeJSON(w, http.StatusOK, map[string]interface{}{
             "contractVersion": 1,
             "schemaVersion": "v1",
@@ -115,32 +143,20 @@ func (s *Server) Start(addr string) error {
                 "capabilities": "/v1/capabilities",
                 "health": "/v1/health",
             },
-            "all": []string{
-                "/v1/health",
-                "/v1/status",
-                "/v1/status/stream",
-                "/v1/status/sse",
-                "/v1/scan",
-                "/v1/fetch",
-                "/v1/fetch/sse",
-                "/v1/organize/plan",
-                "/v1/organize/apply",
-                "/v1/policy/check",
-                "/v1/exec",
-            },
+            "all": api.Endpoints(),
         })
-    }))
+    })
 
     // Self-status for MCP-style probes
-    mux.HandleFunc("/api/self-status", s.wrapAuth(func(w http.ResponseWriter, r *http.Request) {
+    s.handle(mux, "/api/self-status", func(w http.ResponseWriter, r *http.Request) {
         now := time.Now()
         s.writeJSON(w, http.StatusOK, map[string]interface{}{
             "service": "ds",
             "ok": true,
             "nowMs": now.UnixMilli(),
             "auth": map[string]any{
-                "tokenRequired": s.token != "",
-                "corsEnabled": s.corsEnabled,
+                "tokenRequired": s.authEnabled(),
+                "corsEnabled": s.corsEnabled(),
             },
             "endpoints": map[string]string{
                 "openapi": "/openapi.yaml",
@@ -152,192 +168,307 @@ func (s *Server) Start(addr string) error {
                 "organizePlan": "/v1/organize/plan",
                 "organizeApply": "/v1/organize/apply",
                 "policyCheck": "/v1/policy/check",
+                "commands": "/v1/commands",
                 "exec": "/v1/exec",
+                "manifest": "/v1/manifest",
+                "events": "/v1/events",
             },
-            "schema_version": "ds.v1",
+            "schema_version": api.SchemaV1,
         })
-    }))
-
-    mux.HandleFunc("/v1/status", s.wrapAuth(func(w http.ResponseWriter, r *http.Request) {
-        scanner := scan.New(s.cfg, s.workerCount)
-        path := r.URL.Query().Get("path")
-        account := r.URL.Query().Get("account")
-        dirty := r.URL.Query().Get("dirty") == "true"
-        repos, err := scanner.Scan(path)
+    })
+
+    s.handle(mux, "/v1/status", func(w http.ResponseWriter, r *http.Request) {
+        sq, err := parseStatusQuery(r.URL.Query(), responseSchema(w))
         if err != nil { s.writeErr(w, err); return }
-        if dirty {
-            repos = filterDirty(repos)
-        }
-        if account != "" {
-            repos = filterByAccount(repos, account)
-        }
-        s.writeJSONVersioned(w, r, http.StatusOK, repos)
-    }))
-
-    mux.HandleFunc("/v1/status/stream", s.wrapAuth(func(w http.ResponseWriter, r *http.Request) {
-        scanner := scan.New(s.cfg, s.workerCount)
-        path := r.URL.Query().Get("path")
-        account := r.URL.Query().Get("account")
-        dirty := r.URL.Query().Get("dirty") == "true"
-        repos, err := scanner.Scan(path)
+        snap, repos, err := s.selectRepos(r, freshRequested(r))
         if err != nil { s.writeErr(w, err); return }
-        if dirty { repos = filterDirty(repos) }
-        if account != "" { repos = filterByAccount(repos, account) }
+        if notModified(w, r, snap) { return }
+        total := len(repos)
+        repos, next := sq.page(repos)
+        data, err := sq.project(repos)
+        if err != nil { s.writeErr(w, err); return }
+        resp := map[string]interface{}{"data": data, "total": total}
+        if next != "" { resp["next_cursor"] = next }
+        s.writeJSONVersioned(w, r, http.StatusOK, resp)
+    })
+
+    s.handle(mux, "/v1/status/stream", func(w http.ResponseWriter, r *http.Request) {
+        // Streams honour sort and fields but are never paged
+        sq, err := parseStatusQuery(r.URL.Query(), responseSchema(w))
+        if err != nil { s.writeErr(w, err); return }
+        sq.limit, sq.offset = 0, 0
+        snap, repos, err := s.selectRepos(r, freshRequested(r))
+        if err != nil { s.writeErr(w, err); return }
+        if notModified(w, r, snap) { return }
+        repos, _ = sq.page(repos)
         w.Header().Set("Content-Type", "application/x-ndjson")
         bw := bufio.NewWriter(w)
         enc := json.NewEncoder(bw)
         for _, repo := range repos {
-            if err := enc.Encode(repo); err != nil {
+            v, err := sq.projectRepo(repo)
+            if err != nil { return }
+            if err := enc.Encode(v); err != nil {
                 return
             }
             bw.Flush()
         }
-    }))
-
-    mux.HandleFunc("/v1/status/sse", s.wrapAuth(func(w http.ResponseWriter, r *http.Request) {
-        scanner := scan.New(s.cfg, s.workerCount)
-        path := r.URL.Query().Get("path")
-        account := r.URL.Query().Get("account")
-        dirty := r.URL.Query().Get("dirty") == "true"
-        repos, err := scanner.Scan(path)
+    })
+
+    s.handle(mux, "/v1/status/sse", func(w http.ResponseWriter, r *http.Request) {
+        sq, err := parseStatusQuery(r.URL.Query(), responseSchema(w))
+        if err != nil { s.writeErr(w, err); return }
+        sq.limit, sq.offset = 0, 0
+        _, repos, err := s.selectRepos(r, freshRequested(r))
         if err != nil { s.writeErr(w, err); return }
-        if dirty { repos = filterDirty(repos) }
-        if account != "" { repos = filterByAccount(repos, account) }
+        repos, _ = sq.page(repos)
+        // Event ids count repositories, so a reconnect resumes after the
+        // last one received
+        skip, _ := strconv.Atoi(r.Header.Get("Last-Event-ID"))
         sseStart(w)
-        for _, repo := range repos {
-            if err := sseData(w, repo, "repo"); err != nil { return }
+        for i, repo := range repos {
+            if i < skip { continue }
+            v, err := sq.projectRepo(repo)
+            if err != nil { return }
+            if err := sseData(w, v, "repo", strconv.Itoa(i+1)); err != nil { return }
         }
-    }))
+    })
 
-    mux.HandleFunc("/v1/scan", s.wrapAuth(func(w http.ResponseWriter, r *http.Request) {
-        scanner := scan.New(s.cfg, s.workerCount)
-        path := r.URL.Query().Get("path")
-        repos, err := scanner.Scan(path)
+    s.handle(mux, "/v1/scan", func(w http.ResponseWriter, r *http.Request) {
+        // An explicit scan always rescans and refreshes the cache
+        snap, err := s.repos.get(r.URL.Query().Get("path"), true)
         if err != nil { s.writeErr(w, err); return }
-        if err := scanner.SaveIndex(repos); err != nil { s.writeErr(w, err); return }
-        s.writeJSONVersioned(w, r, http.StatusOK, map[string]int{"count": len(repos)})
-    }))
+        repos := snap.Repos()
+        if err := s.scanner.SaveIndex(repos); err != nil { s.writeErr(w, err); return }
+        s.writeJSONVersioned(w, r, http.StatusOK, map[string]interface{}{"count": len(repos)})
+    })
 
-    mux.HandleFunc("/v1/organize/plan", s.wrapAuth(func(w http.ResponseWriter, r *http.Request) {
-        scanner := scan.New(s.cfg, s.workerCount)
-        path := r.URL.Query().Get("path")
+    s.handle(mux, "/v1/organize/plan", func(w http.ResponseWriter, r *http.Request) {
         requireClean := r.URL.Query().Get("require_clean") == "true"
-        repos, err := scanner.Scan(path)
+        snap, err := s.repos.get(r.URL.Query().Get("path"), freshRequested(r))
         if err != nil { s.writeErr(w, err); return }
+        repos := snap.Repos()
         if requireClean {
             for _, r := range repos {
-                if !r.IsClean { s.writeErr(w, fmt.Errorf("require-clean: '%s' has uncommitted changes", r.Name)); return }
+                if !r.IsClean { s.writeErr(w, conflict(map[string]string{"repo": r.Name}, "require-clean: '%s' has uncommitted changes", r.Name)); return }
             }
         }
+        if notModified(w, r, snap) { return }
         plan := scan.OrganizePlanJSON(repos, s.cfg)
         s.writeJSONVersioned(w, r, http.StatusOK, plan)
-    }))
+    })
 
-    mux.HandleFunc("/v1/organize/apply", s.wrapAuth(func(w http.ResponseWriter, r *http.Request) {
-        scanner := scan.New(s.cfg, s.workerCount)
-        path := r.URL.Query().Get("path")
+    s.handle(mux, "/v1/organize/apply", func(w http.ResponseWriter, r *http.Request) {
         requireClean := r.URL.Query().Get("require_clean") == "true"
         force := r.URL.Query().Get("force") == "true"
         dryRun := r.URL.Query().Get("dry_run") == "true"
-        repos, err := scanner.Scan(path)
+        // Moves are planned from a fresh scan, never a cached one
+        snap, err := s.repos.get(r.URL.Query().Get("path"), true)
         if err != nil { s.writeErr(w, err); return }
+        repos := snap.Repos()
         if requireClean {
             for _, r := range repos {
-                if !r.IsClean { s.writeErr(w, fmt.Errorf("require-clean: '%s' has uncommitted changes", r.Name)); return }
+                if !r.IsClean { s.writeErr(w, conflict(map[string]string{"repo": r.Name}, "require-clean: '%s' has uncommitted changes", r.Name)); return }
             }
         }
         results, moved, failed := scan.ApplyOrganizePlan(repos, s.cfg, dryRun, force)
+        if !dryRun {
+            s.repos.invalidate()
+            s.events.Publish(events.Organized(results)...)
+        }
         s.writeJSONVersioned(w, r, http.StatusOK, map[string]interface{}{
             "moved": moved,
             "failed": failed,
             "results": results,
         })
-    }))
-
-    mux.HandleFunc("/v1/fetch", s.wrapAuth(func(w http.ResponseWriter, r *http.Request) {
-        scanner := scan.New(s.cfg, s.workerCount)
-        path := r.URL.Query().Get("path")
-        account := r.URL.Query().Get("account")
-        dirty := r.URL.Query().Get("dirty") == "true"
-        repos, err := scanner.Scan(path)
+    })
+
+    s.handle(mux, "/v1/fetch", func(w http.ResponseWriter, r *http.Request) {
+        _, repos, err := s.selectRepos(r, freshRequested(r))
         if err != nil { s.writeErr(w, err); return }
-        if dirty { repos = filterDirty(repos) }
-        if account != "" { repos = filterByAccount(repos, account) }
         fetcher := scan.NewFetcher(s.workerCount)
         results := fetcher.FetchAll(repos, false)
+        s.repos.invalidate()
+        s.events.Publish(events.FetchFailures(repos, results)...)
+        s.rescanAfterFetch(r)
         s.writeJSONVersioned(w, r, http.StatusOK, map[string]interface{}{"results": results})
-    }))
-
-    mux.HandleFunc("/v1/fetch/sse", s.wrapAuth(func(w http.ResponseWriter, r *http.Request) {
-        scanner := scan.New(s.cfg, s.workerCount)
-        path := r.URL.Query().Get("path")
-        account := r.URL.Query().Get("account")
-        dirty := r.URL.Query().Get("dirty") == "true"
-        repos, err := scanner.Scan(path)
+    })
+
+    s.handle(mux, "/v1/fetch/sse", func(w http.ResponseWriter, r *http.Request) {
+        _, repos, err := s.selectRepos(r, freshRequested(r))
         if err != nil { s.writeErr(w, err); return }
-        if dirty { repos = filterDirty(repos) }
-        if account != "" { repos = filterByAccount(repos, account) }
         fetcher := scan.NewFetcher(s.workerCount)
         sseStart(w)
         ctx := r.Context()
         stream := fetcher.FetchAllStream(ctx, repos)
+        defer s.rescanAfterFetch(r)
+        defer s.repos.invalidate()
+        version := responseSchema(w)
+        // Results arrive in completion order, so a reconnect fetches again
+        // and clients drop the repositories they already have
+        n := 0
         for res := range stream {
-            if err := sseData(w, res, "fetch"); err != nil { return }
+            n++
+            s.events.Publish(events.FetchFailures(repos, []scan.FetchResult{res})...)
+            if err := sseData(w, toWire(res, version), "fetch", strconv.Itoa(n)); err != nil { return }
         }
-    }))
+    })
 
-    mux.HandleFunc("/v1/policy/check", s.wrapAuth(func(w http.ResponseWriter, r *http.Request) {
+    s.handle(mux, "/v1/policy/check", func(w http.ResponseWriter, r *http.Request) {
+        // A policy file runs its own shell commands, so choosing one is as
+        // good as a raw command and is refused whenever raw commands are
         file := r.URL.Query().Get("file")
-        if file == "" { file = ".project-compliance.yaml" }
+        if file == "" { file = defaultPolicyFile }
+        if file != defaultPolicyFile && !s.rawExecAllowed() {
+            s.writeErr(w, forbidden("policy files other than %s need raw commands enabled, which require auth", defaultPolicyFile)); return
+        }
         failOn := r.URL.Query().Get("fail_on")
         if failOn == "" { failOn = "critical" }
-        cfg, err := policy.Load(file)
-        if err != nil { s.writeErr(w, err); return }
-        report, err := policy.RunChecks(cfg)
-        if err != nil { s.writeErr(w, err); return }
-        // Include a fail flag in response
         th, err := policy.SeverityFromString(failOn)
+        if err != nil { s.writeErr(w, badRequest("%v", err)); return }
+        // Report format from ?format= or the Accept header; JSON by default
+        format := r.URL.Query().Get("format")
+        if format == "" { format = policy.FormatFromAccept(r.Header.Get("Accept")) }
+        if format == "" { format = policy.FormatJSON }
+        if !slices.Contains(policy.ReportFormats, format) {
+            s.writeErr(w, badRequest("unknown format %q (use one of %s)", format, strings.Join(policy.ReportFormats, ", "))); return
+        }
+        cfg, err := policy.Load(file)
+        if errors.Is(err, fs.ErrNotExist) { s.writeErr(w, notFound("policy file %s not found", file)); return }
+        if err != nil { s.writeErr(w, invalid(err)); return }
+        targets := []policy.Target{{Dir: "."}}
+        if r.URL.Query().Get("all") == "true" {
+            snap, err := s.repos.get(r.URL.Query().Get("path"), freshRequested(r))
+            if err != nil { s.writeErr(w, err); return }
+            repos := snap.Repos()
+            targets = make([]policy.Target, len(repos))
+            for i, repo := range repos {
+                targets[i] = policy.Target{Name: repo.Name, Dir: repo.Path}
+            }
+        }
+        var timeout time.Duration
+        if v := r.URL.Query().Get("timeout"); v != "" {
+            if timeout, err = time.ParseDuration(v); err != nil { s.writeErr(w, badRequest("invalid timeout %q", v)); return }
+        }
+        report, err := policy.Run(r.Context(), cfg, targets, policy.Options{Workers: s.workerCount, Timeout: timeout, FailOn: th})
         if err != nil { s.writeErr(w, err); return }
-        shouldFail := policy.FailIfAboveSeverity(report, th, cfg)
+        report.File = file
+        s.events.Publish(events.PolicyFailures(report, targets)...)
+        if format != policy.FormatJSON {
+            w.Header().Set("Content-Type", policy.ContentType(format))
+            w.WriteHeader(http.StatusOK)
+            _ = report.Write(w, format)
+            return
+        }
         s.writeJSONVersioned(w, r, http.StatusOK, map[string]interface{}{
             "report": report,
-            "failed_threshold": shouldFail,
+            "failed_threshold": report.FailedThreshold,
         })
-    }))
-
-    mux.HandleFunc("/v1/exec", s.wrapAuth(func(w http.ResponseWriter, r *http.Request) {
-        // Accept command via query param or POST JSON body {"cmd":"..."}
-        cmdStr := r.URL.Query().Get("cmd")
-        if cmdStr == "" && r.Method == http.MethodPost {
-            var body struct{ Cmd string `json:"cmd"` }
-            if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
-                cmdStr = body.Cmd
+    })
+
+    s.handle(mux, "/v1/commands", func(w http.ResponseWriter, r *http.Request) {
+        s.writeJSONVersioned(w, r, http.StatusOK, map[string]interface{}{
+            "commands": s.cfg.Commands,
+            "raw_exec": s.rawExecAllowed(),
+        })
+    })
+
+    s.handle(mux, "/v1/exec", s.handleExec)
+
+    s.handle(mux, "/v1/manifest", func(w http.ResponseWriter, r *http.Request) {
+        // GET exports the workspace manifest; POST applies a manifest body
+        root := r.URL.Query().Get("path")
+        snap, err := s.repos.get(root, r.Method == http.MethodPost || freshRequested(r))
+        if err != nil { s.writeErr(w, err); return }
+        repos := snap.Repos()
+        if r.Method != http.MethodPost {
+            if notModified(w, r, snap) { return }
+            s.writeJSONVersioned(w, r, http.StatusOK, manifest.Build(repos, s.cfg, root, s.workerCount))
+            return
+        }
+        data, err := io.ReadAll(r.Body)
+        if err != nil { s.writeErr(w, badRequest("reading body: %v", err)); return }
+        m, err := manifest.Parse(data)
+        if err != nil { s.writeErr(w, invalid(err)); return }
+        dryRun := r.URL.Query().Get("dry_run") == "true"
+        report := manifest.Apply(r.Context(), m, repos, s.cfg, s.workerCount, dryRun)
+        if !dryRun { s.repos.invalidate() }
+        s.writeJSONVersioned(w, r, http.StatusOK, map[string]interface{}{
+            "cloned": report.Cloned,
+            "present": report.Present,
+            "failed": report.Failed,
+            "results": report.Results,
+            "extra": report.Extra,
+        })
+    })
+
+    s.handle(mux, "/v1/events", func(w http.ResponseWriter, r *http.Request) {
+        if s.events == nil { s.writeErr(w, notFound("events are not enabled on this server")); return }
+        var types []string
+        if v := r.URL.Query().Get("types"); v != "" {
+            for _, t := range strings.Split(v, ",") {
+                t = strings.TrimSpace(t)
+                if !events.ValidType(t) { s.writeErr(w, badRequest("unknown event type %q (use %s)", t, strings.Join(events.Types, ", "))); return }
+                types = append(types, t)
             }
         }
-        if cmdStr == "" { s.writeErr(w, fmt.Errorf("missing cmd")); return }
-
-        scanner := scan.New(s.cfg, s.workerCount)
-        path := r.URL.Query().Get("path")
-        account := r.URL.Query().Get("account")
-        dirty := r.URL.Query().Get("dirty") == "true"
-        timeoutSec, _ := strconv.Atoi(r.URL.Query().Get("timeout"))
-        repos, err := scanner.Scan(path)
-        if err != nil { s.writeErr(w, err); return }
-        if dirty { repos = filterDirty(repos) }
-        if account != "" { repos = filterByAccount(repos, account) }
-        results := runner.ExecInRepos(repos, cmdStr, time.Duration(timeoutSec)*time.Second)
-        s.writeJSONVersioned(w, r, http.StatusOK, map[string]interface{}{"results": results})
-    }))
+        // A reconnect resumes after the last event received; after replays
+        // the log from an event ID. Otherwise only new events are sent.
+        after := r.Header.Get("Last-Event-ID")
+        if after == "" { after = r.URL.Query().Get("after") }
+        sseStart(w)
+        w.WriteHeader(http.StatusOK)
+        if f, ok := w.(http.Flusher); ok { f.Flush() }
+        for e, err := range s.events.Log().Follow(r.Context(), after) {
+            if err != nil { log.Printf("events: %v", err); return }
+            if !e.Match(types) { continue }
+            if err := sseData(w, e, e.Type, e.ID); err != nil { return }
+        }
+    })
 
-    // Optional CORS support for dashboard dev
-    handler := http.Handler(mux)
-    if v := getenv("DS_CORS"); v == "1" || v == "true" { s.corsEnabled = true }
-    if s.corsEnabled {
-        handler = s.wrapCORS(handler)
+    for _, rt := range api.Routes {
+        if _, ok := s.routes[rt.Path]; !ok { panic("server: no handler for " + rt.Method + " " + rt.Path) }
     }
-    srv := &http.Server{Addr: addr, Handler: handler}
-    log.Printf("ds serve listening on %s", addr)
-    return srv.ListenAndServe()
+
+    // CORS for browser dashboards on allowed origins
+    if s.corsEnabled() { return s.wrapCORS(mux) }
+    return mux
+}
+
+// Start serves the API on addr: host:port, tcp://host:port or
+// unix:///path/to/ds.sock. It returns after an interrupt or SIGTERM.
+func (s *Server) Start(addr string) error {
+    handler := s.Handler()
+    ln, err := listen(addr)
+    if err != nil { return err }
+    scheme := "http"
+    if s.tls != nil {
+        tlsCfg, fingerprint, err := loadTLS(*s.tls)
+        if err != nil { ln.Close(); return err }
+        ln = tls.NewListener(ln, tlsCfg)
+        scheme = "https"
+        log.Printf("TLS certificate SHA-256 fingerprint: %s", fingerprint)
+    }
+    srv := &http.Server{Handler: handler}
+
+    // Shut down cleanly on interrupt so a Unix socket is removed
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+    go func() {
+        <-ctx.Done()
+        shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+        defer cancel()
+        _ = srv.Shutdown(shutdownCtx)
+    }()
+
+    if strings.HasPrefix(addr, "unix://") {
+        log.Printf("ds serve listening on %s", addr)
+    } else {
+        log.Printf("ds serve listening on %s://%s", scheme, ln.Addr())
+    }
+    err = srv.Serve(ln)
+    s.events.Close() // Let webhook deliveries finish
+    if err != nil && err != http.ErrServerClosed { return err }
+    return nil
 }
 
 func (s *Server) writeJSON(w http.ResponseWriter, code int, v interface{}) {
@@ -350,20 +481,22 @@ func (s *Server) writeJSON(w http.ResponseWriter, code int, v interface{}) {
     }
 }
 
-func (s *Server) writeErr(w http.ResponseWriter, err error) {
-    s.writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"ok": false, "error": err.Error()})
-}
 
-// local filter helpers (avoid importing from cmd)
-func filterDirty(repos []scan.Repository) []scan.Repository {
-    var out []scan.Repository
-    for _, r := range repos { if !r.IsClean { out = append(out, r) } }
-    return out
+// selectRepos returns the cached snapshot for ?path= and its repositories
+// filtered by the query's repository filters (see filterRepos)
+func (s *Server) selectRepos(r *http.Request, fresh bool) (*snapshot, []scan.Repository, error) {
+    q := r.URL.Query()
+    snap, err := s.repos.get(q.Get("path"), fresh)
+    if err != nil { return nil, nil, err }
+    return snap, filterRepos(snap.Repos(), q), nil
 }
-func filterByAccount(repos []scan.Repository, account string) []scan.Repository {
-    var out []scan.Repository
-    for _, r := range repos { if r.Account == account { out = append(out, r) } }
-    return out
+
+// rescanAfterFetch rescans r's path in the background so that events for
+// newly fetched upstream commits go out without waiting for a request
+func (s *Server) rescanAfterFetch(r *http.Request) {
+    if s.events == nil { return }
+    path := r.URL.Query().Get("path")
+    go func() { _, _ = s.repos.get(path, true) }()
 }
 
 // SSE helpers
@@ -373,7 +506,10 @@ func sseStart(w http.ResponseWriter) {
     w.Header().Set("Connection", "keep-alive")
 }
 
-func sseData(w http.ResponseWriter, v interface{}, event string) error {
+func sseData(w http.ResponseWriter, v interface{}, event, id string) error {
+    if id != "" {
+        if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil { return err }
+    }
     if event != "" {
         if _, err := fmt.Fprintf(w, "event: %s\n", event); err != nil { return err }
     }
@@ -384,47 +520,103 @@ func sseData(w http.ResponseWriter, v interface{}, event string) error {
     return nil
 }
 
-// WithToken sets an optional bearer token; when set, all endpoints require Authorization: Bearer <token>
+// WithRawExec controls whether /v1/exec accepts raw shell commands. Raw
+// commands additionally require authentication to be enabled.
+func (s *Server) WithRawExec(allow bool) *Server { s.noRawExec = !allow; return s }
+
+// WithAuditLog appends a JSON line per /v1/exec call to path; "" disables it
+func (s *Server) WithAuditLog(path string) *Server { s.audit = &auditLog{path: path}; return s }
+
+// WithTLS serves HTTPS using cfg
+func (s *Server) WithTLS(cfg TLSConfig) *Server { s.tls = &cfg; return s }
+
+// WithToken sets an optional bearer token with every scope; when set, all endpoints require Authorization: Bearer <token>
 func (s *Server) WithToken(token string) *Server { s.token = token; return s }
 
-// wrapAuth enforces bearer token when configured
-func (s *Server) wrapAuth(h http.HandlerFunc) http.HandlerFunc {
-    return func(w http.ResponseWriter, r *http.Request) {
-        if s.token != "" {
-            auth := r.Header.Get("Authorization")
-            expected := "Bearer " + s.token
-            if auth != expected {
-                w.Header().Set("WWW-Authenticate", "Bearer")
-                http.Error(w, "Unauthorized", http.StatusUnauthorized)
-                return
-            }
+// WithTokenStore accepts the scoped tokens in store. A token file that
+// exists at startup, or gains a token later, enables authentication on all
+// endpoints.
+func (s *Server) WithTokenStore(store *auth.Store) *Server {
+    s.tokens = store
+    if store != nil && store.Exists() { s.authOn.Store(true) }
+    return s
+}
+
+// authEnabled reports whether requests must carry a bearer token. Once on,
+// auth stays on: revoking the last token or deleting the token file leaves
+// every request rejected rather than opening the server.
+func (s *Server) authEnabled() bool {
+    if s.token != "" || s.authOn.Load() { return true }
+    if s.tokens != nil && !s.tokens.Empty() {
+        s.authOn.Store(true)
+        return true
+    }
+    return false
+}
+
+type tokenNameKey struct{}
+
+// tokenName returns the name of the token that authenticated r
+func tokenName(r *http.Request) string {
+    name, _ := r.Context().Value(tokenNameKey{}).(string)
+    return name
+}
+
+// handle registers h for every method api.Routes defines at path, each
+// behind auth for its route's scope. Other methods get 405 with an Allow
+// header. Paths missing from api.Routes are a programming error.
+func (s *Server) handle(mux *http.ServeMux, path string, h http.HandlerFunc) {
+    methods := api.Methods(path)
+    if len(methods) == 0 { panic("server: " + path + " is not in api.Routes") }
+    paths := []string{path}
+    // Every /v1/ route is also served under /v2/, which selects ds.v2
+    if rest, ok := strings.CutPrefix(path, "/v1/"); ok { paths = append(paths, "/v2/"+rest) }
+    for _, p := range paths {
+        s.routes[p] = methods
+        for _, rt := range api.Routes {
+            if rt.Path == path { mux.HandleFunc(rt.Method+" "+p, wrapSchema(s.wrapAuth(rt.Scope, h))) }
         }
-        h(w, r)
+        mux.HandleFunc(p, wrapSchema(s.methodFallback(methods)))
     }
 }
 
-// wrapCORS enables permissive CORS for local dashboards
-func (s *Server) wrapCORS(next http.Handler) http.Handler {
-    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-        w.Header().Set("Access-Control-Allow-Origin", "*")
-        w.Header().Set("Access-Control-Allow-Methods", "GET,POST,OPTIONS")
-        w.Header().Set("Access-Control-Allow-Headers", "Authorization,Content-Type,Accept")
-        if r.Method == http.MethodOptions {
-            w.WriteHeader(http.StatusNoContent)
-            return
+// wrapAuth requires a bearer token granting scope when auth is enabled
+func (s *Server) wrapAuth(scope string, h http.HandlerFunc) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        name, ok := s.authorize(w, r, scope)
+        if !ok { return }
+        if name != "" {
+            r = r.WithContext(context.WithValue(r.Context(), tokenNameKey{}, name))
         }
-        next.ServeHTTP(w, r)
-    })
+        h(w, r)
+    }
 }
 
-// getenv is a tiny wrapper to avoid importing os repeatedly
-func getenv(k string) string {
-    if v := getEnv(k); v != "" { return v }
-    return ""
+// authorize checks r's bearer token for scope, writing 401 or 403 when it
+// is missing, unknown, expired or lacks the scope. It returns the token's
+// name ("serve" for the --token secret).
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, scope string) (string, bool) {
+    if !s.authEnabled() { return "", true }
+    unauthorized := func(challenge, msg string) (string, bool) {
+        w.Header().Set("WWW-Authenticate", challenge)
+        s.writeErr(w, &APIError{Status: http.StatusUnauthorized, Code: CodeUnauthorized, Message: msg})
+        return "", false
+    }
+    secret, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+    if !found || secret == "" { return unauthorized("Bearer", "missing bearer token") }
+    if s.token != "" && auth.Equal(secret, s.token) { return "serve", true }
+    if s.tokens == nil { return unauthorized(`Bearer error="invalid_token"`, "invalid token") }
+    tok, err := s.tokens.Verify(secret, time.Now())
+    if err != nil { return unauthorized(`Bearer error="invalid_token"`, err.Error()) }
+    if !tok.Allows(scope) {
+        w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, scope))
+        s.writeErr(w, forbidden("token %q lacks the %s scope", tok.Name, scope))
+        return "", false
+    }
+    return tok.Name, true
 }
 
-// indirection for testing
-var getEnv = func(k string) string { return os.Getenv(k) }
+
 
 // writeJSONMaybeEnvelope writes either raw JSON or an envelope with schema_version and data
 func (s *Server) writeJSONMaybeEnvelope(w http.ResponseWriter, r *http.Request, code int, v interface{}) {
@@ -432,19 +624,22 @@ func (s *Server) writeJSONMaybeEnvelope(w http.ResponseWriter, r *http.Request,
     s.writeJSONVersioned(w, r, code, v)
 }
 
-// writeJSONVersioned ensures a top-level schema_version. Arrays are wrapped as {schema_version, data}.
+// writeJSONVersioned ensures a top-level schema_version and converts v to
+// the negotiated schema. Arrays are wrapped as {schema_version, data}.
 func (s *Server) writeJSONVersioned(w http.ResponseWriter, r *http.Request, code int, v interface{}) {
+    version := responseSchema(w)
+    v = toWire(v, version)
     // If already a map, attach schema_version if missing
     if m, ok := v.(map[string]interface{}); ok {
         if _, exists := m["schema_version"]; !exists {
-            m["schema_version"] = "ds.v1"
+            m["schema_version"] = version
         }
         s.writeJSON(w, code, m)
         return
     }
     // Default: wrap
     s.writeJSON(w, code, map[string]interface{}{
-        "schema_version": "ds.v1",
+        "schema_version": version,
         "data":           v,
     })
 }
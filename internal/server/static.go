@@ -7,6 +7,10 @@ import (
     _ "embed"
 )
 
+//go:generate go run ../../cmd/ds openapi -f openapi.yaml
+
+// openAPISpec is generated from internal/api; the dsclient drift test
+// fails when it is stale
 //go:embed openapi.yaml
 var openAPISpec []byte
 
@@ -0,0 +1,294 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/verlyn13/ds-go/internal/config"
+	"github.com/verlyn13/ds-go/internal/scan"
+)
+
+// Column is a status table column
+type Column struct {
+	Key    string
+	Header string
+	Flex   bool // Shrinks to fit the terminal width
+	value  func(repo scan.Repository) string
+}
+
+// DefaultColumns is the column set used when none is configured
+var DefaultColumns = []string{"icon", "name", "status", "changes", "sync", "last_commit"}
+
+// Columns lists every available status column
+var Columns = []Column{
+	{Key: "icon", value: repoIcon},
+	{Key: "name", Header: "Repository", Flex: true, value: func(r scan.Repository) string { return r.Name }},
+	{Key: "account", Header: "Account", value: func(r scan.Repository) string { return r.Account }},
+	{Key: "folder", Header: "Folder", value: func(r scan.Repository) string { return r.FolderName }},
+	{Key: "branch", Header: "Branch", Flex: true, value: func(r scan.Repository) string { return r.Branch }},
+	{Key: "status", Header: "Status", value: repoStatus},
+	{Key: "changes", Header: "Changes", value: repoChanges},
+	{Key: "sync", Header: "Sync", value: repoSync},
+	{Key: "last_commit", Header: "Last Commit", Flex: true, value: repoLastCommit},
+	{Key: "tags", Header: "Tags", Flex: true, value: func(r scan.Repository) string { return strings.Join(r.Tags, ",") }},
+	{Key: "path", Header: "Path", Flex: true, value: func(r scan.Repository) string { return r.Path }},
+	{Key: "remote", Header: "Remote", Flex: true, value: func(r scan.Repository) string { return r.RemoteURL }},
+}
+
+// flexOrder is the order in which flexible columns give up width
+var flexOrder = []string{"last_commit", "path", "remote", "tags", "branch", "name"}
+
+// minFlexWidth is the narrowest a flexible column is truncated to
+const minFlexWidth = 10
+
+// GroupKeys lists the accepted grouping modes
+var GroupKeys = []string{"account", "folder", "tag", "none"}
+
+// TableOptions controls the status table layout
+type TableOptions struct {
+	Columns []string
+	SortBy  string
+	GroupBy string
+	Width   int // Terminal width; 0 detects it, negative disables truncation
+}
+
+// WithDefaults fills unset options from the display config, then built-in defaults
+func (o TableOptions) WithDefaults(cfg *config.Config) TableOptions {
+	if cfg != nil {
+		if len(o.Columns) == 0 {
+			o.Columns = cfg.Display.Columns
+		}
+		if o.SortBy == "" {
+			o.SortBy = cfg.Display.SortBy
+		}
+		if o.GroupBy == "" {
+			o.GroupBy = cfg.Display.GroupBy
+		}
+	}
+	if len(o.Columns) == 0 {
+		o.Columns = DefaultColumns
+	}
+	if o.SortBy == "" {
+		o.SortBy = "name"
+	}
+	if o.GroupBy == "" {
+		o.GroupBy = "account"
+	}
+	return o
+}
+
+// Validate checks column, sort and group keys
+func (o TableOptions) Validate() error {
+	for _, key := range o.Columns {
+		if _, ok := columnByKey(key); !ok {
+			return fmt.Errorf("unknown column %q (use one of %s)", key, strings.Join(columnKeys(), ", "))
+		}
+	}
+	if o.SortBy != "" && !scan.ValidSortKey(o.SortBy) {
+		return fmt.Errorf("unknown sort key %q (use one of %s)", o.SortBy, strings.Join(scan.SortKeys, ", "))
+	}
+	if o.GroupBy != "" && !contains(GroupKeys, o.GroupBy) {
+		return fmt.Errorf("unknown group %q (use one of %s)", o.GroupBy, strings.Join(GroupKeys, ", "))
+	}
+	return nil
+}
+
+// repoGroup is a titled set of repositories in the status table
+type repoGroup struct {
+	name  string
+	repos []scan.Repository
+}
+
+// groupRepos splits repositories into sorted groups, preserving the order of
+// repositories within each group. With tag grouping, a repository appears
+// under each of its tags.
+func groupRepos(repos []scan.Repository, by string) []repoGroup {
+	if by == "none" {
+		return []repoGroup{{repos: repos}}
+	}
+
+	grouped := make(map[string][]scan.Repository)
+	for _, repo := range repos {
+		var keys []string
+		switch by {
+		case "folder":
+			keys = []string{repo.FolderName}
+		case "tag":
+			keys = repo.Tags
+			if len(keys) == 0 {
+				keys = []string{"(untagged)"}
+			}
+		default:
+			keys = []string{repo.Account}
+		}
+		for _, k := range keys {
+			grouped[k] = append(grouped[k], repo)
+		}
+	}
+
+	groups := make([]repoGroup, 0, len(grouped))
+	for name, rs := range grouped {
+		groups = append(groups, repoGroup{name: name, repos: rs})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].name < groups[j].name })
+	return groups
+}
+
+// fitColumns returns the maximum display width of each column so that a row
+// fits in width cells. Flexible columns shrink first; 0 means unlimited.
+func fitColumns(cols []Column, rows [][]string, width int) []int {
+	limits := make([]int, len(cols))
+	if width <= 0 {
+		return limits
+	}
+
+	natural := make([]int, len(cols))
+	total := len(cols) - 1 // Column separators
+	for i, c := range cols {
+		natural[i] = text.RuneWidthWithoutEscSequences(c.Header)
+		for _, row := range rows {
+			if w := text.RuneWidthWithoutEscSequences(row[i]); w > natural[i] {
+				natural[i] = w
+			}
+		}
+		total += natural[i] + 2 // Cell padding
+	}
+
+	over := total - width
+	for _, key := range flexOrder {
+		if over <= 0 {
+			break
+		}
+		for i, c := range cols {
+			if c.Key != key || !c.Flex {
+				continue
+			}
+			floor := max(minFlexWidth, text.RuneWidthWithoutEscSequences(c.Header))
+			if natural[i] <= floor {
+				continue
+			}
+			cut := min(over, natural[i]-floor)
+			limits[i] = natural[i] - cut
+			over -= cut
+		}
+	}
+	return limits
+}
+
+// ellipsize truncates s to width display cells, keeping escape sequences
+func ellipsize(s string, width int) string {
+	if width <= 0 || text.RuneWidthWithoutEscSequences(s) <= width {
+		return s
+	}
+	return text.Trim(s, width-1) + "…"
+}
+
+// TerminalWidth reports the width of stdout, from $COLUMNS or the terminal
+// itself. It returns 0 when stdout is not a terminal.
+func TerminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	if !term.IsTerminal(os.Stdout.Fd()) {
+		return 0
+	}
+	w, _, err := term.GetSize(os.Stdout.Fd())
+	if err != nil {
+		return 0
+	}
+	return w
+}
+
+func repoIcon(repo scan.Repository) string {
+	switch {
+	case !repo.IsClean:
+		return ColorYellow + "●" + ColorReset
+	case repo.Ahead > 0:
+		return ColorBlue + "↑" + ColorReset
+	case repo.Behind > 0:
+		return ColorCyan + "↓" + ColorReset
+	default:
+		return ColorGreen + "✓" + ColorReset
+	}
+}
+
+func repoStatus(repo scan.Repository) string {
+	if !repo.IsClean {
+		return fmt.Sprintf("%s%d files%s", ColorYellow, repo.Uncommitted, ColorReset)
+	}
+	return "clean"
+}
+
+func repoChanges(repo scan.Repository) string {
+	var changes []string
+	if repo.HasStash {
+		changes = append(changes, ColorPurple+"stash"+ColorReset)
+	}
+	return strings.Join(changes, " ")
+}
+
+// repoSync renders ahead/behind counts compactly
+func repoSync(repo scan.Repository) string {
+	var sync string
+	if repo.Ahead > 0 || repo.Behind > 0 {
+		if repo.Ahead > 0 {
+			sync += fmt.Sprintf("%s↑%d%s", ColorBlue, repo.Ahead, ColorReset)
+		}
+		if repo.Behind > 0 {
+			if sync != "" {
+				sync += " "
+			}
+			sync += fmt.Sprintf("%s↓%d%s", ColorCyan, repo.Behind, ColorReset)
+		}
+	} else if !repo.HasUpstream {
+		sync = ColorGray + "no upstream" + ColorReset
+	} else {
+		sync = "synced"
+	}
+	return sync
+}
+
+// repoLastCommit renders the last commit, flagging stale fetch data
+func repoLastCommit(repo scan.Repository) string {
+	lastCommit := repo.LastCommit
+	if repo.LastFetch != nil {
+		age := time.Since(*repo.LastFetch)
+		if age > time.Hour {
+			hours := int(age.Hours())
+			lastCommit = fmt.Sprintf("%s(%dh old)%s %s", ColorGray, hours, ColorReset, lastCommit)
+		}
+	}
+	return lastCommit
+}
+
+func columnByKey(key string) (Column, bool) {
+	for _, c := range Columns {
+		if c.Key == key {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+func columnKeys() []string {
+	keys := make([]string, len(Columns))
+	for i, c := range Columns {
+		keys[i] = c.Key
+	}
+	return keys
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,660 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/verlyn13/ds-go/internal/config"
+	"github.com/verlyn13/ds-go/internal/git"
+	"github.com/verlyn13/ds-go/internal/runner"
+	"github.com/verlyn13/ds-go/internal/scan"
+)
+
+// DashboardOptions configures the interactive dashboard
+type DashboardOptions struct {
+	ScanPath string
+	Workers  int
+	Refresh  time.Duration // Rescan interval; 0 disables live updates
+}
+
+// Dashboard styles build on the table styles above
+var (
+	selectedStyle = lipgloss.NewStyle().
+			Reverse(true)
+
+	paneStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("8")).
+			Padding(0, 1)
+
+	helpStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8"))
+)
+
+// dashboardSorts are the sort keys cycled with 's'
+var dashboardSorts = []string{"name", "account", "dirty", "behind", "ahead", "last_commit"}
+
+type (
+	reposMsg struct {
+		repos []scan.Repository
+		err   error
+	}
+	detailsMsg struct {
+		path    string
+		details *git.RepoDetails
+		err     error
+	}
+	actionMsg struct {
+		text   string
+		err    error
+		rescan bool
+	}
+	tickMsg time.Time
+)
+
+type dashboard struct {
+	cfg     *config.Config
+	opts    DashboardOptions
+	gitCli  *git.Git
+	fetcher *scan.Fetcher
+
+	all  []scan.Repository
+	rows []scan.Repository
+
+	cursor, offset int
+	sortIdx        int
+	filter         string
+	filtering      bool
+	dirtyOnly      bool
+
+	details    *git.RepoDetails
+	detailsFor string
+	detailsErr error
+
+	commands  []string
+	picking   bool
+	cmdCursor int
+
+	width, height int
+	scanning      bool
+	status        string
+	lastScan      time.Time
+}
+
+// RunDashboard starts the full-screen interactive dashboard
+func RunDashboard(cfg *config.Config, opts DashboardOptions) error {
+	if opts.Workers <= 0 {
+		opts.Workers = 10
+	}
+	m := &dashboard{
+		cfg:     cfg,
+		opts:    opts,
+		gitCli:  git.New(),
+		fetcher: scan.NewFetcher(opts.Workers),
+	}
+	for name := range cfg.Commands {
+		m.commands = append(m.commands, name)
+	}
+	sort.Strings(m.commands)
+
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+func (m *dashboard) Init() tea.Cmd {
+	m.scanning = true
+	return tea.Batch(m.scanCmd(), m.tickCmd())
+}
+
+func (m *dashboard) scanCmd() tea.Cmd {
+	cfg, opts := m.cfg, m.opts
+	return func() tea.Msg {
+		// A fresh scanner picks up fetch times recorded since the last scan
+		repos, err := scan.New(cfg, opts.Workers).Scan(opts.ScanPath)
+		return reposMsg{repos: repos, err: err}
+	}
+}
+
+func (m *dashboard) tickCmd() tea.Cmd {
+	if m.opts.Refresh <= 0 {
+		return nil
+	}
+	return tea.Tick(m.opts.Refresh, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+func (m *dashboard) detailsCmd() tea.Cmd {
+	repo, ok := m.selected()
+	if !ok || repo.Path == m.detailsFor {
+		return nil
+	}
+	m.detailsFor = repo.Path
+	m.details = nil
+	path, g := repo.Path, m.gitCli
+	return func() tea.Msg {
+		d, err := g.GetDetails(path, 10)
+		return detailsMsg{path: path, details: d, err: err}
+	}
+}
+
+func (m *dashboard) selected() (scan.Repository, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return scan.Repository{}, false
+	}
+	return m.rows[m.cursor], true
+}
+
+// applyView filters and sorts the scanned repositories, keeping the
+// cursor on the same repository when possible
+func (m *dashboard) applyView() {
+	current := ""
+	if repo, ok := m.selected(); ok {
+		current = repo.Path
+	}
+
+	needle := strings.ToLower(m.filter)
+	rows := make([]scan.Repository, 0, len(m.all))
+	for _, r := range m.all {
+		if m.dirtyOnly && r.IsClean {
+			continue
+		}
+		if needle != "" && !strings.Contains(strings.ToLower(r.Name+" "+r.Account+" "+r.Branch+" "+strings.Join(r.Tags, " ")), needle) {
+			continue
+		}
+		rows = append(rows, r)
+	}
+	scan.SortRepos(rows, dashboardSorts[m.sortIdx])
+	m.rows = rows
+
+	m.cursor = 0
+	for i, r := range rows {
+		if r.Path == current {
+			m.cursor = i
+			break
+		}
+	}
+	m.clampCursor()
+}
+
+func (m *dashboard) listHeight() int {
+	// Header, column header, footer and status lines
+	h := m.height - 5
+	if h < 1 {
+		h = 1
+	}
+	return h
+}
+
+func (m *dashboard) clampCursor() {
+	if m.cursor >= len(m.rows) {
+		m.cursor = len(m.rows) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if h := m.listHeight(); m.cursor >= m.offset+h {
+		m.offset = m.cursor - h + 1
+	}
+}
+
+func (m *dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.clampCursor()
+		return m, nil
+
+	case reposMsg:
+		m.scanning = false
+		if msg.err != nil {
+			m.status = "scan failed: " + msg.err.Error()
+			return m, nil
+		}
+		m.all = msg.repos
+		m.lastScan = time.Now()
+		m.applyView()
+		// Force detail refresh; the selected repo may have changed on disk
+		m.detailsFor = ""
+		return m, m.detailsCmd()
+
+	case detailsMsg:
+		if msg.path == m.detailsFor {
+			m.details, m.detailsErr = msg.details, msg.err
+		}
+		return m, nil
+
+	case actionMsg:
+		m.status = msg.text
+		if msg.err != nil {
+			m.status = msg.text + ": " + msg.err.Error()
+		}
+		if msg.rescan && !m.scanning {
+			m.scanning = true
+			return m, m.scanCmd()
+		}
+		return m, nil
+
+	case tickMsg:
+		if m.scanning {
+			return m, m.tickCmd()
+		}
+		m.scanning = true
+		return m, tea.Batch(m.scanCmd(), m.tickCmd())
+
+	case tea.KeyMsg:
+		if m.filtering {
+			return m.updateFilter(msg)
+		}
+		if m.picking {
+			return m.updatePicker(msg)
+		}
+		return m.updateKeys(msg)
+	}
+	return m, nil
+}
+
+func (m *dashboard) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.filtering = false
+		m.filter = ""
+	case tea.KeyEnter:
+		m.filtering = false
+	case tea.KeyBackspace:
+		if r := []rune(m.filter); len(r) > 0 {
+			m.filter = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.filter += string(msg.Runes)
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	}
+	m.applyView()
+	return m, m.detailsCmd()
+}
+
+func (m *dashboard) updatePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.picking = false
+	case "up", "k":
+		if m.cmdCursor > 0 {
+			m.cmdCursor--
+		}
+	case "down", "j":
+		if m.cmdCursor < len(m.commands)-1 {
+			m.cmdCursor++
+		}
+	case "enter":
+		m.picking = false
+		repo, ok := m.selected()
+		if !ok {
+			return m, nil
+		}
+		name := m.commands[m.cmdCursor]
+		command, err := m.cfg.Commands[name].Render(nil)
+		if err != nil {
+			m.status = fmt.Sprintf("%s: %v", name, err)
+			return m, nil
+		}
+		m.status = fmt.Sprintf("running %s in %s…", name, repo.Name)
+		return m, func() tea.Msg {
+			res := runner.ExecInRepos([]scan.Repository{repo}, command, 0)[0]
+			if !res.Success {
+				return actionMsg{text: fmt.Sprintf("%s failed in %s", name, repo.Name), err: fmt.Errorf("%s", res.Error), rescan: true}
+			}
+			return actionMsg{text: fmt.Sprintf("✓ %s succeeded in %s (%dms)", name, repo.Name, res.DurationMs), rescan: true}
+		}
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m *dashboard) updateKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "up", "k":
+		m.cursor--
+	case "down", "j":
+		m.cursor++
+	case "pgup":
+		m.cursor -= m.listHeight()
+	case "pgdown":
+		m.cursor += m.listHeight()
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.cursor = len(m.rows) - 1
+	case "s":
+		m.sortIdx = (m.sortIdx + 1) % len(dashboardSorts)
+		m.applyView()
+	case "/":
+		m.filtering = true
+		return m, nil
+	case "d":
+		m.dirtyOnly = !m.dirtyOnly
+		m.applyView()
+	case "r":
+		if !m.scanning {
+			m.scanning = true
+			m.status = "rescanning…"
+			return m, m.scanCmd()
+		}
+	case "f":
+		return m, m.fetchSelected()
+	case "F":
+		return m, m.fetchAll()
+	case "p":
+		return m, m.pullSelected()
+	case "o":
+		return m, m.openIn(shellCommand())
+	case "e":
+		return m, m.openIn(editorCommand())
+	case "x":
+		if len(m.commands) == 0 {
+			m.status = "no saved commands (add a commands: section to config.yaml)"
+			return m, nil
+		}
+		m.picking = true
+		m.cmdCursor = 0
+		return m, nil
+	}
+	m.clampCursor()
+	return m, m.detailsCmd()
+}
+
+func (m *dashboard) fetchSelected() tea.Cmd {
+	repo, ok := m.selected()
+	if !ok {
+		return nil
+	}
+	m.status = "fetching " + repo.Name + "…"
+	fetcher := m.fetcher
+	return func() tea.Msg {
+		res := fetcher.FetchSingle(repo)
+		return actionMsg{text: fmt.Sprintf("fetched %s (%.1fs)", repo.Name, res.Duration.Seconds()), err: res.Error, rescan: true}
+	}
+}
+
+func (m *dashboard) fetchAll() tea.Cmd {
+	repos := m.rows
+	m.status = fmt.Sprintf("fetching %d repositories…", len(repos))
+	fetcher := m.fetcher
+	return func() tea.Msg {
+		results := fetcher.FetchAll(repos, false)
+		var failed int
+		for _, r := range results {
+			if r.Error != nil {
+				failed++
+			}
+		}
+		return actionMsg{text: fmt.Sprintf("fetched %d repositories, %d failed", len(results), failed), rescan: true}
+	}
+}
+
+func (m *dashboard) pullSelected() tea.Cmd {
+	repo, ok := m.selected()
+	if !ok {
+		return nil
+	}
+	m.status = "pulling " + repo.Name + "…"
+	g := m.gitCli
+	return func() tea.Msg {
+		err := g.Pull(repo.Path)
+		return actionMsg{text: "pulled " + repo.Name, err: err, rescan: true}
+	}
+}
+
+// openIn runs name with args in the selected repository, reporting it as
+// label when it exits
+func (m *dashboard) openIn(label, name string, args []string) tea.Cmd {
+	repo, ok := m.selected()
+	if !ok {
+		return nil
+	}
+	c := exec.Command(name, args...)
+	c.Dir = repo.Path
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		return actionMsg{text: "returned from " + label, err: err, rescan: true}
+	})
+}
+
+func shellCommand() (string, string, []string) {
+	if sh := os.Getenv("SHELL"); sh != "" {
+		return sh, sh, nil
+	}
+	return "/bin/sh", "/bin/sh", nil
+}
+
+// editorCommand runs $VISUAL or $EDITOR through the shell, as git does, so
+// values with arguments such as "code -w" work
+func editorCommand() (string, string, []string) {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if ed := os.Getenv(env); ed != "" {
+			return ed, "/bin/sh", []string{"-c", ed + ` "$@"`, ed, "."}
+		}
+	}
+	return "vi", "vi", []string{"."}
+}
+
+func (m *dashboard) View() string {
+	if m.width == 0 {
+		return "loading…"
+	}
+
+	listWidth := m.width * 3 / 5
+	if listWidth < 40 {
+		listWidth = m.width
+	}
+	detailWidth := m.width - listWidth - 4 // Border and padding
+
+	var b strings.Builder
+	b.WriteString(m.renderHeader())
+	b.WriteString("\n")
+
+	list := m.renderList(listWidth)
+	if detailWidth >= 20 {
+		detail := paneStyle.Width(detailWidth).Height(m.listHeight()).Render(m.renderDetails(detailWidth))
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, list, detail))
+	} else {
+		b.WriteString(list)
+	}
+	b.WriteString("\n")
+	b.WriteString(m.renderFooter())
+	return b.String()
+}
+
+func (m *dashboard) renderHeader() string {
+	var dirty, behind int
+	for _, r := range m.all {
+		if !r.IsClean {
+			dirty++
+		}
+		if r.Behind > 0 {
+			behind++
+		}
+	}
+	header := fmt.Sprintf("📊 ds — %d repos | %s | %s | sort: %s",
+		len(m.all),
+		dirtyStyle.Render(fmt.Sprintf("%d changes", dirty)),
+		behindStyle.Render(fmt.Sprintf("%d behind", behind)),
+		dashboardSorts[m.sortIdx])
+	if m.dirtyOnly {
+		header += " | dirty only"
+	}
+	if m.filter != "" || m.filtering {
+		header += " | filter: " + m.filter
+	}
+	if m.scanning {
+		header += " | scanning…"
+	}
+	return headerStyle.Render(header)
+}
+
+func (m *dashboard) renderList(width int) string {
+	nameWidth := width - 38
+	if nameWidth < 12 {
+		nameWidth = 12
+	}
+
+	var b strings.Builder
+	b.WriteString(headerStyle.Render(padRight(fmt.Sprintf("  %-*s %-14s %-8s %s", nameWidth, "Repository", "Branch", "Status", "Sync"), width)))
+	b.WriteString("\n")
+
+	if len(m.rows) == 0 {
+		b.WriteString(fetchWarningStyle.Render("  no repositories match"))
+		return lipgloss.NewStyle().Width(width).Height(m.listHeight() + 1).Render(b.String())
+	}
+
+	end := m.offset + m.listHeight()
+	if end > len(m.rows) {
+		end = len(m.rows)
+	}
+	for i := m.offset; i < end; i++ {
+		r := m.rows[i]
+		icon, style := "✓", cleanStyle
+		switch {
+		case !r.IsClean:
+			icon, style = "●", dirtyStyle
+		case r.Ahead > 0:
+			icon, style = "↑", aheadStyle
+		case r.Behind > 0:
+			icon, style = "↓", behindStyle
+		}
+		status := "clean"
+		if !r.IsClean {
+			status = fmt.Sprintf("%d files", r.Uncommitted)
+		}
+		line := fmt.Sprintf("%s %-*s %-14s %-8s %s", icon,
+			nameWidth, truncate(r.Name, nameWidth),
+			truncate(r.Branch, 14), status, syncText(r))
+		line = padRight(truncate(line, width), width)
+		if i == m.cursor {
+			b.WriteString(selectedStyle.Render(line))
+		} else {
+			b.WriteString(style.Render(line))
+		}
+		if i < end-1 {
+			b.WriteString("\n")
+		}
+	}
+	return lipgloss.NewStyle().Width(width).Height(m.listHeight() + 1).Render(b.String())
+}
+
+func (m *dashboard) renderDetails(width int) string {
+	if m.picking {
+		var b strings.Builder
+		b.WriteString(headerStyle.Render("Run saved command"))
+		b.WriteString("\n\n")
+		for i, name := range m.commands {
+			line := truncate(fmt.Sprintf("%s: %s", name, m.cfg.Commands[name]), width)
+			if i == m.cmdCursor {
+				line = selectedStyle.Render(line)
+			}
+			b.WriteString(line + "\n")
+		}
+		return b.String()
+	}
+
+	repo, ok := m.selected()
+	if !ok {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(headerStyle.Render(truncate(repo.Name, width)))
+	b.WriteString("\n")
+	b.WriteString(truncate(repo.Path, width) + "\n")
+	b.WriteString(truncate(repo.RemoteURL, width) + "\n")
+	b.WriteString(truncate(fmt.Sprintf("%s · %s", repo.Branch, syncText(repo)), width) + "\n")
+	if repo.LastFetch != nil {
+		b.WriteString(fetchWarningStyle.Render(fmt.Sprintf("fetched %s ago", time.Since(*repo.LastFetch).Round(time.Minute))) + "\n")
+	}
+
+	if m.detailsErr != nil {
+		b.WriteString("\n" + m.detailsErr.Error())
+		return b.String()
+	}
+	if m.details == nil {
+		b.WriteString("\nloading…")
+		return b.String()
+	}
+
+	section := func(title string, lines []string, limit int, style lipgloss.Style) {
+		b.WriteString("\n" + headerStyle.Render(fmt.Sprintf("%s (%d)", title, len(lines))) + "\n")
+		for i, l := range lines {
+			if i == limit {
+				b.WriteString(fetchWarningStyle.Render(fmt.Sprintf("  … %d more", len(lines)-limit)) + "\n")
+				break
+			}
+			b.WriteString(style.Render(truncate("  "+l, width)) + "\n")
+		}
+	}
+	section("Changed files", m.details.ChangedFiles, 8, dirtyStyle)
+	section("Branches", m.details.Branches, 6, lipgloss.NewStyle())
+	section("Stashes", m.details.Stashes, 3, stashStyle)
+	section("Recent commits", m.details.RecentCommits, 10, lipgloss.NewStyle())
+	return b.String()
+}
+
+func (m *dashboard) renderFooter() string {
+	help := "↑/↓ move · / filter · s sort · d dirty · r rescan · f fetch · F fetch all · p pull · o shell · e editor · x run · q quit"
+	if m.filtering {
+		help = "type to filter · enter keep · esc clear"
+	}
+	if m.picking {
+		help = "↑/↓ choose · enter run · esc cancel"
+	}
+	status := m.status
+	if status == "" && !m.lastScan.IsZero() {
+		status = "last scan " + m.lastScan.Format("15:04:05")
+	}
+	return helpStyle.Render(truncate(help, m.width)) + "\n" + truncate(status, m.width)
+}
+
+func syncText(r scan.Repository) string {
+	switch {
+	case r.Ahead > 0 && r.Behind > 0:
+		return fmt.Sprintf("↑%d ↓%d", r.Ahead, r.Behind)
+	case r.Ahead > 0:
+		return fmt.Sprintf("↑%d", r.Ahead)
+	case r.Behind > 0:
+		return fmt.Sprintf("↓%d", r.Behind)
+	case !r.HasUpstream:
+		return "no upstream"
+	default:
+		return "synced"
+	}
+}
+
+// truncate shortens s to at most width display cells
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+func padRight(s string, width int) string {
+	if w := lipgloss.Width(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
@@ -0,0 +1,387 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format selects how command results are rendered
+type Format string
+
+const (
+	FormatTable    Format = "table"
+	FormatJSON     Format = "json"
+	FormatNDJSON   Format = "ndjson"
+	FormatCSV      Format = "csv"
+	FormatYAML     Format = "yaml"
+	FormatMarkdown Format = "markdown"
+	FormatTemplate Format = "template"
+)
+
+// Formats lists the accepted --output values
+var Formats = []Format{FormatTable, FormatJSON, FormatNDJSON, FormatCSV, FormatYAML, FormatMarkdown, FormatTemplate}
+
+// Output is a command result handed to a Printer
+type Output struct {
+	Data   interface{}  // Full payload for the JSON envelope and YAML
+	Items  interface{}  // Records for NDJSON, CSV, Markdown and templates; defaults to Data
+	Table  func() error // Human-readable renderer for table output
+	Failed bool         // Marks the JSON envelope ok=false
+}
+
+// Printer renders command output in a single selected format
+type Printer struct {
+	Format   Format
+	Out      io.Writer
+	template *template.Template
+}
+
+// NewPrinter validates the format and parses the template, if any.
+// A non-empty template implies the template format.
+func NewPrinter(format, tmpl string) (*Printer, error) {
+	p := &Printer{Format: Format(strings.ToLower(format)), Out: os.Stdout}
+	if p.Format == "" {
+		p.Format = FormatTable
+	}
+	if p.Format == "md" {
+		p.Format = FormatMarkdown
+	}
+	if p.Format == "yml" {
+		p.Format = FormatYAML
+	}
+	if tmpl != "" {
+		p.Format = FormatTemplate
+	}
+
+	valid := false
+	for _, f := range Formats {
+		if p.Format == f {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, fmt.Errorf("unknown output format %q (use one of %s)", format, formatList())
+	}
+
+	if p.Format == FormatTemplate {
+		if tmpl == "" {
+			return nil, fmt.Errorf("template output requires --format '<go template>'")
+		}
+		t, err := template.New("output").Funcs(templateFuncs).Parse(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("parsing --format template: %w", err)
+		}
+		p.template = t
+	}
+	return p, nil
+}
+
+// IsTable reports whether human-readable output was selected
+func (p *Printer) IsTable() bool { return p.Format == FormatTable }
+
+// Print renders the output in the selected format
+func (p *Printer) Print(o Output) error {
+	items := o.Items
+	if items == nil {
+		items = o.Data
+	}
+
+	switch p.Format {
+	case FormatTable:
+		if o.Table != nil {
+			return o.Table()
+		}
+		return p.printMarkdown(items)
+	case FormatJSON:
+		enc := json.NewEncoder(p.Out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(JSONResponse{OK: !o.Failed, Data: o.Data})
+	case FormatYAML:
+		// Round-trip through JSON so YAML keys match the JSON envelope
+		generic, err := toGeneric(JSONResponse{OK: !o.Failed, Data: o.Data})
+		if err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(p.Out)
+		enc.SetIndent(2)
+		defer enc.Close()
+		return enc.Encode(generic)
+	case FormatNDJSON:
+		enc := json.NewEncoder(p.Out)
+		for _, item := range records(items) {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatCSV:
+		headers, rows := tabulate(items)
+		w := csv.NewWriter(p.Out)
+		if err := w.Write(headers); err != nil {
+			return err
+		}
+		if err := w.WriteAll(rows); err != nil {
+			return err
+		}
+		return w.Error()
+	case FormatMarkdown:
+		return p.printMarkdown(items)
+	case FormatTemplate:
+		for _, item := range records(items) {
+			var buf bytes.Buffer
+			if err := p.template.Execute(&buf, item); err != nil {
+				return err
+			}
+			if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+				buf.WriteByte('\n')
+			}
+			if _, err := p.Out.Write(buf.Bytes()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported output format: %s", p.Format)
+}
+
+// PrintError renders a command error in the selected format. It returns
+// false when the format has no structured error shape and the caller should
+// print the error itself.
+func (p *Printer) PrintError(err error) bool {
+	switch p.Format {
+	case FormatJSON:
+		enc := json.NewEncoder(p.Out)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(JSONResponse{OK: false, Error: err.Error()})
+		return true
+	case FormatYAML:
+		enc := yaml.NewEncoder(p.Out)
+		defer enc.Close()
+		_ = enc.Encode(map[string]interface{}{"ok": false, "error": err.Error()})
+		return true
+	}
+	return false
+}
+
+func (p *Printer) printMarkdown(items interface{}) error {
+	headers, rows := tabulate(items)
+	escape := func(s string) string { return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", " ") }
+
+	var b strings.Builder
+	b.WriteString("|")
+	for _, h := range headers {
+		b.WriteString(" " + escape(h) + " |")
+	}
+	b.WriteString("\n|")
+	for range headers {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+	for _, row := range rows {
+		b.WriteString("|")
+		for _, cell := range row {
+			b.WriteString(" " + escape(cell) + " |")
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(p.Out, b.String())
+	return err
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// records returns the elements of a slice, or the value itself
+func records(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		if !rv.IsValid() {
+			return nil
+		}
+		return []interface{}{v}
+	}
+	out := make([]interface{}, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out
+}
+
+// tabulate flattens records into CSV/Markdown columns. Struct fields
+// (including embedded structs) become columns named by their JSON tag;
+// maps become columns keyed by sorted map key.
+func tabulate(v interface{}) ([]string, [][]string) {
+	recs := records(v)
+	var headers []string
+	seen := map[string]bool{}
+	flat := make([]map[string]string, len(recs))
+
+	for i, rec := range recs {
+		flat[i] = map[string]string{}
+		for _, col := range flatten(reflect.ValueOf(rec)) {
+			if !seen[col.name] {
+				seen[col.name] = true
+				headers = append(headers, col.name)
+			}
+			flat[i][col.name] = col.value
+		}
+	}
+
+	rows := make([][]string, len(flat))
+	for i, f := range flat {
+		row := make([]string, len(headers))
+		for j, h := range headers {
+			row[j] = f[h]
+		}
+		rows[i] = row
+	}
+	return headers, rows
+}
+
+type column struct{ name, value string }
+
+func flatten(rv reflect.Value) []column {
+	for (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		if _, ok := rv.Interface().(time.Time); ok {
+			return []column{{"value", cellValue(rv)}}
+		}
+		var cols []column
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, skip := fieldName(f)
+			if skip {
+				continue
+			}
+			fv := rv.Field(i)
+			if f.Anonymous {
+				for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+					fv = fv.Elem()
+				}
+				if fv.Kind() == reflect.Struct {
+					cols = append(cols, flatten(fv)...)
+					continue
+				}
+			}
+			cols = append(cols, column{name, cellValue(fv)})
+		}
+		return cols
+	case reflect.Map:
+		keys := rv.MapKeys()
+		names := make([]string, 0, len(keys))
+		byName := map[string]reflect.Value{}
+		for _, k := range keys {
+			n := fmt.Sprint(k.Interface())
+			names = append(names, n)
+			byName[n] = rv.MapIndex(k)
+		}
+		sort.Strings(names)
+		cols := make([]column, 0, len(names))
+		for _, n := range names {
+			cols = append(cols, column{n, cellValue(byName[n])})
+		}
+		return cols
+	case reflect.Invalid:
+		return nil
+	default:
+		return []column{{"value", cellValue(rv)}}
+	}
+}
+
+func fieldName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name, false
+	}
+	return f.Name, false
+}
+
+// cellValue formats a single value for a table cell
+func cellValue(v reflect.Value) string {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() || ((v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil()) {
+		return ""
+	}
+	switch x := v.Interface().(type) {
+	case time.Time:
+		if x.IsZero() {
+			return ""
+		}
+		return x.Format(time.RFC3339)
+	case time.Duration:
+		return x.String()
+	case error:
+		return x.Error()
+	case fmt.Stringer:
+		return x.String()
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.String {
+			parts := make([]string, v.Len())
+			for i := range parts {
+				parts[i] = v.Index(i).String()
+			}
+			return strings.Join(parts, ";")
+		}
+		b, _ := json.Marshal(v.Interface())
+		return string(b)
+	case reflect.Map, reflect.Struct:
+		b, _ := json.Marshal(v.Interface())
+		return string(b)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func toGeneric(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(b, &out)
+	return out, err
+}
+
+func formatList() string {
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ", ")
+}
@@ -4,12 +4,10 @@ import (
     "encoding/json"
     "fmt"
     "os"
-    "strings"
     "time"
 
     "github.com/charmbracelet/lipgloss"
     "github.com/jedib0t/go-pretty/v6/table"
-    "github.com/verlyn13/ds-go/internal/config"
     "github.com/verlyn13/ds-go/internal/scan"
 )
 
@@ -56,15 +54,17 @@ var (
 			Foreground(lipgloss.Color("8"))
 )
 
-// PrintTable renders repositories in a formatted table - optimized for speed
-func PrintTable(repos []scan.Repository, cfg *config.Config) error {
+// PrintTable renders repositories in a formatted table - optimized for speed.
+// Repositories are printed in the order given; sort them with scan.SortRepos first.
+func PrintTable(repos []scan.Repository, opts TableOptions) error {
 	if len(repos) == 0 {
 		fmt.Println("No repositories found")
 		return nil
 	}
-
-	// Group by account for organized output
-	grouped := groupByAccount(repos)
+	opts = opts.WithDefaults(nil)
+	if err := opts.Validate(); err != nil {
+		return err
+	}
 	
 	// Statistics - single pass
 	var total, clean, dirty, ahead, behind int
@@ -94,6 +94,27 @@ func PrintTable(repos []scan.Repository, cfg *config.Config) error {
 	
 	fmt.Println(titleStyle.Render(header))
 	
+	cols := make([]Column, len(opts.Columns))
+	headers := make(table.Row, len(opts.Columns))
+	for i, key := range opts.Columns {
+		cols[i], _ = columnByKey(key)
+		headers[i] = cols[i].Header
+	}
+	
+	// Size columns across all groups so every table lines up
+	cells := make(map[string][]string, len(repos))
+	all := make([][]string, 0, len(repos))
+	for _, repo := range repos {
+		row := formatRepoRow(repo, cols)
+		cells[repo.Path] = row
+		all = append(all, row)
+	}
+	width := opts.Width
+	if width == 0 {
+		width = TerminalWidth()
+	}
+	limits := fitColumns(cols, all, width)
+	
 	// Create table with minimal styling for performance
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
@@ -101,18 +122,23 @@ func PrintTable(repos []scan.Repository, cfg *config.Config) error {
 	t.Style().Options.SeparateRows = false
 	t.Style().Options.DrawBorder = false
 	
-	// Print each account group
-	for account, accountRepos := range grouped {
-		if len(grouped) > 1 {
-			fmt.Printf("\n%s%s%s (%d)\n", ColorBold, account, ColorReset, len(accountRepos))
+	// Print each group
+	groups := groupRepos(repos, opts.GroupBy)
+	for _, group := range groups {
+		if len(groups) > 1 {
+			fmt.Printf("\n%s%s%s (%d)\n", ColorBold, group.name, ColorReset, len(group.repos))
 		}
 		
 		t.ResetHeaders()
 		t.ResetRows()
-		t.AppendHeader(table.Row{"", "Repository", "Status", "Changes", "Sync", "Last Commit"})
+		t.AppendHeader(headers)
 		
-		for _, repo := range accountRepos {
-			t.AppendRow(formatRepoRow(repo))
+		for _, repo := range group.repos {
+			row := make(table.Row, len(cols))
+			for i, cell := range cells[repo.Path] {
+				row[i] = ellipsize(cell, limits[i])
+			}
+			t.AppendRow(row)
 		}
 		
 		t.Render()
@@ -124,83 +150,13 @@ func PrintTable(repos []scan.Repository, cfg *config.Config) error {
 	return nil
 }
 
-// formatRepoRow formats a single repository row - optimized with direct string building
-func formatRepoRow(repo scan.Repository) table.Row {
-	// Status icon
-	var icon string
-	switch {
-	case !repo.IsClean:
-		icon = ColorYellow + "●" + ColorReset
-	case repo.Ahead > 0:
-		icon = ColorBlue + "↑" + ColorReset
-	case repo.Behind > 0:
-		icon = ColorCyan + "↓" + ColorReset
-	default:
-		icon = ColorGreen + "✓" + ColorReset
-	}
-	
-	// Repository name
-	name := repo.Name
-	if len(name) > 30 {
-		name = name[:27] + "..."
-	}
-	
-	// Status column
-	status := "clean"
-	if !repo.IsClean {
-		status = fmt.Sprintf("%s%d files%s", ColorYellow, repo.Uncommitted, ColorReset)
-	}
-	
-	// Changes column
-	var changes []string
-	if repo.HasStash {
-		changes = append(changes, ColorPurple+"stash"+ColorReset)
-	}
-	changeStr := strings.Join(changes, " ")
-	
-	// Sync column - compact display
-	var sync string
-	if repo.Ahead > 0 || repo.Behind > 0 {
-		if repo.Ahead > 0 {
-			sync += fmt.Sprintf("%s↑%d%s", ColorBlue, repo.Ahead, ColorReset)
-		}
-		if repo.Behind > 0 {
-			if sync != "" {
-				sync += " "
-			}
-			sync += fmt.Sprintf("%s↓%d%s", ColorCyan, repo.Behind, ColorReset)
-		}
-	} else if !repo.HasUpstream {
-		sync = ColorGray + "no upstream" + ColorReset
-	} else {
-		sync = "synced"
-	}
-	
-	// Last commit - truncate for display
-	lastCommit := repo.LastCommit
-	if len(lastCommit) > 40 {
-		lastCommit = lastCommit[:37] + "..."
-	}
-	
-	// Add fetch warning if stale
-	if repo.LastFetch != nil {
-		age := time.Since(*repo.LastFetch)
-		if age > time.Hour {
-			hours := int(age.Hours())
-			lastCommit += fmt.Sprintf(" %s(%dh old)%s", ColorGray, hours, ColorReset)
-		}
-	}
-	
-	return table.Row{icon, name, status, changeStr, sync, lastCommit}
-}
-
-// groupByAccount groups repositories by account
-func groupByAccount(repos []scan.Repository) map[string][]scan.Repository {
-	grouped := make(map[string][]scan.Repository)
-	for _, repo := range repos {
-		grouped[repo.Account] = append(grouped[repo.Account], repo)
+// formatRepoRow renders the selected columns for a single repository
+func formatRepoRow(repo scan.Repository, cols []Column) []string {
+	row := make([]string, len(cols))
+	for i, c := range cols {
+		row[i] = c.value(repo)
 	}
-	return grouped
+	return row
 }
 
 // showFetchHint shows a hint if repositories need fetching
@@ -218,13 +174,6 @@ func showFetchHint(repos []scan.Repository) {
 	}
 }
 
-// PrintJSON outputs repositories as JSON
-func PrintJSON(repos []scan.Repository) error {
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(repos)
-}
-
 // PrintFetchResults prints fetch operation results
 func PrintFetchResults(results []scan.FetchResult) {
 	var succeeded, failed int
@@ -243,16 +192,29 @@ func PrintFetchResults(results []scan.FetchResult) {
         ColorBold, ColorReset, succeeded, failed, totalDuration.Seconds())
 }
 
-// PrintJSONFetchResults outputs fetch results as JSON
-func PrintJSONFetchResults(results []scan.FetchResult) error {
-    encoder := json.NewEncoder(os.Stdout)
-    encoder.SetIndent("", "  ")
-    return encoder.Encode(struct {
-        Results []scan.FetchResult `json:"results"`
-    }{Results: results})
+// PrintCloneResults prints clone operation results
+func PrintCloneResults(results []scan.CloneResult) {
+    var cloned, skipped, failed int
+    for _, r := range results {
+        switch {
+        case r.Skipped:
+            skipped++
+            if r.ExistingAt != "" {
+                fmt.Printf("  %s-%s %s/%s already at %s\n", ColorGray, ColorReset, r.Owner, r.Repo, r.ExistingAt)
+            }
+        case r.Success:
+            cloned++
+        default:
+            failed++
+            fmt.Printf("  %s✗%s %s: %s\n", ColorRed, ColorReset, r.Input, r.Error)
+        }
+    }
+
+    fmt.Printf("\n%sClone complete:%s %d cloned, %d skipped, %d failed\n",
+        ColorBold, ColorReset, cloned, skipped, failed)
 }
 
-// Generic JSON envelope for command responses
+// JSONResponse is the JSON envelope for every command response
 type JSONResponse struct {
     OK    bool        `json:"ok"`
     Error string      `json:"error,omitempty"`
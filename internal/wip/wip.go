@@ -0,0 +1,161 @@
+// Package wip takes and manages snapshots of uncommitted work across
+// repositories. A snapshot is a commit shaped like a git stash entry,
+// holding the working tree, index and untracked files, kept under
+// refs/ds/wip/<id> or on the stash. Taking one leaves the working tree and
+// index untouched; restoring one is git stash apply.
+package wip
+
+import (
+    "fmt"
+    "time"
+
+    "github.com/verlyn13/ds-go/internal/git"
+    "github.com/verlyn13/ds-go/internal/scan"
+)
+
+// Actions reported in Result
+const (
+    ActionSnapshot = "snapshot"
+    ActionClean    = "clean"    // Nothing uncommitted to snapshot
+    ActionRestored = "restored"
+    ActionDropped  = "dropped"
+    ActionMissing  = "missing"  // The repository has no such snapshot
+)
+
+// Result is the outcome of a wip operation in one repository
+type Result struct {
+    Repo       string `json:"repo"`
+    Path       string `json:"path"`
+    Action     string `json:"action,omitempty"`
+    ID         string `json:"id,omitempty"`
+    Ref        string `json:"ref,omitempty"`
+    Commit     string `json:"commit,omitempty"`
+    Success    bool   `json:"success"`
+    Error      string `json:"error,omitempty"`
+    DurationMs int64  `json:"duration_ms"`
+}
+
+// Entry is a snapshot in one repository, for listings
+type Entry struct {
+    Repo string `json:"repo"`
+    Path string `json:"path"`
+    git.WIP
+}
+
+// NewID returns the snapshot ID for a run started at t. Every repository
+// snapshotted in one run shares it, so restore and drop can name the run.
+func NewID(t time.Time) string { return t.Format("20060102-150405") }
+
+// Snapshot records the uncommitted work of each repository as snapshot id,
+// on the stash when stash is set and under refs/ds/wip/ otherwise
+func Snapshot(repos []scan.Repository, id string, stash bool) []Result {
+    g := git.New()
+    results := make([]Result, 0, len(repos))
+    for _, r := range repos {
+        results = append(results, run(r, func(res *Result) error {
+            commit, err := g.CreateWIP(r.Path, git.WIPMessage(id, r.Branch))
+            if err != nil { return err }
+            if commit == "" {
+                res.Action = ActionClean
+                return nil
+            }
+            w, err := g.StoreWIP(r.Path, id, commit, git.WIPMessage(id, r.Branch), stash)
+            if err != nil { return err }
+            res.Action, res.ID, res.Ref, res.Commit = ActionSnapshot, w.ID, w.Ref, w.Commit
+            return nil
+        }))
+    }
+    return results
+}
+
+// List returns every snapshot in repos
+func List(repos []scan.Repository) ([]Entry, []Result) {
+    g := git.New()
+    var entries []Entry
+    var failed []Result
+    for _, r := range repos {
+        list, err := g.ListWIP(r.Path)
+        if err != nil {
+            failed = append(failed, Result{Repo: r.Name, Path: r.Path, Error: err.Error()})
+            continue
+        }
+        for _, w := range list {
+            entries = append(entries, Entry{Repo: r.Name, Path: r.Path, WIP: w})
+        }
+    }
+    return entries, failed
+}
+
+// Restore applies snapshot id in each repository that has it, restoring
+// the index as well when index is set. A repository with uncommitted work
+// is refused unless force is set, so a restore never mixes into it.
+func Restore(repos []scan.Repository, id string, index, force bool) []Result {
+    g := git.New()
+    results := make([]Result, 0, len(repos))
+    for _, r := range repos {
+        results = append(results, withSnapshot(g, r, id, func(res *Result, w git.WIP) error {
+            if !force && !r.IsClean {
+                return fmt.Errorf("%d uncommitted changes (snapshot or commit them, or use --force)", r.Uncommitted)
+            }
+            if err := g.ApplyWIP(r.Path, w.Commit, index); err != nil { return err }
+            res.Action = ActionRestored
+            return nil
+        }))
+    }
+    return results
+}
+
+// Drop deletes snapshot id from each repository that has it
+func Drop(repos []scan.Repository, id string) []Result {
+    g := git.New()
+    results := make([]Result, 0, len(repos))
+    for _, r := range repos {
+        results = append(results, withSnapshot(g, r, id, func(res *Result, w git.WIP) error {
+            if err := g.DropWIP(r.Path, w); err != nil { return err }
+            res.Action = ActionDropped
+            return nil
+        }))
+    }
+    return results
+}
+
+// withSnapshot runs fn on snapshot id of r, "latest" naming the newest.
+// A repository without it is reported as missing, which is not a failure.
+func withSnapshot(g *git.Git, r scan.Repository, id string, fn func(*Result, git.WIP) error) Result {
+    return run(r, func(res *Result) error {
+        list, err := g.ListWIP(r.Path)
+        if err != nil { return err }
+        w, ok := find(list, id)
+        if !ok {
+            res.Action = ActionMissing
+            return nil
+        }
+        res.ID, res.Ref, res.Commit = w.ID, w.Ref, w.Commit
+        return fn(res, w)
+    })
+}
+
+// find returns the snapshot with ID id from list, or the newest for "latest"
+func find(list []git.WIP, id string) (git.WIP, bool) {
+    var found git.WIP
+    ok := false
+    for _, w := range list {
+        if (id == "latest" || w.ID == id) && (!ok || w.Time.After(found.Time)) {
+            found, ok = w, true
+        }
+    }
+    return found, ok
+}
+
+// run times fn for r and records its error
+func run(r scan.Repository, fn func(*Result) error) Result {
+    start := time.Now()
+    res := Result{Repo: r.Name, Path: r.Path}
+    if err := fn(&res); err != nil {
+        res.Error = err.Error()
+    } else {
+        res.Success = true
+    }
+    res.DurationMs = time.Since(start).Milliseconds()
+    return res
+}
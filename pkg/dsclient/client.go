@@ -2,11 +2,22 @@ package dsclient
 
 import (
     "context"
+    "crypto/sha256"
+    "crypto/tls"
+    "crypto/x509"
+    "encoding/hex"
     "encoding/json"
+    "errors"
     "fmt"
     "bytes"
+    "io"
+    "math/rand/v2"
+    "net"
     "net/http"
     "net/url"
+    "strconv"
+    "strings"
+    "syscall"
     "time"
 )
 
@@ -15,8 +26,20 @@ type Client struct {
     BaseURL    string
     Token      string
     HTTPClient *http.Client
+
+    socket      string        // Unix socket path from a unix:// base URL
+    fingerprint string        // Pinned server certificate SHA-256
+    retries     int           // Extra attempts for GETs and stream reconnects
+    backoff     time.Duration // Delay before the first retry; doubles after each
 }
 
+// Retry defaults; see WithRetry
+const (
+    DefaultRetries = 3
+    DefaultBackoff = 250 * time.Millisecond
+    maxBackoff     = 10 * time.Second
+)
+
 // Option configures a Client.
 type Option func(*Client)
 
@@ -26,13 +49,69 @@ func WithToken(token string) Option { return func(c *Client) { c.Token = token }
 // WithHTTPClient provides a custom http.Client.
 func WithHTTPClient(h *http.Client) Option { return func(c *Client) { c.HTTPClient = h } }
 
-// New creates a new Client.
+// WithCertFingerprint pins the server certificate by its SHA-256
+// fingerprint, as printed by `ds serve --tls-self-signed`. Colons and case
+// are ignored. The certificate chain itself is not verified.
+func WithCertFingerprint(fp string) Option {
+    return func(c *Client) { c.fingerprint = strings.ToLower(strings.ReplaceAll(fp, ":", "")) }
+}
+
+// WithRetry sets how often a failed GET is retried, and a dropped stream
+// reconnected, and the delay before the first retry. The delay doubles on
+// each attempt up to 10s, with jitter; a server Retry-After takes
+// precedence. Refused or dropped connections and 429, 502, 503 and 504
+// responses are retried. retries 0 disables retrying.
+func WithRetry(retries int, backoff time.Duration) Option {
+    return func(c *Client) { c.retries, c.backoff = retries, backoff }
+}
+
+// New creates a new Client. A base of unix:///path/to/ds.sock dials the
+// server's Unix socket.
 func New(base string, opts ...Option) *Client {
-    c := &Client{BaseURL: base, HTTPClient: &http.Client{Timeout: 30 * time.Second}}
+    c := &Client{BaseURL: base, HTTPClient: &http.Client{Timeout: 30 * time.Second}, retries: DefaultRetries, backoff: DefaultBackoff}
+    if path, ok := strings.CutPrefix(base, "unix://"); ok {
+        c.BaseURL, c.socket = "http://unix", path
+    }
     for _, opt := range opts { opt(c) }
+    if c.socket != "" || c.fingerprint != "" { c.configureTransport() }
     return c
 }
 
+// configureTransport applies the Unix socket dialer and certificate pin
+// to the HTTP client's transport
+func (c *Client) configureTransport() {
+    tr, ok := c.HTTPClient.Transport.(*http.Transport)
+    if ok {
+        tr = tr.Clone()
+    } else {
+        tr = http.DefaultTransport.(*http.Transport).Clone()
+    }
+    if c.socket != "" {
+        path := c.socket
+        tr.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+            var d net.Dialer
+            return d.DialContext(ctx, "unix", path)
+        }
+    }
+    if c.fingerprint != "" {
+        want := c.fingerprint
+        tr.TLSClientConfig = &tls.Config{
+            InsecureSkipVerify: true, // Replaced by the fingerprint check below
+            VerifyPeerCertificate: func(raw [][]byte, _ [][]*x509.Certificate) error {
+                if len(raw) == 0 { return fmt.Errorf("server sent no certificate") }
+                sum := sha256.Sum256(raw[0])
+                if got := hex.EncodeToString(sum[:]); got != want {
+                    return fmt.Errorf("server certificate fingerprint %s does not match", got)
+                }
+                return nil
+            },
+        }
+    }
+    hc := *c.HTTPClient
+    hc.Transport = tr
+    c.HTTPClient = &hc
+}
+
 // Health fetches /v1/health.
 func (c *Client) Health(ctx context.Context) (HealthResponse, error) {
     var out HealthResponse
@@ -60,14 +139,83 @@ func (c *Client) Capabilities(ctx context.Context) (map[string]any, error) {
     return out, nil
 }
 
-// Status fetches /v1/status with optional filters.
-func (c *Client) Status(ctx context.Context, account, path string, dirty bool) (StatusResponse, error) {
+// Filter selects repositories for status and fetch. Zero values are
+// omitted from the query.
+type Filter struct {
+    Path       string // Scan this directory instead of the server's base_dir
+    Account    string
+    Branch     string
+    Folder     string
+    Tag        string
+    Dirty      bool
+    Ahead      bool // Only repos with unpushed commits
+    Behind     bool // Only repos behind their upstream
+    HasStash   bool
+    NoUpstream bool
+}
+
+func (f Filter) query() url.Values {
     q := url.Values{}
-    if account != "" { q.Set("account", account) }
-    if path != "" { q.Set("path", path) }
-    if dirty { q.Set("dirty", "true") }
+    for k, v := range map[string]string{"path": f.Path, "account": f.Account, "branch": f.Branch, "folder": f.Folder, "tag": f.Tag} {
+        if v != "" { q.Set(k, v) }
+    }
+    for k, v := range map[string]bool{
+        "dirty": f.Dirty, "ahead": f.Ahead, "behind": f.Behind, "has_stash": f.HasStash, "no_upstream": f.NoUpstream,
+    } {
+        if v { q.Set(k, "true") }
+    }
+    return q
+}
+
+// StatusOptions filters, sorts and pages /v1/status. Zero values are
+// omitted from the query.
+type StatusOptions struct {
+    Path       string
+    Account    string
+    Branch     string
+    Folder     string
+    Tag        string
+    Dirty      bool
+    Ahead      bool     // Only repos with unpushed commits
+    Behind     bool     // Only repos behind their upstream
+    HasStash   bool
+    NoUpstream bool
+    Sort       string   // name, account, folder, branch, last_commit, behind, ahead or dirty; prefix - to reverse
+    Fields     []string // Repository fields to return, e.g. Name, Branch, Ahead; empty returns all
+    Limit      int      // Page size; 0 returns every repo
+    Cursor     string   // NextCursor from the previous page
+}
+
+func (o StatusOptions) query() url.Values {
+    q := Filter{
+        Path: o.Path, Account: o.Account, Branch: o.Branch, Folder: o.Folder, Tag: o.Tag,
+        Dirty: o.Dirty, Ahead: o.Ahead, Behind: o.Behind, HasStash: o.HasStash, NoUpstream: o.NoUpstream,
+    }.query()
+    for k, v := range map[string]string{"sort": o.Sort, "fields": strings.Join(o.Fields, ","), "cursor": o.Cursor} {
+        if v != "" { q.Set(k, v) }
+    }
+    if o.Limit > 0 { q.Set("limit", strconv.Itoa(o.Limit)) }
+    return q
+}
+
+// Status fetches one page of /v1/status. With Fields set, unselected
+// Repository fields are left at their zero values.
+func (c *Client) Status(ctx context.Context, opts StatusOptions) (StatusResponse, error) {
     var out StatusResponse
-    return out, c.get(ctx, "/v1/status", q, &out)
+    return out, c.get(ctx, "/v1/status", opts.query(), &out)
+}
+
+// StatusAll follows NextCursor from opts.Cursor and returns every page's
+// repositories
+func (c *Client) StatusAll(ctx context.Context, opts StatusOptions) ([]Repository, error) {
+    var repos []Repository
+    for {
+        page, err := c.Status(ctx, opts)
+        if err != nil { return nil, err }
+        repos = append(repos, page.Data...)
+        if page.NextCursor == "" { return repos, nil }
+        opts.Cursor = page.NextCursor
+    }
 }
 
 // Scan triggers /v1/scan and returns count.
@@ -98,52 +246,134 @@ func (c *Client) OrganizeApply(ctx context.Context, requireClean, force, dryRun
     return out, c.post(ctx, "/v1/organize/apply", q, nil, &out)
 }
 
+// Fetch runs git fetch in the selected repositories and returns once all
+// have finished. Use SubscribeFetch for results as they complete.
+func (c *Client) Fetch(ctx context.Context, f Filter) (FetchResponse, error) {
+    var out FetchResponse
+    return out, c.get(ctx, "/v1/fetch", f.query(), &out)
+}
+
 // PolicyCheck runs policy check.
 func (c *Client) PolicyCheck(ctx context.Context, file, failOn string) (PolicyResponse, error) {
     if file == "" { file = ".project-compliance.yaml" }
     if failOn == "" { failOn = "critical" }
     q := url.Values{"file": {file}, "fail_on": {failOn}}
     var out PolicyResponse
-    return out, c.get(ctx, "/v1/policy/check", q, &out)
+    return out, c.post(ctx, "/v1/policy/check", q, nil, &out)
+}
+
+// Commands lists the command templates the server allows via /v1/commands.
+func (c *Client) Commands(ctx context.Context) (CommandsResponse, error) {
+    var out CommandsResponse
+    return out, c.get(ctx, "/v1/commands", nil, &out)
 }
 
-// Exec runs a command across repositories.
-func (c *Client) Exec(ctx context.Context, cmd string, q url.Values) (ExecResponse, error) {
-    body := map[string]string{"cmd": cmd}
+// Exec runs a named command template (or a raw command, when the server
+// allows it) across repositories.
+func (c *Client) Exec(ctx context.Context, req ExecRequest) (ExecResponse, error) {
     var out ExecResponse
-    return out, c.post(ctx, "/v1/exec", q, body, &out)
+    return out, c.post(ctx, "/v1/exec", nil, req, &out)
+}
+
+// Manifest exports the workspace manifest via GET /v1/manifest.
+func (c *Client) Manifest(ctx context.Context, path string) (ManifestResponse, error) {
+    q := url.Values{}
+    if path != "" { q.Set("path", path) }
+    var out ManifestResponse
+    return out, c.get(ctx, "/v1/manifest", q, &out)
+}
+
+// ApplyManifest clones the manifest's repositories that are missing from
+// the workspace. It needs the organize scope.
+func (c *Client) ApplyManifest(ctx context.Context, m Manifest, dryRun bool, path string) (ManifestApplyResponse, error) {
+    q := url.Values{}
+    if dryRun { q.Set("dry_run", "true") }
+    if path != "" { q.Set("path", path) }
+    var out ManifestApplyResponse
+    return out, c.post(ctx, "/v1/manifest", q, m, &out)
 }
 
 // Helpers
 func (c *Client) get(ctx context.Context, path string, q url.Values, dst any) error {
-    u := c.BaseURL + path
-    if q != nil && len(q) > 0 { u += "?" + q.Encode() }
-    req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
-    req.Header.Set("Accept", "application/json")
-    if c.Token != "" { req.Header.Set("Authorization", "Bearer "+c.Token) }
-    resp, err := c.HTTPClient.Do(req)
+    resp, err := c.do(ctx, c.HTTPClient, http.MethodGet, path, q, nil, "application/json", "")
     if err != nil { return err }
     defer resp.Body.Close()
-    if resp.StatusCode >= 400 { return fmt.Errorf("GET %s: HTTP %d", path, resp.StatusCode) }
     return json.NewDecoder(resp.Body).Decode(dst)
 }
 
 func (c *Client) post(ctx context.Context, path string, q url.Values, body any, dst any) error {
-    u := c.BaseURL + path
-    if q != nil && len(q) > 0 { u += "?" + q.Encode() }
-    var req *http.Request
+    var b []byte
     if body != nil {
-        b, _ := json.Marshal(body)
-        req, _ = http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(b))
-        req.Header.Set("Content-Type", "application/json")
-    } else {
-        req, _ = http.NewRequestWithContext(ctx, http.MethodPost, u, nil)
+        var err error
+        if b, err = json.Marshal(body); err != nil { return err }
     }
-    req.Header.Set("Accept", "application/json")
-    if c.Token != "" { req.Header.Set("Authorization", "Bearer "+c.Token) }
-    resp, err := c.HTTPClient.Do(req)
+    resp, err := c.do(ctx, c.HTTPClient, http.MethodPost, path, q, b, "application/json", "")
     if err != nil { return err }
     defer resp.Body.Close()
-    if resp.StatusCode >= 400 { return fmt.Errorf("POST %s: HTTP %d", path, resp.StatusCode) }
     return json.NewDecoder(resp.Body).Decode(dst)
 }
+
+// do sends a request and returns the response to a 2xx status; other
+// statuses become an *APIError. GETs are retried as set by WithRetry.
+func (c *Client) do(ctx context.Context, hc *http.Client, method, path string, q url.Values, body []byte, accept, lastEventID string) (*http.Response, error) {
+    u := c.BaseURL + path
+    if len(q) > 0 { u += "?" + q.Encode() }
+    for attempt := 0; ; attempt++ {
+        var rd io.Reader
+        if body != nil { rd = bytes.NewReader(body) }
+        req, err := http.NewRequestWithContext(ctx, method, u, rd)
+        if err != nil { return nil, err }
+        if body != nil { req.Header.Set("Content-Type", "application/json") }
+        req.Header.Set("Accept", accept)
+        if lastEventID != "" { req.Header.Set("Last-Event-ID", lastEventID) }
+        if c.Token != "" { req.Header.Set("Authorization", "Bearer "+c.Token) }
+        resp, err := hc.Do(req)
+        if err == nil && resp.StatusCode < 400 { return resp, nil }
+
+        var retryAfter string
+        if err == nil {
+            retryAfter = resp.Header.Get("Retry-After")
+            if !retryableStatus(resp.StatusCode) || method != http.MethodGet || attempt >= c.retries {
+                defer resp.Body.Close()
+                return nil, decodeError(resp, method, path)
+            }
+            _, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+            resp.Body.Close()
+        } else if !retryableErr(err) || ctx.Err() != nil || method != http.MethodGet || attempt >= c.retries {
+            return nil, err
+        }
+        if err := c.wait(ctx, attempt, retryAfter); err != nil { return nil, err }
+    }
+}
+
+// wait sleeps before retry attempt+1: Retry-After seconds when the server
+// sent them, otherwise the client's backoff doubled per attempt, with jitter
+func (c *Client) wait(ctx context.Context, attempt int, retryAfter string) error {
+    d := c.backoff << attempt
+    if d <= 0 || d > maxBackoff { d = maxBackoff }
+    d = d/2 + rand.N(d/2+1)
+    if secs, err := strconv.Atoi(retryAfter); err == nil && secs >= 0 { d = time.Duration(secs) * time.Second }
+    t := time.NewTimer(d)
+    defer t.Stop()
+    select {
+    case <-ctx.Done():
+        return ctx.Err()
+    case <-t.C:
+        return nil
+    }
+}
+
+// retryableErr reports whether a request failed because the server was
+// unreachable or dropped the connection, as it does while restarting
+func retryableErr(err error) bool {
+    return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+        errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func retryableStatus(status int) bool {
+    switch status {
+    case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+        return true
+    }
+    return false
+}
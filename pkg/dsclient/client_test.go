@@ -2,11 +2,21 @@ package dsclient
 
 import (
     "context"
+    "crypto/sha256"
+    "encoding/hex"
     "encoding/json"
+    "errors"
+    "net"
     "net/http"
     "net/http/httptest"
+    "path/filepath"
+    "strings"
     "testing"
     "time"
+
+    "github.com/verlyn13/ds-go/internal/config"
+    "github.com/verlyn13/ds-go/internal/events"
+    "github.com/verlyn13/ds-go/internal/server"
 )
 
 func TestHealthAndToken(t *testing.T) {
@@ -117,7 +127,200 @@ func TestStatusTyped(t *testing.T) {
     defer srv.Close()
 
     c := New(srv.URL)
-    out, err := c.Status(context.Background(), "", "", false)
+    out, err := c.Status(context.Background(), StatusOptions{})
     if err != nil { t.Fatalf("status: %v", err) }
     if out.SchemaVersion != "ds.v1" { t.Fatalf("missing schema_version") }
 }
+
+func TestStatusPaging(t *testing.T) {
+    names := []string{"a", "b", "c"}
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        q := r.URL.Query()
+        if q.Get("sort") != "-ahead" || q.Get("fields") != "Name,Ahead" || q.Get("behind") != "true" || q.Get("limit") != "2" {
+            t.Errorf("unexpected query %s", r.URL.RawQuery)
+        }
+        start := 0
+        if q.Get("cursor") == "next" { start = 2 }
+        end := min(start+2, len(names))
+        resp := StatusResponse{SchemaVersion: "ds.v1", Total: len(names)}
+        for _, n := range names[start:end] {
+            resp.Data = append(resp.Data, Repository{Name: n})
+        }
+        if end < len(names) { resp.NextCursor = "next" }
+        _ = json.NewEncoder(w).Encode(resp)
+    }))
+    defer srv.Close()
+
+    c := New(srv.URL)
+    opts := StatusOptions{Behind: true, Sort: "-ahead", Fields: []string{"Name", "Ahead"}, Limit: 2}
+    page, err := c.Status(context.Background(), opts)
+    if err != nil { t.Fatalf("status: %v", err) }
+    if len(page.Data) != 2 || page.Total != 3 || page.NextCursor != "next" { t.Fatalf("first page: %+v", page) }
+
+    all, err := c.StatusAll(context.Background(), opts)
+    if err != nil { t.Fatalf("status all: %v", err) }
+    if len(all) != 3 || all[2].Name != "c" { t.Fatalf("all pages: %+v", all) }
+}
+
+func TestUnixSocket(t *testing.T) {
+    sock := filepath.Join(t.TempDir(), "ds.sock")
+    ln, err := net.Listen("unix", sock)
+    if err != nil { t.Fatalf("listen: %v", err) }
+    srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        _ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "schema_version": "ds.v1"})
+    }))
+    srv.Listener = ln
+    srv.Start()
+    defer srv.Close()
+
+    c := New("unix://" + sock)
+    out, err := c.Health(context.Background())
+    if err != nil { t.Fatalf("health over unix socket: %v", err) }
+    if !out.OK { t.Fatalf("expected ok") }
+}
+
+func TestCertFingerprint(t *testing.T) {
+    srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        _ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "schema_version": "ds.v1"})
+    }))
+    defer srv.Close()
+    sum := sha256.Sum256(srv.Certificate().Raw)
+
+    c := New(srv.URL, WithCertFingerprint(hex.EncodeToString(sum[:])))
+    if _, err := c.Health(context.Background()); err != nil { t.Fatalf("pinned health: %v", err) }
+
+    c = New(srv.URL, WithCertFingerprint(strings.Repeat("00", 32)))
+    if _, err := c.Health(context.Background()); err == nil { t.Fatalf("expected fingerprint mismatch") }
+}
+
+func TestTypedErrors(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/v1/exec":
+            w.Header().Set("Content-Type", "application/json")
+            w.WriteHeader(http.StatusUnprocessableEntity)
+            _, _ = w.Write([]byte(`{"ok":false,"error":{"code":"validation_failed","message":"missing required parameter \"who\""},"schema_version":"ds.v1"}`))
+        case "/v1/health":
+            http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        default:
+            w.WriteHeader(http.StatusNotFound)
+        }
+    }))
+    defer srv.Close()
+
+    c := New(srv.URL)
+    _, err := c.Exec(context.Background(), ExecRequest{Command: "greet"})
+    var apiErr *APIError
+    if !errors.As(err, &apiErr) { t.Fatalf("expected APIError, got %v", err) }
+    if apiErr.StatusCode != http.StatusUnprocessableEntity || apiErr.Code != CodeValidation { t.Fatalf("unexpected error: %+v", apiErr) }
+    if apiErr.Message != `missing required parameter "who"` { t.Fatalf("unexpected message: %q", apiErr.Message) }
+
+    if _, err := c.Health(context.Background()); !IsUnauthorized(err) { t.Fatalf("expected unauthorized, got %v", err) }
+    if _, err := c.Scan(context.Background(), ""); !IsNotFound(err) { t.Fatalf("expected not found, got %v", err) }
+}
+
+func TestRetryIdempotentGET(t *testing.T) {
+    var gets, posts int
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Method == http.MethodPost {
+            posts++
+            w.WriteHeader(http.StatusServiceUnavailable)
+            return
+        }
+        if gets++; gets < 3 {
+            w.WriteHeader(http.StatusServiceUnavailable)
+            return
+        }
+        _ = json.NewEncoder(w).Encode(map[string]any{"count": 2, "schema_version": "ds.v1"})
+    }))
+    defer srv.Close()
+
+    c := New(srv.URL, WithRetry(3, time.Millisecond))
+    out, err := c.Scan(context.Background(), "")
+    if err != nil || out.Count != 2 { t.Fatalf("scan after retries: %+v %v", out, err) }
+    if gets != 3 { t.Fatalf("expected 3 attempts, got %d", gets) }
+
+    if _, err := c.Exec(context.Background(), ExecRequest{Command: "x"}); err == nil { t.Fatalf("expected error") }
+    if posts != 1 { t.Fatalf("POST must not be retried, got %d attempts", posts) }
+
+    gets = -10
+    _, err = New(srv.URL, WithRetry(1, time.Millisecond)).Scan(context.Background(), "")
+    var apiErr *APIError
+    if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable { t.Fatalf("expected 503 after retries, got %v", err) }
+}
+
+func TestStreamStatus(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/v1/status/stream" || r.URL.Query().Get("sort") != "name" || r.URL.Query().Has("limit") {
+            w.WriteHeader(http.StatusNotFound)
+            return
+        }
+        w.Header().Set("Content-Type", "application/x-ndjson")
+        _, _ = w.Write([]byte(`{"Name":"a"}` + "\n" + `{"Name":"b"}` + "\n"))
+    }))
+    defer srv.Close()
+
+    var names []string
+    for repo, err := range New(srv.URL).StreamStatus(context.Background(), StatusOptions{Sort: "name", Limit: 5}) {
+        if err != nil { t.Fatalf("stream: %v", err) }
+        names = append(names, repo.Name)
+    }
+    if strings.Join(names, ",") != "a,b" { t.Fatalf("unexpected repos: %v", names) }
+}
+
+func TestSubscribeFetchReconnect(t *testing.T) {
+    var lastIDs []string
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        lastIDs = append(lastIDs, r.Header.Get("Last-Event-ID"))
+        w.Header().Set("Content-Type", "text/event-stream")
+        _, _ = w.Write([]byte("id: 1\nevent: fetch\ndata: {\"RepoName\":\"a\",\"Success\":true}\n\n"))
+        if len(lastIDs) == 1 {
+            // Drop the connection mid-stream
+            w.(http.Flusher).Flush()
+            conn, _, _ := w.(http.Hijacker).Hijack()
+            conn.Close()
+            return
+        }
+        _, _ = w.Write([]byte(": keep-alive\n\nid: 2\nevent: fetch\ndata: {\"RepoName\":\"b\",\"Success\":false,\"Error\":{}}\n\n"))
+    }))
+    defer srv.Close()
+
+    var got []string
+    for res, err := range New(srv.URL, WithRetry(2, time.Millisecond)).SubscribeFetch(context.Background(), Filter{Account: "acme"}) {
+        if err != nil { t.Fatalf("subscribe: %v", err) }
+        got = append(got, res.RepoName)
+        if res.RepoName == "b" && (res.Success || res.Error == nil) { t.Fatalf("expected failed result: %+v", res) }
+    }
+    if strings.Join(got, ",") != "a,b" { t.Fatalf("each repo once, got %v", got) }
+    if len(lastIDs) != 2 || lastIDs[0] != "" || lastIDs[1] != "1" { t.Fatalf("unexpected Last-Event-ID: %q", lastIDs) }
+}
+
+func TestSubscribeEvents(t *testing.T) {
+    dir := t.TempDir()
+    bus := events.NewBus(config.EventsConfig{Log: filepath.Join(dir, "events.log"), DeadLetter: filepath.Join(dir, "dead.jsonl")})
+    cfg := &config.Config{BaseDir: dir, Accounts: map[string]config.AccountConfig{}}
+    h := server.New(cfg, 2).WithEvents(bus, events.NewDetector(filepath.Join(dir, "state.json"), 0)).Handler()
+    srv := httptest.NewServer(h)
+    defer srv.Close()
+
+    old := events.New(events.FetchFailed, "old", "", nil)
+    bus.Publish(old)
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    go func() {
+        time.Sleep(200 * time.Millisecond)
+        bus.Publish(events.New(events.RepoDirty, "a", "", nil), events.New(events.FetchFailed, "b", "", map[string]interface{}{"error": "boom"}))
+    }()
+
+    // Replays the logged event, then skips the repo.dirty event
+    var got []string
+    for e, err := range New(srv.URL).SubscribeEvents(ctx, []string{events.FetchFailed}, "0") {
+        if err != nil { t.Fatalf("subscribe: %v", err) }
+        got = append(got, e.Repo)
+        if e.Repo == "b" {
+            if e.Data["error"] != "boom" { t.Fatalf("event data lost: %+v", e) }
+            break
+        }
+    }
+    if strings.Join(got, ",") != "old,b" { t.Fatalf("got events for %v", got) }
+}
@@ -0,0 +1,116 @@
+package dsclient
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "reflect"
+    "strings"
+    "sync"
+    "testing"
+
+    "github.com/verlyn13/ds-go/internal/api"
+    "github.com/verlyn13/ds-go/internal/config"
+    "github.com/verlyn13/ds-go/internal/server"
+)
+
+// These tests keep the server, the committed OpenAPI document and this
+// client in step with internal/api.
+
+func realHandler(t *testing.T) http.Handler {
+    t.Helper()
+    cfg := &config.Config{BaseDir: t.TempDir(), Accounts: map[string]config.AccountConfig{}}
+    return server.New(cfg, 2).Handler()
+}
+
+func TestOpenAPIUpToDate(t *testing.T) {
+    want, err := api.OpenAPI()
+    if err != nil { t.Fatalf("generate: %v", err) }
+    rec := httptest.NewRecorder()
+    realHandler(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil))
+    if rec.Code != http.StatusOK { t.Fatalf("GET /openapi.yaml: %d", rec.Code) }
+    if !bytes.Equal(rec.Body.Bytes(), want) {
+        t.Fatalf("internal/server/openapi.yaml is stale; run go generate ./internal/server")
+    }
+}
+
+func TestRoutesServed(t *testing.T) {
+    h := realHandler(t)
+    for _, rt := range api.Routes {
+        paths := []string{rt.Path}
+        if strings.HasPrefix(rt.Path, "/v1/") { paths = append(paths, "/v2/"+strings.TrimPrefix(rt.Path, "/v1/")) }
+        for _, p := range paths {
+            rec := httptest.NewRecorder()
+            h.ServeHTTP(rec, httptest.NewRequest(rt.Method, p, nil))
+            if rec.Code == http.StatusMethodNotAllowed { t.Errorf("%s %s: 405", rt.Method, p) }
+            if rec.Code == http.StatusNotFound && !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+                t.Errorf("%s %s: not routed", rt.Method, p)
+            }
+            resp := rt.Response
+            if rt.ResponseV2 != nil && strings.HasPrefix(p, "/v2/") { resp = rt.ResponseV2 }
+            if rec.Code != http.StatusOK || rt.Stream != "" || resp == nil || reflect.TypeOf(resp).Kind() != reflect.Struct { continue }
+            // Every key the schema requires is in the body
+            schema, err := api.JSONSchema(resp)
+            if err != nil { t.Fatalf("%s: %v", rt.Name, err) }
+            var body map[string]any
+            if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil { t.Errorf("%s %s: %v", rt.Method, p, err); continue }
+            for _, key := range schema.Required {
+                if _, ok := body[key]; !ok { t.Errorf("%s %s: response lacks %q", rt.Method, p, key) }
+            }
+        }
+    }
+}
+
+// clientHelpers are Client methods built on other methods rather than on
+// one route
+var clientHelpers = map[string]bool{"StatusAll": true}
+
+func TestClientMatchesRoutes(t *testing.T) {
+    var mu sync.Mutex
+    var got string
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        _, _ = io.Copy(io.Discard, r.Body)
+        mu.Lock()
+        got = r.Method + " " + r.URL.Path
+        mu.Unlock()
+        w.Header().Set("Content-Type", "application/json")
+        _, _ = w.Write([]byte("{}"))
+    }))
+    defer srv.Close()
+
+    c := reflect.ValueOf(New(srv.URL))
+    covered := map[string]bool{}
+    for _, rt := range api.Routes {
+        if rt.Client == "" { continue }
+        covered[rt.Client] = true
+        m := c.MethodByName(rt.Client)
+        if !m.IsValid() { t.Errorf("route %s names missing Client.%s", rt.Name, rt.Client); continue }
+        args := []reflect.Value{reflect.ValueOf(context.Background())}
+        for i := 1; i < m.Type().NumIn(); i++ {
+            args = append(args, reflect.Zero(m.Type().In(i)))
+        }
+        got = ""
+        out := m.Call(args)
+        if seq := out[0]; seq.Kind() == reflect.Func {
+            // Streams send their request when iterated
+            stop := reflect.MakeFunc(seq.Type().In(0), func([]reflect.Value) []reflect.Value {
+                return []reflect.Value{reflect.ValueOf(false)}
+            })
+            seq.Call([]reflect.Value{stop})
+        }
+        if want := rt.Method + " " + rt.Path; got != want {
+            t.Errorf("Client.%s called %q, want %q", rt.Client, got, want)
+        }
+    }
+
+    ct := c.Type()
+    for i := 0; i < ct.NumMethod(); i++ {
+        name := ct.Method(i).Name
+        if !covered[name] && !clientHelpers[name] && ct.Method(i).Type.NumIn() > 1 && ct.Method(i).Type.In(1) == reflect.TypeOf((*context.Context)(nil)).Elem() {
+            t.Errorf("Client.%s is not named by any api.Routes entry", name)
+        }
+    }
+}
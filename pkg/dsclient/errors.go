@@ -0,0 +1,90 @@
+package dsclient
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "strings"
+)
+
+// Error codes returned by the ds API
+const (
+    CodeBadRequest       = "bad_request"
+    CodeUnauthorized     = "unauthorized"
+    CodeForbidden        = "forbidden"
+    CodeNotFound         = "not_found"
+    CodeMethodNotAllowed = "method_not_allowed"
+    CodeConflict         = "conflict"
+    CodeValidation       = "validation_failed"
+    CodeInternal         = "internal"
+)
+
+// APIError is a non-2xx response from the ds API.
+type APIError struct {
+    StatusCode int             // HTTP status
+    Method     string          // Request method
+    Path       string          // Request path
+    Code       string          `json:"code"`
+    Message    string          `json:"message"`
+    Details    json.RawMessage `json:"details,omitempty"`
+}
+
+func (e *APIError) Error() string {
+    return fmt.Sprintf("%s %s: %s (HTTP %d, %s)", e.Method, e.Path, e.Message, e.StatusCode, e.Code)
+}
+
+// IsCode reports whether err is an APIError with the given code.
+func IsCode(err error, code string) bool {
+    var apiErr *APIError
+    return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// IsNotFound reports whether err is a 404 from the API.
+func IsNotFound(err error) bool { return IsCode(err, CodeNotFound) }
+
+// IsUnauthorized reports whether err is a 401 or 403 from the API.
+func IsUnauthorized(err error) bool {
+    return IsCode(err, CodeUnauthorized) || IsCode(err, CodeForbidden)
+}
+
+// decodeError builds an APIError from an error response. Bodies from
+// older servers ({"error":"..."}) and plain text are also understood.
+func decodeError(resp *http.Response, method, path string) error {
+    apiErr := &APIError{StatusCode: resp.StatusCode, Method: method, Path: path}
+    body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+    var env struct {
+        Error json.RawMessage `json:"error"`
+    }
+    if json.Unmarshal(body, &env) == nil && len(env.Error) > 0 {
+        if json.Unmarshal(env.Error, apiErr) != nil {
+            _ = json.Unmarshal(env.Error, &apiErr.Message)
+        }
+    } else {
+        apiErr.Message = strings.TrimSpace(string(body))
+    }
+    if apiErr.Message == "" { apiErr.Message = http.StatusText(resp.StatusCode) }
+    if apiErr.Code == "" { apiErr.Code = codeForStatus(resp.StatusCode) }
+    return apiErr
+}
+
+func codeForStatus(status int) string {
+    switch status {
+    case http.StatusBadRequest:
+        return CodeBadRequest
+    case http.StatusUnauthorized:
+        return CodeUnauthorized
+    case http.StatusForbidden:
+        return CodeForbidden
+    case http.StatusNotFound:
+        return CodeNotFound
+    case http.StatusMethodNotAllowed:
+        return CodeMethodNotAllowed
+    case http.StatusConflict:
+        return CodeConflict
+    case http.StatusUnprocessableEntity:
+        return CodeValidation
+    }
+    return CodeInternal
+}
@@ -0,0 +1,148 @@
+package dsclient
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "io"
+    "iter"
+    "maps"
+    "net/http"
+    "net/url"
+    "strings"
+)
+
+// StreamStatus iterates over /v1/status/stream, one repository per NDJSON
+// line. Sort, Fields and the filters apply; Limit and Cursor are ignored.
+// An error ends the iteration.
+func (c *Client) StreamStatus(ctx context.Context, opts StatusOptions) iter.Seq2[Repository, error] {
+    opts.Limit, opts.Cursor = 0, ""
+    return func(yield func(Repository, error) bool) {
+        resp, err := c.do(ctx, c.streamClient(), http.MethodGet, "/v1/status/stream", opts.query(), nil, "application/x-ndjson", "")
+        if err != nil { yield(Repository{}, err); return }
+        defer resp.Body.Close()
+        dec := json.NewDecoder(resp.Body)
+        for {
+            var repo Repository
+            if err := dec.Decode(&repo); err != nil {
+                if err != io.EOF { yield(Repository{}, err) }
+                return
+            }
+            if !yield(repo, nil) { return }
+        }
+    }
+}
+
+// SubscribeStatus iterates over the repo events of /v1/status/sse. A
+// dropped connection is resumed after the last repository received.
+func (c *Client) SubscribeStatus(ctx context.Context, opts StatusOptions) iter.Seq2[Repository, error] {
+    opts.Limit, opts.Cursor = 0, ""
+    return subscribe[Repository](ctx, c, "/v1/status/sse", opts.query(), "repo", nil)
+}
+
+// SubscribeFetch iterates over fetch results from /v1/fetch/sse as each
+// repository finishes. A dropped connection is reopened, which fetches
+// again; results for repositories already yielded are skipped.
+func (c *Client) SubscribeFetch(ctx context.Context, f Filter) iter.Seq2[FetchResult, error] {
+    return subscribe(ctx, c, "/v1/fetch/sse", f.query(), "fetch", func(r FetchResult) string { return r.RepoName })
+}
+
+// SubscribeEvents iterates over workspace events from /v1/events as they
+// are logged, only those of types when given. With after set, logged
+// events after that ID are replayed first. A dropped connection resumes
+// after the last event received.
+func (c *Client) SubscribeEvents(ctx context.Context, types []string, after string) iter.Seq2[Event, error] {
+    q := url.Values{}
+    if len(types) > 0 { q.Set("types", strings.Join(types, ",")) }
+    if after != "" { q.Set("after", after) }
+    return subscribe[Event](ctx, c, "/v1/events", q, "", nil)
+}
+
+// streamClient is c.HTTPClient without its overall timeout, which would
+// cut long streams short; streams end with their context
+func (c *Client) streamClient() *http.Client {
+    hc := *c.HTTPClient
+    hc.Timeout = 0
+    return &hc
+}
+
+// subscribe iterates over the data of the named events of an SSE stream,
+// or of every event when event is empty.
+// When the connection drops it reconnects with Last-Event-ID, up to the
+// client's retry limit without an event in between. With key set, items
+// whose key was already yielded as often on an earlier connection are
+// skipped.
+func subscribe[T any](ctx context.Context, c *Client, path string, q url.Values, event string, key func(T) string) iter.Seq2[T, error] {
+    return func(yield func(T, error) bool) {
+        var zero T
+        hc := c.streamClient()
+        lastID := ""
+        yielded := map[string]int{}
+        for failures := 0; ; {
+            resp, err := c.do(ctx, hc, http.MethodGet, path, q, nil, "text/event-stream", lastID)
+            if err != nil { yield(zero, err); return }
+            again := maps.Clone(yielded)
+            stopped := false
+            err = readSSE(resp.Body, func(ev sseEvent) bool {
+                if ev.id != "" { lastID = ev.id }
+                if event != "" && ev.event != event { return true }
+                failures = 0
+                var v T
+                if err := json.Unmarshal([]byte(ev.data), &v); err != nil {
+                    yield(zero, err)
+                    stopped = true
+                    return false
+                }
+                if key != nil {
+                    k := key(v)
+                    if again[k] > 0 { again[k]--; return true }
+                    yielded[k]++
+                }
+                if !yield(v, nil) { stopped = true; return false }
+                return true
+            })
+            resp.Body.Close()
+            if stopped || err == nil { return }
+            if ctx.Err() != nil { yield(zero, ctx.Err()); return }
+            if failures >= c.retries { yield(zero, err); return }
+            if err := c.wait(ctx, failures, ""); err != nil { yield(zero, err); return }
+            failures++
+        }
+    }
+}
+
+type sseEvent struct {
+    id, event, data string
+}
+
+// readSSE calls fn for each event in r until fn returns false. It returns
+// nil when r ends cleanly and the read error when the stream is cut.
+func readSSE(r io.Reader, fn func(sseEvent) bool) error {
+    sc := bufio.NewScanner(r)
+    sc.Buffer(make([]byte, 0, 64<<10), 4<<20)
+    var ev sseEvent
+    var data []string
+    for sc.Scan() {
+        line := sc.Text()
+        if line == "" {
+            if data != nil {
+                if ev.event == "" { ev.event = "message" }
+                ev.data = strings.Join(data, "\n")
+                if !fn(ev) { return nil }
+            }
+            ev, data = sseEvent{}, nil
+            continue
+        }
+        field, value, _ := strings.Cut(line, ":")
+        value = strings.TrimPrefix(value, " ")
+        switch field {
+        case "id":
+            ev.id = value
+        case "event":
+            ev.event = value
+        case "data":
+            data = append(data, value)
+        }
+    }
+    return sc.Err()
+}